@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"complaint-monitor/internal/config"
+	"complaint-monitor/internal/logger"
+	"complaint-monitor/internal/repository"
+	"complaint-monitor/internal/service"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const blacklistUsage = `用法:
+  complaint-monitor [-config 配置文件] blacklist import -file 文件 [-format csv|jsonl] [-dry-run] [-batch 500]
+  complaint-monitor [-config 配置文件] blacklist export -file 文件 [-format csv|jsonl] [-min-risk-count N] [-from 日期] [-to 日期] [-subject-id ID]
+  complaint-monitor [-config 配置文件] blacklist verify-audit [-batch 1000]
+
+日期格式为 2006-01-02 或 2006-01-02 15:04:05，-from/-to 按最后触发时间（last_risk_time）筛选，-to 不含`
+
+// runBlacklistCommand 执行黑名单导入导出子命令，返回进程退出码
+func runBlacklistCommand(configPath string, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, blacklistUsage)
+		return 2
+	}
+
+	cfg, err := config.LoadWithDefaults(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ 加载配置失败: %v\n", err)
+		return 1
+	}
+
+	// 子命令只输出错误日志，避免与执行结果混在一起
+	log, err := logger.NewLogger("error")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ 初始化日志失败: %v\n", err)
+		return 1
+	}
+	defer log.Sync()
+
+	database, err := repository.NewDatabase(&cfg.Database, log)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ 初始化数据库失败: %v\n", err)
+		return 1
+	}
+	defer database.Close()
+
+	blacklistRepo := repository.NewBlacklistRepository(database.GetDB(), log)
+	auditor := service.NewBlacklistAuditor(repository.NewBlacklistAuditRepository(database.GetDB(), log), log)
+	allowlist := service.NewAllowlistService(repository.NewBuyerAllowlistRepository(database.GetDB(), log), log)
+	transfer := service.NewBlacklistTransfer(blacklistRepo, allowlist, auditor, cfg.Blacklist, log)
+
+	switch args[0] {
+	case "import":
+		// 开启Redis镜像时，导入的记录实时刷新镜像并发布到 blacklist:changes
+		if cfg.Blacklist.MirrorEnabled {
+			redisClient, err := connectMirrorRedis(cfg)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+				return 1
+			}
+			defer redisClient.Close()
+			rangeRepo := repository.NewBlacklistIPRangeRepository(database.GetDB(), log)
+			transfer.AddListener(service.NewBlacklistMirror(redisClient, blacklistRepo, rangeRepo, cfg.Blacklist, log))
+		}
+		err = runBlacklistImport(transfer, args[1:])
+	case "export":
+		err = runBlacklistExport(transfer, args[1:])
+	case "verify-audit":
+		err = runBlacklistVerifyAudit(auditor, args[1:])
+	default:
+		fmt.Fprintln(os.Stderr, blacklistUsage)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// connectMirrorRedis 连接黑名单镜像使用的Redis
+func connectMirrorRedis(cfg *config.Config) (*redis.Client, error) {
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.GetAddress(),
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		redisClient.Close()
+		return nil, fmt.Errorf("Redis连接失败: %w", err)
+	}
+	return redisClient, nil
+}
+
+// runBlacklistImport 导入黑名单并输出汇总
+func runBlacklistImport(transfer *service.BlacklistTransfer, args []string) error {
+	fs := flag.NewFlagSet("blacklist import", flag.ContinueOnError)
+	file := fs.String("file", "", "导入文件路径")
+	format := fs.String("format", "", "文件格式（csv/jsonl，默认按扩展名判断）")
+	dryRun := fs.Bool("dry-run", false, "只校验并统计将新增和更新的记录数，不写库")
+	batch := fs.Int("batch", 500, "每批写入的记录数")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" || *batch <= 0 {
+		return fmt.Errorf("-file 不能为空，-batch 必须大于0")
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return fmt.Errorf("打开导入文件失败: %w", err)
+	}
+	defer f.Close()
+
+	summary, err := transfer.Import(f, blacklistFileFormat(*format, *file), filepath.Base(*file), *dryRun, *batch)
+	if summary != nil {
+		output, _ := json.MarshalIndent(summary, "", "  ")
+		fmt.Println(string(output))
+	}
+	if err != nil {
+		return fmt.Errorf("导入黑名单失败: %w", err)
+	}
+	return nil
+}
+
+// runBlacklistExport 按筛选条件导出黑名单
+func runBlacklistExport(transfer *service.BlacklistTransfer, args []string) error {
+	fs := flag.NewFlagSet("blacklist export", flag.ContinueOnError)
+	file := fs.String("file", "", "导出文件路径")
+	format := fs.String("format", "", "文件格式（csv/jsonl，默认按扩展名判断）")
+	minRiskCount := fs.Int("min-risk-count", 0, "风险计数下限（含）")
+	from := fs.String("from", "", "最后触发时间下限（含）")
+	to := fs.String("to", "", "最后触发时间上限（不含）")
+	subjectID := fs.Int("subject-id", 0, "只导出投诉过该主体的买家")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return fmt.Errorf("-file 不能为空")
+	}
+
+	filter := repository.BlacklistExportFilter{
+		MinRiskCount: *minRiskCount,
+		SubjectID:    *subjectID,
+	}
+	var err error
+	if filter.From, err = parseCommandDate(*from); err != nil {
+		return fmt.Errorf("-from 格式错误: %w", err)
+	}
+	if filter.To, err = parseCommandDate(*to); err != nil {
+		return fmt.Errorf("-to 格式错误: %w", err)
+	}
+
+	f, err := os.Create(*file)
+	if err != nil {
+		return fmt.Errorf("创建导出文件失败: %w", err)
+	}
+	defer f.Close()
+
+	count, err := transfer.Export(f, blacklistFileFormat(*format, *file), filter, 1000)
+	if err != nil {
+		return fmt.Errorf("导出黑名单失败: %w", err)
+	}
+	fmt.Printf("✅ 已导出 %d 条黑名单记录到 %s\n", count, *file)
+	return nil
+}
+
+// runBlacklistVerifyAudit 校验黑名单审计哈希链，发现问题时返回错误
+func runBlacklistVerifyAudit(auditor *service.BlacklistAuditor, args []string) error {
+	fs := flag.NewFlagSet("blacklist verify-audit", flag.ContinueOnError)
+	batch := fs.Int("batch", 1000, "每批读取的记录数")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *batch <= 0 {
+		return fmt.Errorf("-batch 必须大于0")
+	}
+
+	result, err := auditor.Verify(*batch)
+	if err != nil {
+		return fmt.Errorf("校验审计记录失败: %w", err)
+	}
+	output, _ := json.MarshalIndent(result, "", "  ")
+	fmt.Println(string(output))
+
+	if !result.OK() {
+		return fmt.Errorf("审计哈希链校验失败，发现%d处问题", len(result.Problems))
+	}
+	fmt.Printf("✅ 已校验 %d 条审计记录，哈希链完整（链尾 seq=%d）\n", result.Checked, result.LastSeq)
+	return nil
+}
+
+// blacklistFileFormat 未指定格式时按扩展名判断（.jsonl/.ndjson 为 JSONL，其余为 CSV）
+func blacklistFileFormat(format, file string) string {
+	if format != "" {
+		return format
+	}
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".jsonl", ".ndjson":
+		return service.BlacklistFormatJSONL
+	default:
+		return service.BlacklistFormatCSV
+	}
+}
+
+// parseCommandDate 解析命令行日期（空字符串返回零值）
+func parseCommandDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无法解析日期: %s", value)
+}
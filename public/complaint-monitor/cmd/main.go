@@ -10,12 +10,18 @@ import (
 	"syscall"
 	"time"
 
+	"complaint-monitor/internal/api"
 	"complaint-monitor/internal/cert"
+	"complaint-monitor/internal/cluster"
 	"complaint-monitor/internal/config"
+	"complaint-monitor/internal/gateway"
+	"complaint-monitor/internal/gateway/simulator"
 	"complaint-monitor/internal/lock"
 	"complaint-monitor/internal/logger"
+	"complaint-monitor/internal/ratelimit"
 	"complaint-monitor/internal/repository"
 	"complaint-monitor/internal/service"
+	"complaint-monitor/internal/watermark"
 	"complaint-monitor/internal/worker"
 	"complaint-monitor/pkg/metrics"
 	"complaint-monitor/pkg/monitor"
@@ -35,6 +41,11 @@ func main() {
 	// 解析命令行参数
 	flag.Parse()
 
+	// 黑名单导入导出子命令
+	if flag.Arg(0) == "blacklist" {
+		os.Exit(runBlacklistCommand(*configPath, flag.Args()[1:]))
+	}
+
 	// 显示版本信息
 	fmt.Printf("投诉监控系统 (Complaint Monitor) %s\n", version)
 	fmt.Printf("构建时间: %s\n", buildTime)
@@ -103,6 +114,12 @@ func main() {
 	complaintRepo := repository.NewComplaintRepository(db, log)
 	blacklistRepo := repository.NewBlacklistRepository(db, log)
 	orderRepo := repository.NewOrderRepository(db, log)
+	syncStateRepo := repository.NewSyncStateRepository(db, log)
+	retryRepo := repository.NewRetryRepository(db, log)
+	blacklistDecisionRepo := repository.NewBlacklistDecisionRepository(db, log)
+	blacklistRangeRepo := repository.NewBlacklistIPRangeRepository(db, log)
+	blacklistAuditRepo := repository.NewBlacklistAuditRepository(db, log)
+	allowlistRepo := repository.NewBuyerAllowlistRepository(db, log)
 
 	// 初始化证书管理器
 	certManager := cert.NewCertManager(
@@ -111,6 +128,14 @@ func main() {
 		log,
 	)
 
+	// 初始化投诉网关（默认使用证书管理器创建的SDK网关，本地开发可切换为模拟网关）
+	var gateways gateway.Provider = certManager
+	if cfg.Gateway.UseSimulator() {
+		log.Warn("⚠️ 使用模拟投诉网关，不会调用真实支付宝接口",
+			zap.String("simulator_url", cfg.Gateway.SimulatorURL))
+		gateways = simulator.NewProvider(cfg.Gateway.SimulatorURL, nil)
+	}
+
 	// 初始化分布式锁
 	lockManager := lock.NewDistributedLock(
 		redisClient,
@@ -119,10 +144,50 @@ func main() {
 		log,
 	)
 
+	// 初始化同步水位存储
+	watermarkStore := watermark.NewStore(redisClient, syncStateRepo, log)
+
 	// 初始化服务层
-	alipayService := service.NewAlipayService(log)
+	var limiter *ratelimit.Limiter
+	if cfg.RateLimit.Enabled {
+		limiter = ratelimit.NewLimiter(redisClient, cfg.RateLimit, log)
+	}
+	alipayService := service.NewAlipayService(limiter, log)
 	notificationService := service.NewNotificationService(db, log)
-	blacklistService := service.NewBlacklistService(blacklistRepo, complaintRepo, notificationService, log)
+	riskScorer := service.NewRiskScorer(complaintRepo, log)
+	blacklistRuleEngine := service.NewBlacklistRuleEngine(cfg.BlacklistRules)
+	blacklistAuditor := service.NewBlacklistAuditor(blacklistAuditRepo, log)
+	allowlistService := service.NewAllowlistService(allowlistRepo, log)
+	blacklistService := service.NewBlacklistService(blacklistRepo, complaintRepo, blacklistDecisionRepo, blacklistRuleEngine, riskScorer, notificationService, blacklistAuditor, allowlistService, lockManager, cfg.Blacklist, log)
+	identityResolver := service.NewIdentityResolver(orderRepo, subjectRepo, alipayService, cfg.Identity, log)
+	complaintNotifier := service.NewComplaintNotifier(notificationService, riskScorer, cfg.Notification, log)
+	blacklistIndex := service.NewBlacklistIndex(blacklistRepo, blacklistRangeRepo, cfg.Blacklist, log)
+	if err := blacklistIndex.Reload(); err != nil {
+		log.Fatal("加载黑名单内存索引失败", zap.Error(err))
+	}
+	// 网段服务依赖内存索引统计网段内的黑名单IP，需在内存索引之后注册
+	ipRangeService := service.NewIPRangeService(blacklistRangeRepo, blacklistIndex, allowlistService, cfg.Blacklist, log)
+	blacklistService.AddListener(blacklistIndex)
+	blacklistService.AddListener(ipRangeService)
+	ipRangeService.AddListener(blacklistIndex)
+	var blacklistMirror *service.BlacklistMirror
+	if cfg.Blacklist.MirrorEnabled {
+		blacklistMirror = service.NewBlacklistMirror(redisClient, blacklistRepo, blacklistRangeRepo, cfg.Blacklist, log)
+		blacklistService.AddListener(blacklistMirror)
+		ipRangeService.AddListener(blacklistMirror)
+	}
+	buyerGraphService := service.NewBuyerGraphService(orderRepo, blacklistIndex, blacklistService, lockManager, redisClient, cfg.BuyerGraph, log)
+	retryService := service.NewRetryService(retryRepo, notificationService, cfg.Retry, log)
+	complaintHandleService := service.NewComplaintHandleService(complaintRepo, subjectRepo, gateways, alipayService, log)
+
+	// 初始化集群成员管理（多实例分片）
+	var membership *cluster.Membership
+	if cfg.Cluster.Enabled {
+		membership = cluster.NewMembership(redisClient, cfg.Cluster, log)
+		if err := membership.Join(context.Background()); err != nil {
+			log.Fatal("加入集群失败", zap.Error(err))
+		}
+	}
 
 	// 初始化Worker管理器
 	workerManager := worker.NewManager(
@@ -131,10 +196,15 @@ func main() {
 		complaintRepo,
 		blacklistRepo,
 		orderRepo,
-		certManager,
+		gateways,
 		lockManager,
 		alipayService,
 		blacklistService,
+		identityResolver,
+		complaintNotifier,
+		watermarkStore,
+		retryService,
+		membership,
 		log,
 	)
 
@@ -142,9 +212,27 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// 启动集群心跳
+	if membership != nil {
+		go membership.Run(ctx)
+	}
+
 	// 启动Worker管理器
 	go workerManager.Start(ctx)
 
+	// 启动黑名单过期清理、内存索引同步和Redis镜像同步
+	go blacklistService.RunExpirySweeper(ctx)
+	go ipRangeService.RunExpirySweeper(ctx)
+	go blacklistIndex.Run(ctx)
+	if blacklistMirror != nil {
+		go blacklistMirror.Run(ctx)
+	}
+
+	// 启动买家关联分析
+	if cfg.BuyerGraph.Enabled {
+		go buyerGraphService.Run(ctx)
+	}
+
 	// 初始化系统指标采集器
 	systemCollector := monitor.NewSystemCollector(log)
 	go systemCollector.Start(ctx)
@@ -191,6 +279,56 @@ func main() {
 		}
 	}()
 
+	// 启动管理接口服务
+	complaintHandler := api.NewComplaintHandler(complaintHandleService, log)
+	apiMux := http.NewServeMux()
+	apiMux.HandleFunc("/api/complaint/finish", api.RequireToken(cfg.API.AuthToken, log, complaintHandler.HandleFinish()))
+	apiMux.HandleFunc("/api/complaint/reply", api.RequireToken(cfg.API.AuthToken, log, complaintHandler.HandleReply()))
+	apiMux.HandleFunc("/api/complaint/status-logs", api.RequireToken(cfg.API.AuthToken, log, complaintHandler.HandleStatusHistory()))
+	blacklistHandler := api.NewBlacklistHandler(blacklistService, blacklistIndex, buyerGraphService, blacklistAuditor, log)
+	apiMux.HandleFunc("/api/blacklist/review", api.RequireToken(cfg.API.AuthToken, log, blacklistHandler.HandleListReview()))
+	apiMux.HandleFunc("/api/blacklist/check", api.RequireToken(cfg.API.AuthToken, log, blacklistHandler.HandleCheck()))
+	apiMux.HandleFunc("/api/blacklist/clusters", api.RequireToken(cfg.API.AuthToken, log, blacklistHandler.HandleListClusters()))
+	apiMux.HandleFunc("/api/blacklist/update", api.RequireToken(cfg.API.AuthToken, log, blacklistHandler.HandleUpdate()))
+	apiMux.HandleFunc("/api/blacklist/remove", api.RequireToken(cfg.API.AuthToken, log, blacklistHandler.HandleRemove()))
+	apiMux.HandleFunc("/api/blacklist/audit", api.RequireToken(cfg.API.AuthToken, log, blacklistHandler.HandleAudit()))
+	ipRangeHandler := api.NewIPRangeHandler(ipRangeService, log)
+	apiMux.HandleFunc("/api/blacklist/ranges", api.RequireToken(cfg.API.AuthToken, log, ipRangeHandler.HandleList()))
+	apiMux.HandleFunc("/api/blacklist/ranges/add", api.RequireToken(cfg.API.AuthToken, log, ipRangeHandler.HandleAdd()))
+	apiMux.HandleFunc("/api/blacklist/ranges/remove", api.RequireToken(cfg.API.AuthToken, log, ipRangeHandler.HandleRemove()))
+	allowlistHandler := api.NewAllowlistHandler(allowlistService, log)
+	apiMux.HandleFunc("/api/blacklist/allowlist", api.RequireToken(cfg.API.AuthToken, log, allowlistHandler.HandleList()))
+	apiMux.HandleFunc("/api/blacklist/allowlist/add", api.RequireToken(cfg.API.AuthToken, log, allowlistHandler.HandleAdd()))
+	apiMux.HandleFunc("/api/blacklist/allowlist/remove", api.RequireToken(cfg.API.AuthToken, log, allowlistHandler.HandleRemove()))
+	retryHandler := api.NewRetryHandler(retryService, log)
+	apiMux.HandleFunc("/api/complaint/retry/dead", api.RequireToken(cfg.API.AuthToken, log, retryHandler.HandleListDead()))
+	apiMux.HandleFunc("/api/complaint/retry/requeue", api.RequireToken(cfg.API.AuthToken, log, retryHandler.HandleRequeue()))
+	rescanHandler := api.NewRescanHandler(subjectRepo, workerManager, log)
+	apiMux.HandleFunc("/api/complaint/rescan", api.RequireToken(cfg.API.AuthToken, log, rescanHandler.HandleRescan()))
+
+	// 支付宝投诉消息通知（通过主体证书验签，不使用令牌鉴权）
+	notifyHandler := api.NewNotifyHandler(subjectRepo, gateways, workerManager, log)
+	apiMux.HandleFunc(cfg.API.NotifyPath, notifyHandler.HandleComplaintNotify())
+
+	apiServer := &http.Server{
+		Addr:              cfg.API.GetAddress(),
+		Handler:           apiMux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		log.Info("🛠 管理接口服务启动",
+			zap.String("address", apiServer.Addr),
+			zap.Bool("auth_enabled", cfg.API.AuthToken != ""))
+
+		if cfg.API.AuthToken == "" {
+			log.Warn("管理接口未配置auth_token，所有管理请求将被拒绝")
+		}
+
+		if err := apiServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Error("管理接口服务启动失败", zap.Error(err))
+		}
+	}()
+
 	// 更新初始指标
 	metrics.UpdateWorkerTotal(workerManager.GetWorkerCount())
 
@@ -226,7 +364,7 @@ func main() {
 			defer shutdownCancel()
 
 			// 执行优雅关闭
-			if err := gracefulShutdown(shutdownCtx, log, workerManager, database, redisClient, metricsServer, healthServer, systemCollector); err != nil {
+			if err := gracefulShutdown(shutdownCtx, log, workerManager, membership, database, redisClient, metricsServer, healthServer, apiServer, systemCollector); err != nil {
 				log.Error("优雅关闭失败", zap.Error(err))
 				os.Exit(1)
 			}
@@ -248,10 +386,12 @@ func gracefulShutdown(
 	ctx context.Context,
 	log *zap.Logger,
 	workerManager *worker.Manager,
+	membership *cluster.Membership,
 	database *repository.Database,
 	redisClient *redis.Client,
 	metricsServer *http.Server,
 	healthServer *http.Server,
+	apiServer *http.Server,
 	systemCollector *monitor.SystemCollector,
 ) error {
 	log.Info("开始执行优雅关闭...")
@@ -260,6 +400,11 @@ func gracefulShutdown(
 	workerManager.Stop()
 	log.Info("Worker管理器已停止")
 
+	// 退出集群（其他实例将接管本实例负责的主体）
+	if membership != nil {
+		membership.Stop(ctx)
+	}
+
 	// 停止系统指标采集器
 	systemCollector.Stop()
 	log.Info("系统指标采集器已停止")
@@ -278,6 +423,13 @@ func gracefulShutdown(
 		log.Info("健康检查服务已关闭")
 	}
 
+	// 关闭管理接口服务
+	if err := apiServer.Shutdown(ctx); err != nil {
+		log.Error("关闭管理接口服务失败", zap.Error(err))
+	} else {
+		log.Info("管理接口服务已关闭")
+	}
+
 	// 关闭Redis连接
 	if err := redisClient.Close(); err != nil {
 		log.Error("关闭Redis连接失败", zap.Error(err))
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"time"
+
+	"complaint-monitor/internal/gateway/simulator"
+)
+
+var (
+	addr         = flag.String("addr", ":8099", "模拟网关监听地址")
+	scenarioName = flag.String("scenario", "paged", "模拟场景（empty/paged/page_boundary/rate_limited/multi_order）")
+	total        = flag.Int("total", 450, "投诉数量")
+)
+
+// 本地模拟支付宝投诉网关
+// 配合 gateway.simulator_url 配置使用，无需生产证书即可跑通投诉拉取、入库和拉黑流程
+func main() {
+	flag.Parse()
+
+	scenario, err := simulator.ScenarioByName(*scenarioName, *total)
+	if err != nil {
+		log.Fatalf("创建模拟场景失败: %v", err)
+	}
+
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           simulator.New(scenario),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	log.Printf("模拟投诉网关启动: addr=%s, scenario=%s, total=%d", *addr, scenario.Name, len(scenario.Complaints))
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatalf("模拟投诉网关启动失败: %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"complaint-monitor/internal/service"
+
+	"go.uber.org/zap"
+)
+
+// AllowlistHandler 买家白名单管理接口
+type AllowlistHandler struct {
+	allowlistService *service.AllowlistService
+	logger           *zap.Logger
+}
+
+// NewAllowlistHandler 创建买家白名单管理接口
+func NewAllowlistHandler(allowlistService *service.AllowlistService, logger *zap.Logger) *AllowlistHandler {
+	return &AllowlistHandler{
+		allowlistService: allowlistService,
+		logger:           logger,
+	}
+}
+
+// AddAllowlistRequest 添加白名单请求
+type AddAllowlistRequest struct {
+	MatchType string `json:"match_type"` // 匹配类型（user/ip/device）
+	Value     string `json:"value"`      // 支付宝用户ID、IP或设备码
+	Reason    string `json:"reason"`     // 加入白名单的原因
+	ExpireAt  string `json:"expire_at"`  // 过期时间（2006-01-02 15:04:05，为空表示永久有效）
+	HandlerID int    `json:"handler_id"` // 处理人ID
+}
+
+// RemoveAllowlistRequest 删除白名单请求
+type RemoveAllowlistRequest struct {
+	ID uint `json:"id"` // 白名单ID
+}
+
+// HandleList 查询所有白名单（GET）
+func (h *AllowlistHandler) HandleList() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, h.logger, http.StatusMethodNotAllowed, "仅支持GET请求")
+			return
+		}
+
+		entries, err := h.allowlistService.List()
+		if err != nil {
+			h.logger.Error("查询白名单失败", zap.Error(err))
+			writeError(w, h.logger, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		writeJSON(w, h.logger, http.StatusOK, Response{
+			Message: "ok",
+			Data:    entries,
+		})
+	}
+}
+
+// HandleAdd 添加白名单（POST）
+func (h *AllowlistHandler) HandleAdd() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, h.logger, http.StatusMethodNotAllowed, "仅支持POST请求")
+			return
+		}
+
+		var req AddAllowlistRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, h.logger, http.StatusBadRequest, "请求参数格式错误")
+			return
+		}
+		if req.MatchType == "" || req.Value == "" {
+			writeError(w, h.logger, http.StatusBadRequest, "match_type和value不能为空")
+			return
+		}
+
+		var expireAt *time.Time
+		if req.ExpireAt != "" {
+			t, err := time.ParseInLocation("2006-01-02 15:04:05", req.ExpireAt, time.Local)
+			if err != nil {
+				writeError(w, h.logger, http.StatusBadRequest, "expire_at格式错误")
+				return
+			}
+			expireAt = &t
+		}
+
+		entry, err := h.allowlistService.Add(req.MatchType, req.Value, req.Reason, expireAt, req.HandlerID)
+		if err != nil {
+			h.logger.Error("添加白名单失败",
+				zap.String("match_type", req.MatchType),
+				zap.String("value", req.Value),
+				zap.Error(err))
+			writeError(w, h.logger, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		writeJSON(w, h.logger, http.StatusOK, Response{
+			Message: "已添加",
+			Data:    entry,
+		})
+	}
+}
+
+// HandleRemove 删除白名单（POST）
+func (h *AllowlistHandler) HandleRemove() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, h.logger, http.StatusMethodNotAllowed, "仅支持POST请求")
+			return
+		}
+
+		var req RemoveAllowlistRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, h.logger, http.StatusBadRequest, "请求参数格式错误")
+			return
+		}
+		if req.ID == 0 {
+			writeError(w, h.logger, http.StatusBadRequest, "id不能为空")
+			return
+		}
+
+		entry, err := h.allowlistService.Remove(req.ID)
+		if err != nil {
+			h.logger.Error("删除白名单失败", zap.Uint("allowlist_id", req.ID), zap.Error(err))
+			writeError(w, h.logger, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if entry == nil {
+			writeError(w, h.logger, http.StatusNotFound, "白名单不存在")
+			return
+		}
+
+		writeJSON(w, h.logger, http.StatusOK, Response{Message: "已删除", Data: entry})
+	}
+}
@@ -0,0 +1,284 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"complaint-monitor/internal/model"
+	"complaint-monitor/internal/service"
+
+	"go.uber.org/zap"
+)
+
+// BlacklistHandler 黑名单管理接口
+type BlacklistHandler struct {
+	blacklistService *service.BlacklistService
+	index            *service.BlacklistIndex
+	buyerGraph       *service.BuyerGraphService
+	auditor          *service.BlacklistAuditor
+	logger           *zap.Logger
+}
+
+// NewBlacklistHandler 创建黑名单管理接口
+func NewBlacklistHandler(blacklistService *service.BlacklistService, index *service.BlacklistIndex, buyerGraph *service.BuyerGraphService, auditor *service.BlacklistAuditor, logger *zap.Logger) *BlacklistHandler {
+	return &BlacklistHandler{
+		blacklistService: blacklistService,
+		index:            index,
+		buyerGraph:       buyerGraph,
+		auditor:          auditor,
+		logger:           logger,
+	}
+}
+
+// ReviewList 待人工审核列表响应
+type ReviewList struct {
+	Total int64                      `json:"total"`
+	Page  int                        `json:"page"`
+	Items []*model.BlacklistDecision `json:"items"`
+}
+
+// HandleListReview 查询拉黑规则标记为人工审核的投诉（GET，参数：subject_id、page、page_size）
+func (h *BlacklistHandler) HandleListReview() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, h.logger, http.StatusMethodNotAllowed, "仅支持GET请求")
+			return
+		}
+
+		query := r.URL.Query()
+		subjectID, _ := strconv.Atoi(query.Get("subject_id"))
+		page, _ := strconv.Atoi(query.Get("page"))
+		pageSize, _ := strconv.Atoi(query.Get("page_size"))
+		if page < 1 {
+			page = 1
+		}
+
+		items, total, err := h.blacklistService.ListReview(subjectID, page, pageSize)
+		if err != nil {
+			h.logger.Error("查询待审核列表失败", zap.Error(err))
+			writeError(w, h.logger, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		writeJSON(w, h.logger, http.StatusOK, Response{
+			Message: "ok",
+			Data:    ReviewList{Total: total, Page: page, Items: items},
+		})
+	}
+}
+
+// HandleCheck 查询买家ID、IP、设备码是否命中黑名单（GET，参数：buyer_id、ip、device_code，至少一个）
+// 供收银台支付前调用，直接查询内存索引，不访问数据库
+func (h *BlacklistHandler) HandleCheck() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, h.logger, http.StatusMethodNotAllowed, "仅支持GET请求")
+			return
+		}
+
+		query := r.URL.Query()
+		buyerID := query.Get("buyer_id")
+		ipAddress := query.Get("ip")
+		deviceCode := query.Get("device_code")
+		if buyerID == "" && ipAddress == "" && deviceCode == "" {
+			writeError(w, h.logger, http.StatusBadRequest, "buyer_id、ip、device_code不能同时为空")
+			return
+		}
+
+		result := h.index.Check(buyerID, ipAddress, deviceCode, time.Now())
+		if result.Blocked {
+			h.logger.Info("黑名单查询命中",
+				zap.String("buyer_id", buyerID),
+				zap.String("ip", ipAddress),
+				zap.String("risk_level", string(result.RiskLevel)),
+				zap.Int("match_count", len(result.Matches)))
+		}
+
+		writeJSON(w, h.logger, http.StatusOK, Response{
+			Message: "ok",
+			Data:    result,
+		})
+	}
+}
+
+// HandleListClusters 查询最近一次买家关联分析发现的团伙（GET，参数：flagged=1 只返回包含黑名单账号的团伙，buyer_id 只返回包含该买家的团伙）
+func (h *BlacklistHandler) HandleListClusters() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, h.logger, http.StatusMethodNotAllowed, "仅支持GET请求")
+			return
+		}
+
+		snapshot, err := h.buyerGraph.Snapshot(r.Context())
+		if err != nil {
+			h.logger.Error("查询买家关联团伙失败", zap.Error(err))
+			writeError(w, h.logger, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if snapshot == nil {
+			writeError(w, h.logger, http.StatusNotFound, "尚未执行买家关联分析")
+			return
+		}
+
+		query := r.URL.Query()
+		flaggedOnly := query.Get("flagged") == "1"
+		buyerID := query.Get("buyer_id")
+
+		clusters := make([]service.BuyerCluster, 0, len(snapshot.Clusters))
+		for _, cluster := range snapshot.Clusters {
+			if flaggedOnly && !cluster.Flagged {
+				continue
+			}
+			if buyerID != "" && !slices.Contains(cluster.BuyerIDs, buyerID) {
+				continue
+			}
+			clusters = append(clusters, cluster)
+		}
+		snapshot.Clusters = clusters
+
+		writeJSON(w, h.logger, http.StatusOK, Response{
+			Message: "ok",
+			Data:    snapshot,
+		})
+	}
+}
+
+// UpdateBlacklistRequest 手动修改黑名单请求（字段为空表示不修改）
+type UpdateBlacklistRequest struct {
+	ID        uint    `json:"id"`         // 黑名单记录ID
+	RiskLevel string  `json:"risk_level"` // 风险等级（可以调低）
+	ExpireAt  string  `json:"expire_at"`  // 过期时间（2006-01-02 15:04:05）
+	Permanent bool    `json:"permanent"`  // 改为永久有效
+	Remark    *string `json:"remark"`     // 备注
+	Reason    string  `json:"reason"`     // 修改原因（记录到审计）
+	HandlerID int     `json:"handler_id"` // 处理人ID
+}
+
+// RemoveBlacklistRequest 手动解除拉黑请求
+type RemoveBlacklistRequest struct {
+	ID        uint   `json:"id"`         // 黑名单记录ID
+	Reason    string `json:"reason"`     // 解除原因（记录到审计）
+	HandlerID int    `json:"handler_id"` // 处理人ID
+}
+
+// AuditRecord 审计记录及其Hash校验结果
+type AuditRecord struct {
+	*model.BlacklistAudit
+	HashValid bool `json:"hash_valid"` // 记录内容与Hash是否一致（整条链的校验使用 blacklist verify-audit 命令）
+}
+
+// HandleUpdate 手动修改黑名单的风险等级、过期时间和备注（POST）
+func (h *BlacklistHandler) HandleUpdate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, h.logger, http.StatusMethodNotAllowed, "仅支持POST请求")
+			return
+		}
+
+		var req UpdateBlacklistRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, h.logger, http.StatusBadRequest, "请求参数格式错误")
+			return
+		}
+		if req.ID == 0 || req.HandlerID == 0 {
+			writeError(w, h.logger, http.StatusBadRequest, "id和handler_id不能为空")
+			return
+		}
+
+		edit := service.BlacklistEdit{
+			Permanent: req.Permanent,
+			Remark:    req.Remark,
+		}
+		if req.RiskLevel != "" {
+			level := service.RiskLevel(req.RiskLevel)
+			edit.RiskLevel = &level
+		}
+		if req.ExpireAt != "" {
+			expireAt, err := time.ParseInLocation("2006-01-02 15:04:05", req.ExpireAt, time.Local)
+			if err != nil {
+				writeError(w, h.logger, http.StatusBadRequest, "expire_at格式错误")
+				return
+			}
+			edit.ExpireAt = &expireAt
+		}
+
+		entry, err := h.blacklistService.UpdateEntry(req.ID, edit, req.HandlerID, req.Reason)
+		if err != nil {
+			h.logger.Error("修改黑名单失败", zap.Uint("blacklist_id", req.ID), zap.Error(err))
+			writeError(w, h.logger, http.StatusBadRequest, err.Error())
+			return
+		}
+		if entry == nil {
+			writeError(w, h.logger, http.StatusNotFound, "黑名单记录不存在")
+			return
+		}
+
+		writeJSON(w, h.logger, http.StatusOK, Response{Message: "已修改", Data: entry})
+	}
+}
+
+// HandleRemove 手动解除拉黑（POST）
+func (h *BlacklistHandler) HandleRemove() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, h.logger, http.StatusMethodNotAllowed, "仅支持POST请求")
+			return
+		}
+
+		var req RemoveBlacklistRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, h.logger, http.StatusBadRequest, "请求参数格式错误")
+			return
+		}
+		if req.ID == 0 || req.HandlerID == 0 {
+			writeError(w, h.logger, http.StatusBadRequest, "id和handler_id不能为空")
+			return
+		}
+
+		entry, err := h.blacklistService.RemoveEntry(req.ID, req.HandlerID, req.Reason)
+		if err != nil {
+			h.logger.Error("解除拉黑失败", zap.Uint("blacklist_id", req.ID), zap.Error(err))
+			writeError(w, h.logger, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if entry == nil {
+			writeError(w, h.logger, http.StatusNotFound, "黑名单记录不存在")
+			return
+		}
+
+		writeJSON(w, h.logger, http.StatusOK, Response{Message: "已解除", Data: entry})
+	}
+}
+
+// HandleAudit 查询买家的全部黑名单变更记录（GET，参数：buyer_id）
+func (h *BlacklistHandler) HandleAudit() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, h.logger, http.StatusMethodNotAllowed, "仅支持GET请求")
+			return
+		}
+
+		buyerID := r.URL.Query().Get("buyer_id")
+		if buyerID == "" {
+			writeError(w, h.logger, http.StatusBadRequest, "buyer_id不能为空")
+			return
+		}
+
+		audits, err := h.auditor.History(buyerID)
+		if err != nil {
+			h.logger.Error("查询黑名单审计记录失败", zap.String("buyer_id", buyerID), zap.Error(err))
+			writeError(w, h.logger, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		records := make([]AuditRecord, 0, len(audits))
+		for _, audit := range audits {
+			records = append(records, AuditRecord{BlacklistAudit: audit, HashValid: audit.Hash == audit.ComputeHash()})
+		}
+
+		writeJSON(w, h.logger, http.StatusOK, Response{Message: "ok", Data: records})
+	}
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"complaint-monitor/internal/model"
+	"complaint-monitor/internal/service"
+
+	"go.uber.org/zap"
+)
+
+// ComplaintHandler 投诉处理接口
+type ComplaintHandler struct {
+	handleService *service.ComplaintHandleService
+	logger        *zap.Logger
+}
+
+// NewComplaintHandler 创建投诉处理接口
+func NewComplaintHandler(handleService *service.ComplaintHandleService, logger *zap.Logger) *ComplaintHandler {
+	return &ComplaintHandler{
+		handleService: handleService,
+		logger:        logger,
+	}
+}
+
+// FinishRequest 完结投诉请求
+type FinishRequest struct {
+	ComplaintID uint   `json:"complaint_id"` // 投诉主表ID（alipay_complaint.id）
+	ProcessCode string `json:"process_code"` // 商家处理结果码
+	Remark      string `json:"remark"`       // 处理备注
+	HandlerID   int    `json:"handler_id"`   // 处理人ID
+}
+
+// ReplyRequest 回复投诉请求
+type ReplyRequest struct {
+	ComplaintID uint   `json:"complaint_id"` // 投诉主表ID（alipay_complaint.id）
+	Content     string `json:"content"`      // 回复内容
+	HandlerID   int    `json:"handler_id"`   // 处理人ID
+}
+
+// ComplaintStatusHistory 投诉状态变更历史响应
+type ComplaintStatusHistory struct {
+	Complaint *model.Complaint            `json:"complaint"`
+	Logs      []*model.ComplaintStatusLog `json:"logs"`
+}
+
+// HandleFinish 完结投诉（POST）
+func (h *ComplaintHandler) HandleFinish() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, h.logger, http.StatusMethodNotAllowed, "仅支持POST请求")
+			return
+		}
+
+		var req FinishRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, h.logger, http.StatusBadRequest, "请求参数格式错误")
+			return
+		}
+		if req.ComplaintID == 0 || req.ProcessCode == "" {
+			writeError(w, h.logger, http.StatusBadRequest, "complaint_id和process_code不能为空")
+			return
+		}
+
+		if err := h.handleService.FinishComplaint(r.Context(), req.ComplaintID, req.ProcessCode, req.Remark, req.HandlerID); err != nil {
+			h.logger.Error("完结投诉失败",
+				zap.Uint("complaint_id", req.ComplaintID),
+				zap.Error(err))
+			writeError(w, h.logger, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		writeJSON(w, h.logger, http.StatusOK, Response{Message: "投诉已完结"})
+	}
+}
+
+// HandleReply 回复投诉（POST）
+func (h *ComplaintHandler) HandleReply() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, h.logger, http.StatusMethodNotAllowed, "仅支持POST请求")
+			return
+		}
+
+		var req ReplyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, h.logger, http.StatusBadRequest, "请求参数格式错误")
+			return
+		}
+		if req.ComplaintID == 0 || req.Content == "" {
+			writeError(w, h.logger, http.StatusBadRequest, "complaint_id和content不能为空")
+			return
+		}
+
+		if err := h.handleService.ReplyComplaint(r.Context(), req.ComplaintID, req.Content, req.HandlerID); err != nil {
+			h.logger.Error("回复投诉失败",
+				zap.Uint("complaint_id", req.ComplaintID),
+				zap.Error(err))
+			writeError(w, h.logger, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		writeJSON(w, h.logger, http.StatusOK, Response{Message: "投诉已回复"})
+	}
+}
+
+// HandleStatusHistory 查询投诉的状态变更历史（GET，参数：complaint_id）
+func (h *ComplaintHandler) HandleStatusHistory() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, h.logger, http.StatusMethodNotAllowed, "仅支持GET请求")
+			return
+		}
+
+		complaintID, _ := strconv.ParseUint(r.URL.Query().Get("complaint_id"), 10, 64)
+		if complaintID == 0 {
+			writeError(w, h.logger, http.StatusBadRequest, "complaint_id不能为空")
+			return
+		}
+
+		complaint, logs, err := h.handleService.StatusHistory(uint(complaintID))
+		if err != nil {
+			h.logger.Error("查询投诉状态变更历史失败",
+				zap.Uint64("complaint_id", complaintID),
+				zap.Error(err))
+			writeError(w, h.logger, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		writeJSON(w, h.logger, http.StatusOK, Response{
+			Message: "ok",
+			Data:    ComplaintStatusHistory{Complaint: complaint, Logs: logs},
+		})
+	}
+}
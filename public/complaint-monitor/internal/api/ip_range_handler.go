@@ -0,0 +1,126 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"complaint-monitor/internal/service"
+
+	"go.uber.org/zap"
+)
+
+// IPRangeHandler 黑名单网段管理接口
+type IPRangeHandler struct {
+	rangeService *service.IPRangeService
+	logger       *zap.Logger
+}
+
+// NewIPRangeHandler 创建黑名单网段管理接口
+func NewIPRangeHandler(rangeService *service.IPRangeService, logger *zap.Logger) *IPRangeHandler {
+	return &IPRangeHandler{
+		rangeService: rangeService,
+		logger:       logger,
+	}
+}
+
+// AddIPRangeRequest 添加黑名单网段请求
+type AddIPRangeRequest struct {
+	CIDR      string `json:"cidr"`       // 网段（如 1.2.3.0/24、2001:db8::/48，单个IP视为/32或/128）
+	RiskLevel string `json:"risk_level"` // 风险等级（为空时为low，过期时间按风险等级计算）
+	Remark    string `json:"remark"`
+}
+
+// RemoveIPRangeRequest 删除黑名单网段请求
+type RemoveIPRangeRequest struct {
+	ID uint `json:"id"` // 网段ID
+}
+
+// HandleList 查询所有黑名单网段（GET）
+func (h *IPRangeHandler) HandleList() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, h.logger, http.StatusMethodNotAllowed, "仅支持GET请求")
+			return
+		}
+
+		ranges, err := h.rangeService.List()
+		if err != nil {
+			h.logger.Error("查询黑名单网段失败", zap.Error(err))
+			writeError(w, h.logger, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		writeJSON(w, h.logger, http.StatusOK, Response{
+			Message: "ok",
+			Data:    ranges,
+		})
+	}
+}
+
+// HandleAdd 添加黑名单网段（POST）
+func (h *IPRangeHandler) HandleAdd() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, h.logger, http.StatusMethodNotAllowed, "仅支持POST请求")
+			return
+		}
+
+		var req AddIPRangeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, h.logger, http.StatusBadRequest, "请求参数格式错误")
+			return
+		}
+		if req.CIDR == "" {
+			writeError(w, h.logger, http.StatusBadRequest, "cidr不能为空")
+			return
+		}
+		if req.RiskLevel == "" {
+			req.RiskLevel = string(service.RiskLevelLow)
+		}
+
+		ipRange, err := h.rangeService.Add(req.CIDR, service.RiskLevel(req.RiskLevel), req.Remark)
+		if err != nil {
+			h.logger.Error("添加黑名单网段失败", zap.String("cidr", req.CIDR), zap.Error(err))
+			writeError(w, h.logger, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		writeJSON(w, h.logger, http.StatusOK, Response{
+			Message: "已添加",
+			Data:    ipRange,
+		})
+	}
+}
+
+// HandleRemove 删除黑名单网段（POST）
+func (h *IPRangeHandler) HandleRemove() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, h.logger, http.StatusMethodNotAllowed, "仅支持POST请求")
+			return
+		}
+
+		var req RemoveIPRangeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, h.logger, http.StatusBadRequest, "请求参数格式错误")
+			return
+		}
+		if req.ID == 0 {
+			writeError(w, h.logger, http.StatusBadRequest, "id不能为空")
+			return
+		}
+
+		ipRange, err := h.rangeService.Remove(req.ID)
+		if err != nil {
+			h.logger.Error("删除黑名单网段失败", zap.Uint("range_id", req.ID), zap.Error(err))
+			writeError(w, h.logger, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if ipRange == nil {
+			writeError(w, h.logger, http.StatusNotFound, "网段不存在")
+			return
+		}
+
+		writeJSON(w, h.logger, http.StatusOK, Response{Message: "已删除", Data: ipRange})
+	}
+}
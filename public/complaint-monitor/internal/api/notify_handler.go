@@ -0,0 +1,128 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"complaint-monitor/internal/gateway"
+	"complaint-monitor/internal/model"
+	"complaint-monitor/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// ComplaintNotificationProcessor 投诉通知处理器（worker.Manager 是默认实现）
+type ComplaintNotificationProcessor interface {
+	HandleComplaintNotification(ctx context.Context, subject *model.Subject, gw gateway.ComplaintGateway, notification *gateway.ComplaintNotification) error
+}
+
+// 支付宝异步通知应答（响应内容不是 success 时支付宝会按策略重试）
+const (
+	notifyAckSuccess = "success"
+	notifyAckFail    = "fail"
+)
+
+// NotifyHandler 支付宝投诉消息通知接收接口
+// 通知到达后立即处理，轮询作为对账兜底
+type NotifyHandler struct {
+	subjectRepo *repository.SubjectRepository
+	gateways    gateway.Provider
+	processor   ComplaintNotificationProcessor
+	logger      *zap.Logger
+}
+
+// NewNotifyHandler 创建通知接收接口
+func NewNotifyHandler(
+	subjectRepo *repository.SubjectRepository,
+	gateways gateway.Provider,
+	processor ComplaintNotificationProcessor,
+	logger *zap.Logger,
+) *NotifyHandler {
+	return &NotifyHandler{
+		subjectRepo: subjectRepo,
+		gateways:    gateways,
+		processor:   processor,
+		logger:      logger,
+	}
+}
+
+// HandleComplaintNotify 接收投诉通知（POST，application/x-www-form-urlencoded）
+// 按 app_id 查找主体，使用主体证书验签后交给投诉处理流程
+func (h *NotifyHandler) HandleComplaintNotify() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "仅支持POST请求", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			h.logger.Warn("解析投诉通知失败", zap.Error(err))
+			writeAck(w, http.StatusBadRequest, notifyAckFail)
+			return
+		}
+
+		notification, err := gateway.ParseComplaintNotification(r.PostForm)
+		if err != nil {
+			h.logger.Warn("投诉通知格式错误", zap.Error(err))
+			writeAck(w, http.StatusBadRequest, notifyAckFail)
+			return
+		}
+
+		logger := h.logger.With(
+			zap.String("app_id", notification.AppID),
+			zap.String("notify_id", notification.NotifyID),
+			zap.String("msg_method", notification.MsgMethod),
+			zap.Int64("alipay_complain_id", notification.AlipayComplainId),
+		)
+
+		subject, err := h.subjectRepo.FindByAppID(notification.AppID)
+		if err != nil {
+			logger.Warn("投诉通知对应的主体不存在", zap.Error(err))
+			writeAck(w, http.StatusNotFound, notifyAckFail)
+			return
+		}
+		if !subject.IsActive() {
+			// 主体已禁用时直接应答成功，避免支付宝持续重试
+			logger.Info("主体已禁用，忽略投诉通知", zap.Int("subject_id", subject.ID))
+			writeAck(w, http.StatusOK, notifyAckSuccess)
+			return
+		}
+
+		subject, err = h.subjectRepo.LoadSubjectWithCert(subject.ID)
+		if err != nil {
+			logger.Error("加载主体证书失败", zap.Error(err))
+			writeAck(w, http.StatusInternalServerError, notifyAckFail)
+			return
+		}
+
+		gw, err := h.gateways.Gateway(subject)
+		if err != nil {
+			logger.Error("加载证书失败", zap.Error(err))
+			writeAck(w, http.StatusInternalServerError, notifyAckFail)
+			return
+		}
+
+		if err := gw.VerifyNotification(r.PostForm); err != nil {
+			logger.Warn("投诉通知验签失败", zap.Error(err))
+			writeAck(w, http.StatusForbidden, notifyAckFail)
+			return
+		}
+
+		if err := h.processor.HandleComplaintNotification(r.Context(), subject, gw, notification); err != nil {
+			// 应答失败由支付宝重试，轮询也会兜底
+			logger.Error("处理投诉通知失败", zap.Error(err))
+			writeAck(w, http.StatusInternalServerError, notifyAckFail)
+			return
+		}
+
+		logger.Info("投诉通知处理完成", zap.Int("subject_id", subject.ID))
+		writeAck(w, http.StatusOK, notifyAckSuccess)
+	}
+}
+
+// writeAck 写入支付宝通知应答（纯文本）
+func writeAck(w http.ResponseWriter, status int, ack string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(ack))
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"complaint-monitor/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// FullRescanRequester 全量扫描请求（worker.Manager 是默认实现）
+type FullRescanRequester interface {
+	RequestFullRescan(ctx context.Context, subjectID int) error
+}
+
+// RescanHandler 全量扫描管理接口
+// 请求写入Redis，由负责该主体的实例在下一次轮询时执行（多实例部署时任意实例均可接收请求）
+type RescanHandler struct {
+	subjectRepo *repository.SubjectRepository
+	requester   FullRescanRequester
+	logger      *zap.Logger
+}
+
+// NewRescanHandler 创建全量扫描管理接口
+func NewRescanHandler(subjectRepo *repository.SubjectRepository, requester FullRescanRequester, logger *zap.Logger) *RescanHandler {
+	return &RescanHandler{
+		subjectRepo: subjectRepo,
+		requester:   requester,
+		logger:      logger,
+	}
+}
+
+// RescanRequest 全量扫描请求
+type RescanRequest struct {
+	SubjectID int `json:"subject_id"` // 主体ID
+}
+
+// HandleRescan 请求主体在下一次轮询时执行全量扫描（POST）
+func (h *RescanHandler) HandleRescan() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, h.logger, http.StatusMethodNotAllowed, "仅支持POST请求")
+			return
+		}
+
+		var req RescanRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, h.logger, http.StatusBadRequest, "请求参数格式错误")
+			return
+		}
+		if req.SubjectID <= 0 {
+			writeError(w, h.logger, http.StatusBadRequest, "subject_id不能为空")
+			return
+		}
+
+		// 主体不存在时 FindByID 返回错误
+		if _, err := h.subjectRepo.FindByID(req.SubjectID); err != nil {
+			h.logger.Warn("查询主体失败", zap.Int("subject_id", req.SubjectID), zap.Error(err))
+			writeError(w, h.logger, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if err := h.requester.RequestFullRescan(r.Context(), req.SubjectID); err != nil {
+			h.logger.Error("请求全量扫描失败", zap.Int("subject_id", req.SubjectID), zap.Error(err))
+			writeError(w, h.logger, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		writeJSON(w, h.logger, http.StatusOK, Response{Message: "已请求全量扫描，将在下一次轮询时执行"})
+	}
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// Response 统一响应结构
+type Response struct {
+	Code    int         `json:"code"`           // 0表示成功
+	Message string      `json:"message"`        // 提示信息
+	Data    interface{} `json:"data,omitempty"` // 响应数据
+}
+
+// writeJSON 输出JSON响应
+func writeJSON(w http.ResponseWriter, logger *zap.Logger, statusCode int, resp Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logger.Error("编码API响应失败", zap.Error(err))
+	}
+}
+
+// writeError 输出错误响应
+func writeError(w http.ResponseWriter, logger *zap.Logger, statusCode int, message string) {
+	writeJSON(w, logger, statusCode, Response{Code: statusCode, Message: message})
+}
+
+// RequireToken 管理接口鉴权（Authorization: Bearer {token}）
+// 未配置token时拒绝所有请求，避免管理接口裸奔
+func RequireToken(token string, logger *zap.Logger, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token == "" {
+			writeError(w, logger, http.StatusForbidden, "管理接口未配置auth_token")
+			return
+		}
+
+		provided := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			writeError(w, logger, http.StatusUnauthorized, "鉴权失败")
+			return
+		}
+
+		next(w, r)
+	}
+}
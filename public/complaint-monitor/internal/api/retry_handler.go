@@ -0,0 +1,96 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"complaint-monitor/internal/model"
+	"complaint-monitor/internal/service"
+
+	"go.uber.org/zap"
+)
+
+// RetryHandler 投诉重试（死信）管理接口
+type RetryHandler struct {
+	retryService *service.RetryService
+	logger       *zap.Logger
+}
+
+// NewRetryHandler 创建投诉重试管理接口
+func NewRetryHandler(retryService *service.RetryService, logger *zap.Logger) *RetryHandler {
+	return &RetryHandler{
+		retryService: retryService,
+		logger:       logger,
+	}
+}
+
+// DeadLetterList 死信列表响应
+type DeadLetterList struct {
+	Total int64                   `json:"total"`
+	Page  int                     `json:"page"`
+	Items []*model.ComplaintRetry `json:"items"`
+}
+
+// RequeueRequest 死信重新入队请求
+type RequeueRequest struct {
+	ID uint `json:"id"` // 重试记录ID
+}
+
+// HandleListDead 查询死信列表（GET，参数：subject_id、page、page_size）
+func (h *RetryHandler) HandleListDead() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, h.logger, http.StatusMethodNotAllowed, "仅支持GET请求")
+			return
+		}
+
+		query := r.URL.Query()
+		subjectID, _ := strconv.Atoi(query.Get("subject_id"))
+		page, _ := strconv.Atoi(query.Get("page"))
+		pageSize, _ := strconv.Atoi(query.Get("page_size"))
+		if page < 1 {
+			page = 1
+		}
+
+		items, total, err := h.retryService.ListDead(subjectID, page, pageSize)
+		if err != nil {
+			h.logger.Error("查询死信列表失败", zap.Error(err))
+			writeError(w, h.logger, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		writeJSON(w, h.logger, http.StatusOK, Response{
+			Message: "ok",
+			Data:    DeadLetterList{Total: total, Page: page, Items: items},
+		})
+	}
+}
+
+// HandleRequeue 死信重新入队（POST）
+func (h *RetryHandler) HandleRequeue() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, h.logger, http.StatusMethodNotAllowed, "仅支持POST请求")
+			return
+		}
+
+		var req RequeueRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, h.logger, http.StatusBadRequest, "请求参数格式错误")
+			return
+		}
+		if req.ID == 0 {
+			writeError(w, h.logger, http.StatusBadRequest, "id不能为空")
+			return
+		}
+
+		if err := h.retryService.Requeue(req.ID); err != nil {
+			h.logger.Error("死信重新入队失败", zap.Uint("retry_id", req.ID), zap.Error(err))
+			writeError(w, h.logger, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		writeJSON(w, h.logger, http.StatusOK, Response{Message: "已重新入队"})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"complaint-monitor/internal/gateway"
 	"complaint-monitor/internal/model"
 
 	"github.com/smartwalle/alipay/v3"
@@ -42,6 +43,15 @@ func NewCertManager(encryptionKey []byte, cacheTTL time.Duration, logger *zap.Lo
 	}
 }
 
+// Gateway 加载证书并创建基于SDK的投诉网关（实现 gateway.Provider）
+func (cm *CertManager) Gateway(subject *model.Subject) (gateway.ComplaintGateway, error) {
+	client, err := cm.LoadCert(subject)
+	if err != nil {
+		return nil, err
+	}
+	return gateway.NewSDKGateway(client, subject.AlipayAppID, cm.logger.With(zap.Int("subject_id", subject.ID))), nil
+}
+
 // LoadCert 加载证书并创建支付宝客户端（内存加载）
 func (cm *CertManager) LoadCert(subject *model.Subject) (*alipay.Client, error) {
 	// 检查是否有证书关联
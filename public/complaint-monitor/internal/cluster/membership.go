@@ -0,0 +1,269 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"complaint-monitor/internal/config"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// membersKey 集群成员有序集合（member: 实例ID, score: 最后心跳时间戳）
+const membersKey = "complaint:cluster:members"
+
+// Membership 集群成员管理
+// 实例定期向Redis写入心跳，超过 member_ttl 未心跳的实例视为下线
+// 主体按最高随机权重哈希（Rendezvous Hashing）分配给存活实例，成员变化时只迁移受影响的主体
+type Membership struct {
+	redis             *redis.Client
+	instanceID        string
+	heartbeatInterval time.Duration
+	memberTTL         time.Duration
+	logger            *zap.Logger
+
+	members       []string  // 当前存活成员（有序）
+	lastHeartbeat time.Time // 最近一次心跳成功的时间
+	expired       bool      // 心跳超过 member_ttl 未成功（其他实例已接管本实例的主体）
+	membersMu     sync.RWMutex
+	changes       chan struct{}
+	stopChan      chan struct{}
+	stopOnce      sync.Once
+}
+
+// NewMembership 创建集群成员管理
+func NewMembership(redisClient *redis.Client, cfg config.ClusterConfig, logger *zap.Logger) *Membership {
+	instanceID := cfg.InstanceID
+	if instanceID == "" {
+		instanceID = defaultInstanceID()
+	}
+
+	return &Membership{
+		redis:             redisClient,
+		instanceID:        instanceID,
+		heartbeatInterval: cfg.GetHeartbeatInterval(),
+		memberTTL:         cfg.GetMemberTTL(),
+		logger:            logger.With(zap.String("instance_id", instanceID)),
+		changes:           make(chan struct{}, 1),
+		stopChan:          make(chan struct{}),
+	}
+}
+
+// InstanceID 当前实例ID
+func (m *Membership) InstanceID() string {
+	return m.instanceID
+}
+
+// Join 加入集群（写入首次心跳并加载成员列表）
+func (m *Membership) Join(ctx context.Context) error {
+	if err := m.heartbeat(ctx); err != nil {
+		return fmt.Errorf("加入集群失败: %w", err)
+	}
+	m.logger.Info("已加入集群", zap.Strings("members", m.Members()))
+	return nil
+}
+
+// Run 定期心跳并刷新成员列表（阻塞运行）
+func (m *Membership) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.heartbeatInterval)
+	defer ticker.Stop()
+
+	// 心跳持续失败超过 member_ttl 时其他实例会接管本实例的主体，到期时需通知停止本实例的Worker
+	expiry := time.NewTimer(m.untilExpiry(time.Now()))
+	defer expiry.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.stopChan:
+			return
+		case <-ticker.C:
+			if err := m.heartbeat(ctx); err != nil {
+				m.logger.Warn("集群心跳失败", zap.Error(err))
+			}
+		case <-expiry.C:
+			m.checkExpired(time.Now())
+			expiry.Reset(m.untilExpiry(time.Now()))
+		}
+	}
+}
+
+// Stop 停止心跳并退出集群（其他实例下次心跳时即可接管本实例的主体）
+func (m *Membership) Stop(ctx context.Context) {
+	m.stopOnce.Do(func() {
+		close(m.stopChan)
+		if err := m.redis.ZRem(ctx, membersKey, m.instanceID).Err(); err != nil {
+			m.logger.Warn("退出集群失败", zap.Error(err))
+			return
+		}
+		m.logger.Info("已退出集群")
+	})
+}
+
+// Changes 成员变化通知
+func (m *Membership) Changes() <-chan struct{} {
+	return m.changes
+}
+
+// Members 当前存活成员
+func (m *Membership) Members() []string {
+	m.membersMu.RLock()
+	defer m.membersMu.RUnlock()
+	return append([]string(nil), m.members...)
+}
+
+// Owns 主体是否归当前实例负责
+// 心跳超过 member_ttl 未成功时其他实例已接管本实例的主体，不再负责任何主体
+func (m *Membership) Owns(subjectID int) bool {
+	m.membersMu.RLock()
+	defer m.membersMu.RUnlock()
+
+	if !m.aliveLocked(time.Now()) {
+		return false
+	}
+	return Owner(m.members, subjectID) == m.instanceID
+}
+
+// aliveLocked 最近一次心跳是否仍在 member_ttl 内（调用方需持有 membersMu）
+func (m *Membership) aliveLocked(now time.Time) bool {
+	return !m.lastHeartbeat.IsZero() && now.Sub(m.lastHeartbeat) < m.memberTTL
+}
+
+// untilExpiry 距离心跳过期的时间（已过期时为一个心跳间隔后再检查）
+func (m *Membership) untilExpiry(now time.Time) time.Duration {
+	m.membersMu.RLock()
+	defer m.membersMu.RUnlock()
+
+	if remaining := m.lastHeartbeat.Add(m.memberTTL).Sub(now); remaining > 0 {
+		return remaining
+	}
+	return m.heartbeatInterval
+}
+
+// checkExpired 心跳过期时通知成员变化（每次过期只通知一次）
+func (m *Membership) checkExpired(now time.Time) {
+	m.membersMu.Lock()
+	if m.expired || m.aliveLocked(now) {
+		m.membersMu.Unlock()
+		return
+	}
+	m.expired = true
+	lastHeartbeat := m.lastHeartbeat
+	m.membersMu.Unlock()
+
+	m.logger.Warn("集群心跳已过期，停止负责所有主体",
+		zap.Time("last_heartbeat", lastHeartbeat),
+		zap.Duration("member_ttl", m.memberTTL))
+	m.notifyChange()
+}
+
+// notifyChange 发送成员变化通知
+func (m *Membership) notifyChange() {
+	select {
+	case m.changes <- struct{}{}:
+	default: // 已有未处理的通知
+	}
+}
+
+// heartbeat 写入心跳、清理过期成员并刷新成员列表
+func (m *Membership) heartbeat(ctx context.Context) error {
+	now := time.Now()
+	expireBefore := now.Add(-m.memberTTL)
+
+	pipe := m.redis.TxPipeline()
+	pipe.ZAdd(ctx, membersKey, &redis.Z{Score: float64(now.Unix()), Member: m.instanceID})
+	pipe.ZRemRangeByScore(ctx, membersKey, "-inf", "("+strconv.FormatInt(expireBefore.Unix(), 10))
+	rangeCmd := pipe.ZRange(ctx, membersKey, 0, -1)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	members := rangeCmd.Val()
+
+	m.membersMu.Lock()
+	// 心跳过期后恢复时，即使成员列表未变也需要重新分配主体
+	recovered := m.expired
+	changed := recovered || !equalMembers(m.members, members)
+	previous := m.members
+	m.members = members
+	m.lastHeartbeat = now
+	m.expired = false
+	m.membersMu.Unlock()
+
+	if recovered {
+		m.logger.Info("集群心跳已恢复", zap.Strings("members", members))
+	}
+	if changed {
+		m.logger.Info("集群成员变化",
+			zap.Strings("previous", previous),
+			zap.Strings("current", members))
+		m.notifyChange()
+	}
+
+	return nil
+}
+
+// Owner 计算主体归属的实例（最高随机权重哈希）
+// 成员增减时只有归属于变化成员的主体会迁移
+func Owner(members []string, subjectID int) string {
+	var (
+		owner    string
+		maxScore uint64
+	)
+	for _, member := range members {
+		score := hashScore(member, subjectID)
+		if owner == "" || score > maxScore || (score == maxScore && member < owner) {
+			owner = member
+			maxScore = score
+		}
+	}
+	return owner
+}
+
+// hashScore 计算实例对主体的权重
+func hashScore(member string, subjectID int) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(member))
+	h.Write([]byte{':'})
+	h.Write([]byte(strconv.Itoa(subjectID)))
+	return mix64(h.Sum64())
+}
+
+// mix64 64位哈希终结混淆（FNV对尾部相近的输入雪崩效果差，需再混淆一次保证分布均匀）
+func mix64(x uint64) uint64 {
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// equalMembers 比较成员列表（ZRANGE结果有序）
+func equalMembers(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// defaultInstanceID 默认实例ID（主机名-进程ID）
+func defaultInstanceID() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
@@ -0,0 +1,100 @@
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestOwnerSingleMember(t *testing.T) {
+	for subjectID := 1; subjectID <= 100; subjectID++ {
+		if owner := Owner([]string{"a"}, subjectID); owner != "a" {
+			t.Fatalf("Owner(%d) = %s, 期望 a", subjectID, owner)
+		}
+	}
+	if owner := Owner(nil, 1); owner != "" {
+		t.Errorf("无成员时 Owner = %s, 期望空", owner)
+	}
+}
+
+func TestOwnerDistribution(t *testing.T) {
+	members := []string{"node-1", "node-2", "node-3"}
+	counts := make(map[string]int)
+	for subjectID := 1; subjectID <= 3000; subjectID++ {
+		counts[Owner(members, subjectID)]++
+	}
+
+	for _, member := range members {
+		// 每个实例应分到大致1/3的主体
+		if counts[member] < 800 || counts[member] > 1200 {
+			t.Errorf("%s 分配到 %d 个主体，分布不均: %v", member, counts[member], counts)
+		}
+	}
+}
+
+func TestOwnerRebalanceMovesOnlyAffectedSubjects(t *testing.T) {
+	before := []string{"node-1", "node-2", "node-3"}
+	after := []string{"node-1", "node-3"} // node-2 下线
+
+	for subjectID := 1; subjectID <= 1000; subjectID++ {
+		oldOwner := Owner(before, subjectID)
+		newOwner := Owner(after, subjectID)
+		if oldOwner != "node-2" && oldOwner != newOwner {
+			t.Fatalf("主体 %d 从 %s 迁移到 %s，只有下线实例的主体应迁移", subjectID, oldOwner, newOwner)
+		}
+		if newOwner == "node-2" {
+			t.Fatalf("主体 %d 仍分配给已下线实例", subjectID)
+		}
+	}
+}
+
+func TestOwnerIndependentOfMemberOrder(t *testing.T) {
+	a := []string{"node-1", "node-2", "node-3"}
+	b := []string{"node-3", "node-1", "node-2"}
+	for subjectID := 1; subjectID <= 100; subjectID++ {
+		if Owner(a, subjectID) != Owner(b, subjectID) {
+			t.Fatalf("主体 %d 的归属依赖成员顺序", subjectID)
+		}
+	}
+}
+
+func TestOwnsAfterHeartbeatExpired(t *testing.T) {
+	m := &Membership{
+		instanceID: "node-1",
+		memberTTL:  15 * time.Second,
+		members:    []string{"node-1"},
+		logger:     zap.NewNop(),
+		changes:    make(chan struct{}, 1),
+	}
+
+	// 从未心跳成功时不负责任何主体
+	if m.Owns(1) {
+		t.Fatal("未心跳成功时 Owns 应返回 false")
+	}
+
+	m.lastHeartbeat = time.Now()
+	if !m.Owns(1) {
+		t.Fatal("心跳未过期时单成员应负责所有主体")
+	}
+
+	m.lastHeartbeat = time.Now().Add(-20 * time.Second)
+	if m.Owns(1) {
+		t.Fatal("心跳过期后 Owns 应返回 false")
+	}
+
+	m.checkExpired(time.Now())
+	select {
+	case <-m.Changes():
+	default:
+		t.Fatal("心跳过期后应发送成员变化通知")
+	}
+
+	// 同一次过期只通知一次
+	m.checkExpired(time.Now())
+	select {
+	case <-m.Changes():
+		t.Fatal("心跳过期重复通知")
+	default:
+	}
+}
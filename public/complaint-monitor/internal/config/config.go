@@ -7,14 +7,25 @@ import (
 
 // Config 应用配置
 type Config struct {
-	App      AppConfig      `mapstructure:"app"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Redis    RedisConfig    `mapstructure:"redis"`
-	Worker   WorkerConfig   `mapstructure:"worker"`
-	Cert     CertConfig     `mapstructure:"cert"`
-	Lock     LockConfig     `mapstructure:"lock"`
-	Metrics  MetricsConfig  `mapstructure:"metrics"`
-	Health   HealthConfig   `mapstructure:"health"`
+	App       AppConfig       `mapstructure:"app"`
+	Database  DatabaseConfig  `mapstructure:"database"`
+	Redis     RedisConfig     `mapstructure:"redis"`
+	Worker    WorkerConfig    `mapstructure:"worker"`
+	Cert      CertConfig      `mapstructure:"cert"`
+	Lock      LockConfig      `mapstructure:"lock"`
+	Metrics   MetricsConfig   `mapstructure:"metrics"`
+	Health    HealthConfig    `mapstructure:"health"`
+	API       APIConfig       `mapstructure:"api"`
+	Gateway   GatewayConfig   `mapstructure:"gateway"`
+	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
+	Cluster   ClusterConfig   `mapstructure:"cluster"`
+	Retry     RetryConfig     `mapstructure:"retry"`
+
+	Blacklist      BlacklistConfig      `mapstructure:"blacklist"`
+	BlacklistRules BlacklistRulesConfig `mapstructure:"blacklist_rules"`
+	BuyerGraph     BuyerGraphConfig     `mapstructure:"buyer_graph"`
+	Identity       IdentityConfig       `mapstructure:"identity"`
+	Notification   NotificationConfig   `mapstructure:"notification"`
 }
 
 // AppConfig 应用配置
@@ -71,6 +82,8 @@ type WorkerConfig struct {
 	RefreshInterval int  `mapstructure:"refresh_interval"` // 刷新主体列表间隔（秒）
 	FetchInterval   int  `mapstructure:"fetch_interval"`   // 获取投诉间隔（秒）
 	Restartable     bool `mapstructure:"restartable"`      // 是否自动重启
+	FullScanDays    int  `mapstructure:"full_scan_days"`   // 全量扫描时查询过去N天
+	SyncOverlap     int  `mapstructure:"sync_overlap"`     // 增量查询时水位向前重叠的安全时间（秒）
 }
 
 // GetRefreshInterval 获取刷新间隔
@@ -83,6 +96,11 @@ func (c *WorkerConfig) GetFetchInterval() time.Duration {
 	return time.Duration(c.FetchInterval) * time.Second
 }
 
+// GetSyncOverlap 获取水位安全重叠时间
+func (c *WorkerConfig) GetSyncOverlap() time.Duration {
+	return time.Duration(c.SyncOverlap) * time.Second
+}
+
 // CertConfig 证书配置
 type CertConfig struct {
 	CacheTTL      int    `mapstructure:"cache_ttl"`      // 证书缓存时间（秒）
@@ -140,6 +158,251 @@ func (c *HealthConfig) GetAddress() string {
 	return fmt.Sprintf(":%d", c.Port)
 }
 
+// APIConfig 管理接口配置
+type APIConfig struct {
+	Port       int    `mapstructure:"port"`
+	AuthToken  string `mapstructure:"auth_token"`  // 管理接口鉴权令牌（为空时拒绝所有管理请求）
+	NotifyPath string `mapstructure:"notify_path"` // 支付宝投诉消息通知接收路径（验签鉴权，不需要令牌）
+}
+
+// GetAddress 获取管理接口地址
+func (c *APIConfig) GetAddress() string {
+	return fmt.Sprintf(":%d", c.Port)
+}
+
+// GatewayConfig 投诉网关配置
+type GatewayConfig struct {
+	SimulatorURL string `mapstructure:"simulator_url"` // 模拟网关地址（为空时使用真实支付宝网关，仅限本地开发）
+}
+
+// UseSimulator 是否使用模拟网关
+func (c *GatewayConfig) UseSimulator() bool {
+	return c.SimulatorURL != ""
+}
+
+// RateLimitConfig 支付宝API限流配置（按 AppID + 接口名 共享令牌桶）
+type RateLimitConfig struct {
+	Enabled     bool    `mapstructure:"enabled"`
+	Rate        float64 `mapstructure:"rate"`         // 每秒补充的令牌数
+	Burst       int     `mapstructure:"burst"`        // 令牌桶容量
+	BackoffBase int     `mapstructure:"backoff_base"` // 触发支付宝限流后的初始退避时间（秒）
+	BackoffMax  int     `mapstructure:"backoff_max"`  // 最大退避时间（秒）
+}
+
+// GetBackoffBase 获取初始退避时间
+func (c *RateLimitConfig) GetBackoffBase() time.Duration {
+	return time.Duration(c.BackoffBase) * time.Second
+}
+
+// GetBackoffMax 获取最大退避时间
+func (c *RateLimitConfig) GetBackoffMax() time.Duration {
+	return time.Duration(c.BackoffMax) * time.Second
+}
+
+// ClusterConfig 多实例分片配置
+type ClusterConfig struct {
+	Enabled           bool   `mapstructure:"enabled"`
+	InstanceID        string `mapstructure:"instance_id"`        // 实例ID（为空时使用 主机名-进程ID）
+	HeartbeatInterval int    `mapstructure:"heartbeat_interval"` // 心跳间隔（秒）
+	MemberTTL         int    `mapstructure:"member_ttl"`         // 超过该时间未心跳视为下线（秒）
+}
+
+// GetHeartbeatInterval 获取心跳间隔
+func (c *ClusterConfig) GetHeartbeatInterval() time.Duration {
+	return time.Duration(c.HeartbeatInterval) * time.Second
+}
+
+// GetMemberTTL 获取成员过期时间
+func (c *ClusterConfig) GetMemberTTL() time.Duration {
+	return time.Duration(c.MemberTTL) * time.Second
+}
+
+// RetryConfig 投诉处理失败重试配置
+type RetryConfig struct {
+	MaxAttempts int `mapstructure:"max_attempts"` // 最大失败次数（达到后转入死信）
+	BackoffBase int `mapstructure:"backoff_base"` // 首次重试间隔（秒），之后指数增长
+	BackoffMax  int `mapstructure:"backoff_max"`  // 最大重试间隔（秒）
+	BatchSize   int `mapstructure:"batch_size"`   // 每轮询周期每个主体最多重试的数量
+}
+
+// GetBackoffBase 获取首次重试间隔
+func (c *RetryConfig) GetBackoffBase() time.Duration {
+	return time.Duration(c.BackoffBase) * time.Second
+}
+
+// GetBackoffMax 获取最大重试间隔
+func (c *RetryConfig) GetBackoffMax() time.Duration {
+	return time.Duration(c.BackoffMax) * time.Second
+}
+
+// BlacklistConfig 黑名单有效期配置
+// 按风险等级计算过期时间（每次触发从触发时间重新计算），严重风险（critical）永久有效
+type BlacklistConfig struct {
+	LowExpireDays    int `mapstructure:"low_expire_days"`    // 低风险有效期（天）
+	MediumExpireDays int `mapstructure:"medium_expire_days"` // 中风险有效期（天）
+	HighExpireDays   int `mapstructure:"high_expire_days"`   // 高风险有效期（天）
+	SweepInterval    int `mapstructure:"sweep_interval"`     // 过期清理间隔（秒）
+	SweepBatchSize   int `mapstructure:"sweep_batch_size"`   // 每次清理最多处理的记录数
+
+	IndexSyncInterval   int `mapstructure:"index_sync_interval"`   // 内存索引增量同步间隔（秒）
+	IndexReloadInterval int `mapstructure:"index_reload_interval"` // 内存索引全量重建间隔（秒，用于同步其他实例或PHP侧的删除）
+
+	MirrorEnabled        bool `mapstructure:"mirror_enabled"`         // 是否将黑名单镜像到Redis（供PHP侧查询）
+	MirrorResyncInterval int  `mapstructure:"mirror_resync_interval"` // Redis镜像全量重建间隔（秒，修复漂移）
+
+	RangePromoteEnabled   bool `mapstructure:"range_promote_enabled"`   // 同一网段内的黑名单IP达到阈值后是否自动拉黑整个网段
+	RangePromoteThreshold int  `mapstructure:"range_promote_threshold"` // 自动升级阈值（网段内不同黑名单IP数）
+	RangePromotePrefixV4  int  `mapstructure:"range_promote_prefix_v4"` // IPv4自动升级的网段长度
+	RangePromotePrefixV6  int  `mapstructure:"range_promote_prefix_v6"` // IPv6自动升级的网段长度
+}
+
+// GetExpireDuration 获取风险等级对应的有效期（返回0表示永久有效，未知等级按低风险处理）
+func (c *BlacklistConfig) GetExpireDuration(riskLevel string) time.Duration {
+	day := 24 * time.Hour
+	switch riskLevel {
+	case "critical":
+		return 0
+	case "high":
+		return time.Duration(c.HighExpireDays) * day
+	case "medium":
+		return time.Duration(c.MediumExpireDays) * day
+	default:
+		return time.Duration(c.LowExpireDays) * day
+	}
+}
+
+// GetSweepInterval 获取过期清理间隔
+func (c *BlacklistConfig) GetSweepInterval() time.Duration {
+	return time.Duration(c.SweepInterval) * time.Second
+}
+
+// GetIndexSyncInterval 获取内存索引增量同步间隔
+func (c *BlacklistConfig) GetIndexSyncInterval() time.Duration {
+	return time.Duration(c.IndexSyncInterval) * time.Second
+}
+
+// GetIndexReloadInterval 获取内存索引全量重建间隔
+func (c *BlacklistConfig) GetIndexReloadInterval() time.Duration {
+	return time.Duration(c.IndexReloadInterval) * time.Second
+}
+
+// GetMirrorResyncInterval 获取Redis镜像全量重建间隔
+func (c *BlacklistConfig) GetMirrorResyncInterval() time.Duration {
+	return time.Duration(c.MirrorResyncInterval) * time.Second
+}
+
+// 拉黑规则动作
+const (
+	BlacklistActionBlock  = "block"  // 拉黑
+	BlacklistActionReview = "review" // 标记人工审核，不拉黑
+	BlacklistActionIgnore = "ignore" // 忽略，不拉黑
+)
+
+// BlacklistRulesConfig 拉黑规则配置
+// 新投诉入库后按顺序匹配规则，第一条命中的规则决定动作；均未命中时使用默认动作
+type BlacklistRulesConfig struct {
+	DefaultAction string          `mapstructure:"default_action"` // 未命中任何规则时的动作（默认block）
+	Rules         []BlacklistRule `mapstructure:"rules"`
+}
+
+// BlacklistRule 拉黑规则
+// 所有已配置的条件同时满足时命中，未配置（空值或0）的条件不参与匹配
+type BlacklistRule struct {
+	Name           string   `mapstructure:"name"`
+	Action         string   `mapstructure:"action"`          // block/review/ignore
+	Statuses       []string `mapstructure:"statuses"`        // 投诉状态（任一匹配）
+	ReasonKeywords []string `mapstructure:"reason_keywords"` // 投诉原因关键词（任一包含）
+	MinAmount      float64  `mapstructure:"min_amount"`      // 投诉金额下限（含）
+	MaxAmount      float64  `mapstructure:"max_amount"`      // 投诉金额上限（含）
+	MinHistory     int64    `mapstructure:"min_history"`     // 买家历史投诉次数下限（含本次）
+	MaxHistory     int64    `mapstructure:"max_history"`     // 买家历史投诉次数上限（含本次）
+	SubjectIDs     []int    `mapstructure:"subject_ids"`     // 主体ID（任一匹配）
+	AgentIDs       []int    `mapstructure:"agent_ids"`       // 代理商ID（任一匹配）
+}
+
+// isValidBlacklistAction 是否为有效的拉黑规则动作
+func isValidBlacklistAction(action string) bool {
+	switch action {
+	case BlacklistActionBlock, BlacklistActionReview, BlacklistActionIgnore:
+		return true
+	}
+	return false
+}
+
+// Validate 验证配置
+func (c *BlacklistRulesConfig) Validate() error {
+	if c.DefaultAction != "" && !isValidBlacklistAction(c.DefaultAction) {
+		return fmt.Errorf("无效的默认动作: %s", c.DefaultAction)
+	}
+	for i, rule := range c.Rules {
+		if rule.Name == "" {
+			return fmt.Errorf("第%d条规则缺少名称", i+1)
+		}
+		if !isValidBlacklistAction(rule.Action) {
+			return fmt.Errorf("规则 %s 的动作无效: %s", rule.Name, rule.Action)
+		}
+	}
+	return nil
+}
+
+// BuyerGraphConfig 买家关联图配置
+// 按共用的支付IP、首次打开IP、设备码关联买家账号，发现包含黑名单账号的关联团伙
+type BuyerGraphConfig struct {
+	Enabled             bool   `mapstructure:"enabled"`               // 是否启用关联分析
+	Interval            int    `mapstructure:"interval"`              // 分析间隔（秒）
+	LookbackDays        int    `mapstructure:"lookback_days"`         // 分析最近N天的已支付订单
+	MaxSharedBuyers     int    `mapstructure:"max_shared_buyers"`     // 同一IP/设备码关联的买家超过该数量时忽略（公共出口IP、通用UA）
+	AutoBlacklist       bool   `mapstructure:"auto_blacklist"`        // 是否自动拉黑关联团伙中尚未拉黑的账号
+	AssociatedRiskLevel string `mapstructure:"associated_risk_level"` // 关联账号拉黑的风险等级
+}
+
+// GetInterval 获取分析间隔
+func (c *BuyerGraphConfig) GetInterval() time.Duration {
+	return time.Duration(c.Interval) * time.Second
+}
+
+// Validate 验证配置
+func (c *BuyerGraphConfig) Validate() error {
+	switch c.AssociatedRiskLevel {
+	case "", "low", "medium", "high", "critical":
+	default:
+		return fmt.Errorf("无效的关联账号风险等级: %s", c.AssociatedRiskLevel)
+	}
+	return nil
+}
+
+// IdentityConfig 投诉买家身份解析配置
+// 订单表查不到 buyer_id 时，通过主体的支付宝应用调用 alipay.trade.query 获取投诉订单的买家ID
+type IdentityConfig struct {
+	TradeQueryEnabled   bool `mapstructure:"trade_query_enabled"`    // 是否调用交易查询获取买家ID
+	TradeQueryMaxOrders int  `mapstructure:"trade_query_max_orders"` // 每条投诉最多查询的订单数
+	WriteBackBuyerID    bool `mapstructure:"write_back_buyer_id"`    // 是否把查询到的买家ID回写到 order 表（仅回写 buyer_id 为空的订单）
+}
+
+// NotificationConfig 消息推送配置
+// 新入库的投诉按主体和金额过滤后写入 telegram_message_queue，拉黑、解除、死信等通知不受影响
+type NotificationConfig struct {
+	ComplaintEnabled   bool    `mapstructure:"complaint_enabled"`    // 是否推送新投诉通知
+	ComplaintMinAmount float64 `mapstructure:"complaint_min_amount"` // 投诉订单总金额低于该值时不推送（0表示不限制）
+	DisabledSubjectIDs []int   `mapstructure:"disabled_subject_ids"` // 不推送投诉通知的主体ID
+}
+
+// ComplaintSkipReason 投诉通知的跳过原因（需要推送时返回空字符串）
+func (c *NotificationConfig) ComplaintSkipReason(subjectID int, amount float64) string {
+	if !c.ComplaintEnabled {
+		return "disabled"
+	}
+	for _, id := range c.DisabledSubjectIDs {
+		if id == subjectID {
+			return "subject_disabled"
+		}
+	}
+	if amount < c.ComplaintMinAmount {
+		return "below_min_amount"
+	}
+	return ""
+}
+
 // Validate 验证配置
 func (cfg *Config) Validate() error {
 	// 验证证书配置
@@ -160,6 +423,16 @@ func (cfg *Config) Validate() error {
 		return fmt.Errorf("Redis地址不能为空")
 	}
 
+	// 验证拉黑规则配置
+	if err := cfg.BlacklistRules.Validate(); err != nil {
+		return fmt.Errorf("拉黑规则配置错误: %w", err)
+	}
+
+	// 验证买家关联图配置
+	if err := cfg.BuyerGraph.Validate(); err != nil {
+		return fmt.Errorf("买家关联图配置错误: %w", err)
+	}
+
 	return nil
 }
 
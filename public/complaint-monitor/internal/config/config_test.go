@@ -2,6 +2,7 @@ package config
 
 import (
 	"testing"
+	"time"
 )
 
 func TestConfigLoad(t *testing.T) {
@@ -92,6 +93,42 @@ func TestConfigValidate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "无效拉黑规则动作",
+			config: &Config{
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Database: "test_db",
+				},
+				Redis: RedisConfig{
+					Host: "localhost",
+				},
+				Cert: CertConfig{
+					EncryptionKey: "12345678901234567890123456789012",
+				},
+				BlacklistRules: BlacklistRulesConfig{
+					Rules: []BlacklistRule{{Name: "drop", Action: "delete"}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "无效关联账号风险等级",
+			config: &Config{
+				Database: DatabaseConfig{
+					Host:     "localhost",
+					Database: "test_db",
+				},
+				Redis: RedisConfig{
+					Host: "localhost",
+				},
+				Cert: CertConfig{
+					EncryptionKey: "12345678901234567890123456789012",
+				},
+				BuyerGraph: BuyerGraphConfig{AssociatedRiskLevel: "extreme"},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -159,3 +196,50 @@ func TestConfigHelperMethods(t *testing.T) {
 		t.Error("不应该识别为开发环境")
 	}
 }
+
+func TestBlacklistGetExpireDuration(t *testing.T) {
+	cfg := BlacklistConfig{LowExpireDays: 30, MediumExpireDays: 90, HighExpireDays: 180}
+	day := 24 * time.Hour
+
+	tests := []struct {
+		riskLevel string
+		expected  time.Duration
+	}{
+		{"low", 30 * day},
+		{"medium", 90 * day},
+		{"high", 180 * day},
+		{"critical", 0},
+		{"", 30 * day}, // 未知等级按低风险处理
+	}
+
+	for _, tt := range tests {
+		if got := cfg.GetExpireDuration(tt.riskLevel); got != tt.expected {
+			t.Errorf("GetExpireDuration(%q) = %v, 期望 %v", tt.riskLevel, got, tt.expected)
+		}
+	}
+}
+
+func TestNotificationComplaintSkipReason(t *testing.T) {
+	cfg := NotificationConfig{ComplaintEnabled: true, ComplaintMinAmount: 100, DisabledSubjectIDs: []int{2}}
+
+	tests := []struct {
+		subjectID int
+		amount    float64
+		expected  string
+	}{
+		{1, 100, ""},
+		{1, 99.99, "below_min_amount"},
+		{2, 500, "subject_disabled"},
+	}
+
+	for _, tt := range tests {
+		if got := cfg.ComplaintSkipReason(tt.subjectID, tt.amount); got != tt.expected {
+			t.Errorf("ComplaintSkipReason(%d, %v) = %q, 期望 %q", tt.subjectID, tt.amount, got, tt.expected)
+		}
+	}
+
+	cfg.ComplaintEnabled = false
+	if got := cfg.ComplaintSkipReason(1, 500); got != "disabled" {
+		t.Errorf("关闭推送时 ComplaintSkipReason = %q, 期望 disabled", got)
+	}
+}
@@ -78,6 +78,12 @@ func setDefaults(cfg *Config) {
 	if cfg.Worker.FetchInterval == 0 {
 		cfg.Worker.FetchInterval = 2
 	}
+	if cfg.Worker.FullScanDays == 0 {
+		cfg.Worker.FullScanDays = 10
+	}
+	if cfg.Worker.SyncOverlap == 0 {
+		cfg.Worker.SyncOverlap = 300
+	}
 
 	// 证书配置默认值
 	if cfg.Cert.CacheTTL == 0 {
@@ -107,4 +113,107 @@ func setDefaults(cfg *Config) {
 	if cfg.Health.Path == "" {
 		cfg.Health.Path = "/health"
 	}
+
+	// 限流配置默认值
+	if cfg.RateLimit.Rate == 0 {
+		cfg.RateLimit.Rate = 5
+	}
+	if cfg.RateLimit.Burst == 0 {
+		cfg.RateLimit.Burst = 10
+	}
+	if cfg.RateLimit.BackoffBase == 0 {
+		cfg.RateLimit.BackoffBase = 2
+	}
+	if cfg.RateLimit.BackoffMax == 0 {
+		cfg.RateLimit.BackoffMax = 120
+	}
+
+	// 集群配置默认值
+	if cfg.Cluster.HeartbeatInterval == 0 {
+		cfg.Cluster.HeartbeatInterval = 5
+	}
+	if cfg.Cluster.MemberTTL == 0 {
+		cfg.Cluster.MemberTTL = 15
+	}
+
+	// 重试配置默认值
+	if cfg.Retry.MaxAttempts == 0 {
+		cfg.Retry.MaxAttempts = 8
+	}
+	if cfg.Retry.BackoffBase == 0 {
+		cfg.Retry.BackoffBase = 60
+	}
+	if cfg.Retry.BackoffMax == 0 {
+		cfg.Retry.BackoffMax = 3600
+	}
+	if cfg.Retry.BatchSize == 0 {
+		cfg.Retry.BatchSize = 20
+	}
+
+	// 黑名单有效期配置默认值
+	if cfg.Blacklist.LowExpireDays == 0 {
+		cfg.Blacklist.LowExpireDays = 30
+	}
+	if cfg.Blacklist.MediumExpireDays == 0 {
+		cfg.Blacklist.MediumExpireDays = 90
+	}
+	if cfg.Blacklist.HighExpireDays == 0 {
+		cfg.Blacklist.HighExpireDays = 180
+	}
+	if cfg.Blacklist.SweepInterval == 0 {
+		cfg.Blacklist.SweepInterval = 3600
+	}
+	if cfg.Blacklist.SweepBatchSize == 0 {
+		cfg.Blacklist.SweepBatchSize = 100
+	}
+	if cfg.Blacklist.IndexSyncInterval == 0 {
+		cfg.Blacklist.IndexSyncInterval = 10
+	}
+	if cfg.Blacklist.IndexReloadInterval == 0 {
+		cfg.Blacklist.IndexReloadInterval = 300
+	}
+	if cfg.Blacklist.MirrorResyncInterval == 0 {
+		cfg.Blacklist.MirrorResyncInterval = 600
+	}
+	if cfg.Blacklist.RangePromoteThreshold == 0 {
+		cfg.Blacklist.RangePromoteThreshold = 5
+	}
+	if cfg.Blacklist.RangePromotePrefixV4 == 0 {
+		cfg.Blacklist.RangePromotePrefixV4 = 24
+	}
+	if cfg.Blacklist.RangePromotePrefixV6 == 0 {
+		cfg.Blacklist.RangePromotePrefixV6 = 64
+	}
+
+	// 拉黑规则配置默认值（未配置时保持所有投诉都触发拉黑）
+	if cfg.BlacklistRules.DefaultAction == "" {
+		cfg.BlacklistRules.DefaultAction = BlacklistActionBlock
+	}
+
+	// 买家关联图配置默认值
+	if cfg.BuyerGraph.Interval == 0 {
+		cfg.BuyerGraph.Interval = 3600
+	}
+	if cfg.BuyerGraph.LookbackDays == 0 {
+		cfg.BuyerGraph.LookbackDays = 30
+	}
+	if cfg.BuyerGraph.MaxSharedBuyers == 0 {
+		cfg.BuyerGraph.MaxSharedBuyers = 10
+	}
+	if cfg.BuyerGraph.AssociatedRiskLevel == "" {
+		cfg.BuyerGraph.AssociatedRiskLevel = "low"
+	}
+
+	// 买家身份解析配置默认值
+	if cfg.Identity.TradeQueryMaxOrders == 0 {
+		cfg.Identity.TradeQueryMaxOrders = 5
+	}
+
+	// 管理接口配置默认值
+	if cfg.API.Port == 0 {
+		cfg.API.Port = 8081
+	}
+	if cfg.API.NotifyPath == "" {
+		cfg.API.NotifyPath = "/alipay/notify/complaint"
+	}
 }
@@ -0,0 +1,74 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"complaint-monitor/internal/model"
+)
+
+// ComplaintGateway 支付宝投诉网关（投诉列表、详情、完结、回复、交易查询）
+// 默认实现为 SDKGateway（基于 smartwalle/alipay SDK），本地开发和测试可使用 simulator 包中的模拟网关
+type ComplaintGateway interface {
+	// AppID 网关对应的支付宝应用ID
+	AppID() string
+	// BatchQuery 分页查询投诉列表
+	BatchQuery(ctx context.Context, req ComplaintListRequest) (*ComplaintListResponse, error)
+	// DetailQuery 查询投诉详情
+	DetailQuery(ctx context.Context, req ComplaintDetailRequest) (*ComplaintDetailResponse, error)
+	// Finish 完结投诉
+	Finish(ctx context.Context, req FinishRequest) error
+	// Reply 回复投诉（不改变投诉状态）
+	Reply(ctx context.Context, req ReplyRequest) error
+	// TradeQuery 查询交易（用于获取投诉订单的买家ID）
+	TradeQuery(ctx context.Context, req TradeQueryRequest) (*TradeQueryResponse, error)
+	// VerifyNotification 验证支付宝异步通知签名
+	VerifyNotification(values url.Values) error
+}
+
+// Provider 网关提供者（按主体创建网关）
+// cert.CertManager 是默认实现
+type Provider interface {
+	Gateway(subject *model.Subject) (ComplaintGateway, error)
+}
+
+// APIError 支付宝接口返回的业务错误
+type APIError struct {
+	API     string // 接口名称
+	Code    string // 响应码
+	Msg     string // 响应消息
+	SubCode string // 子响应码
+	SubMsg  string // 子响应消息
+}
+
+// Error 实现error接口
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API返回错误: %s - %s (sub_code: %s, sub_msg: %s)", e.Code, e.Msg, e.SubCode, e.SubMsg)
+}
+
+// CodeCallLimited 支付宝公共错误码：调用频次超限
+const CodeCallLimited = "40005"
+
+// IsThrottled 是否为支付宝限流错误
+// 公共错误码40005表示调用频次超限，部分接口以业务错误码返回，子错误码中包含LIMIT
+func (e *APIError) IsThrottled() bool {
+	return e.Code == CodeCallLimited || strings.Contains(strings.ToUpper(e.SubCode), "LIMIT")
+}
+
+// IsThrottled 错误链中是否包含支付宝限流错误
+func IsThrottled(err error) bool {
+	apiErr, ok := AsAPIError(err)
+	return ok && apiErr.IsThrottled()
+}
+
+// AsAPIError 从错误链中提取支付宝业务错误
+func AsAPIError(err error) (*APIError, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+	return nil, false
+}
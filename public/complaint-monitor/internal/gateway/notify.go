@@ -0,0 +1,75 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// ComplaintNotification 支付宝投诉消息通知（开放平台消息服务异步推送，表单格式）
+// 投诉创建、状态变更时推送，biz_content 字段名以开放平台消息文档为准
+type ComplaintNotification struct {
+	NotifyID         string // 通知ID
+	MsgMethod        string // 消息类型
+	AppID            string // 支付宝应用ID
+	AlipayComplainId int64  // 投诉主表ID（用于查询详情）
+	TaskId           string // 投诉单号（可能为空，以详情为准）
+	Status           string // 投诉状态（可能为空，以详情为准）
+}
+
+// complaintNotificationBiz 投诉消息业务内容
+type complaintNotificationBiz struct {
+	ComplainID json.Number `json:"complain_id"` // 投诉主表ID
+	ID         json.Number `json:"id"`          // 部分消息使用id字段
+	TaskID     string      `json:"task_id"`
+	Status     string      `json:"status"`
+}
+
+// ParseComplaintNotification 解析投诉消息通知（不验证签名，验签见 ComplaintGateway.VerifyNotification）
+func ParseComplaintNotification(values url.Values) (*ComplaintNotification, error) {
+	appID := values.Get("app_id")
+	if appID == "" {
+		return nil, fmt.Errorf("通知缺少app_id")
+	}
+
+	bizContent := values.Get("biz_content")
+	if bizContent == "" {
+		return nil, fmt.Errorf("通知缺少biz_content")
+	}
+
+	var biz complaintNotificationBiz
+	if err := json.Unmarshal([]byte(bizContent), &biz); err != nil {
+		return nil, fmt.Errorf("解析通知biz_content失败: %w", err)
+	}
+
+	rawID := biz.ComplainID
+	if rawID == "" {
+		rawID = biz.ID
+	}
+	complainID, err := strconv.ParseInt(rawID.String(), 10, 64)
+	if err != nil || complainID <= 0 {
+		return nil, fmt.Errorf("通知中的投诉主表ID无效: %q", rawID)
+	}
+
+	return &ComplaintNotification{
+		NotifyID:         values.Get("notify_id"),
+		MsgMethod:        values.Get("msg_method"),
+		AppID:            appID,
+		AlipayComplainId: complainID,
+		TaskId:           biz.TaskID,
+		Status:           biz.Status,
+	}, nil
+}
+
+// ToItem 将投诉详情转换为列表项（推送通知只携带投诉主表ID，需先查询详情再进入统一处理流程）
+func (r *ComplaintDetailResponse) ToItem(alipayComplainId int64) ComplaintItem {
+	return ComplaintItem{
+		ComplaintID:      alipayComplainId,
+		ComplaintEventID: r.ComplaintEventID,
+		Status:           r.Status,
+		ComplainantID:    r.ComplainantID,
+		GmtCreate:        r.GmtCreate,
+		GmtModified:      r.GmtModified,
+	}
+}
@@ -0,0 +1,52 @@
+package gateway
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestParseComplaintNotification(t *testing.T) {
+	values := url.Values{
+		"notify_id":   {"n1"},
+		"msg_method":  {"alipay.security.risk.complaint.notify"},
+		"app_id":      {"2021000000000001"},
+		"biz_content": {`{"complain_id":123456,"task_id":"T1","status":"MERCHANT_PROCESSING"}`},
+	}
+
+	n, err := ParseComplaintNotification(values)
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if n.AlipayComplainId != 123456 || n.TaskId != "T1" || n.AppID != "2021000000000001" || n.NotifyID != "n1" {
+		t.Errorf("解析结果错误: %+v", n)
+	}
+}
+
+func TestParseComplaintNotificationStringID(t *testing.T) {
+	values := url.Values{
+		"app_id":      {"2021000000000001"},
+		"biz_content": {`{"id":"98765"}`},
+	}
+
+	n, err := ParseComplaintNotification(values)
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if n.AlipayComplainId != 98765 {
+		t.Errorf("AlipayComplainId = %d, 期望 98765", n.AlipayComplainId)
+	}
+}
+
+func TestParseComplaintNotificationInvalid(t *testing.T) {
+	cases := map[string]url.Values{
+		"缺少app_id":      {"biz_content": {`{"complain_id":1}`}},
+		"缺少biz_content": {"app_id": {"a"}},
+		"非JSON":         {"app_id": {"a"}, "biz_content": {"x"}},
+		"缺少投诉ID":        {"app_id": {"a"}, "biz_content": {`{"task_id":"T1"}`}},
+	}
+	for name, values := range cases {
+		if _, err := ParseComplaintNotification(values); err == nil {
+			t.Errorf("%s: 期望返回错误", name)
+		}
+	}
+}
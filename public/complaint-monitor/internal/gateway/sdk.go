@@ -0,0 +1,343 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/smartwalle/alipay/v3"
+	"go.uber.org/zap"
+)
+
+// 支付宝投诉相关API名称
+const (
+	APIComplaintBatchQuery     = "alipay.security.risk.complaint.info.batchquery" // 查询消费者投诉列表
+	APIComplaintInfoQuery      = "alipay.security.risk.complaint.info.query"      // 查询消费者投诉详情
+	APIComplaintProcessFinish  = "alipay.security.risk.complaint.process.finish"  // 处理消费者投诉（完结）
+	APIComplaintFeedbackSubmit = "alipay.security.risk.complaint.feedback.submit" // 商家回复消费者投诉（SDK未提供对应方法）
+	APITradeQuery              = "alipay.trade.query"                             // 统一收单交易查询（获取投诉订单的买家ID）
+)
+
+var _ ComplaintGateway = (*SDKGateway)(nil)
+
+// SDKGateway 基于 smartwalle/alipay SDK 的投诉网关
+type SDKGateway struct {
+	client *alipay.Client
+	appID  string
+	logger *zap.Logger
+}
+
+// NewSDKGateway 创建SDK投诉网关
+func NewSDKGateway(client *alipay.Client, appID string, logger *zap.Logger) *SDKGateway {
+	return &SDKGateway{
+		client: client,
+		appID:  appID,
+		logger: logger,
+	}
+}
+
+// AppID 网关对应的支付宝应用ID
+func (g *SDKGateway) AppID() string {
+	return g.appID
+}
+
+// BatchQuery 分页查询投诉列表
+// 使用SDK提供的 SecurityRiskComplaintInfoBatchQuery 方法
+func (g *SDKGateway) BatchQuery(ctx context.Context, req ComplaintListRequest) (*ComplaintListResponse, error) {
+	// 构建请求参数（使用SDK提供的结构体）
+	// 时间为空时SDK的omitempty标签会忽略该参数
+	payload := alipay.SecurityRiskComplaintInfoBatchQueryReq{
+		CurrentPageNum:    int64(req.PageNum),
+		PageSize:          int64(req.PageSize),
+		GmtComplaintStart: req.BeginTime,        // 投诉时间范围下界（格式：yyyy-MM-dd HH:mm:ss）
+		GmtComplaintEnd:   req.EndTime,          // 投诉时间范围上界（格式：yyyy-MM-dd HH:mm:ss）
+		GmtProcessStart:   req.ProcessBeginTime, // 处理时间范围下界
+		GmtProcessEnd:     req.ProcessEndTime,   // 处理时间范围上界
+	}
+
+	// 打印完整的查询条件（JSON格式，便于调试）
+	payloadJSON, _ := json.Marshal(payload)
+	g.logger.Info("=== 支付宝投诉列表API查询条件 ===",
+		zap.String("api_name", APIComplaintBatchQuery),
+		zap.String("payload_json", string(payloadJSON)),
+		zap.String("gmt_complaint_start", payload.GmtComplaintStart),
+		zap.String("gmt_complaint_end", payload.GmtComplaintEnd),
+		zap.String("gmt_process_start", payload.GmtProcessStart),
+		zap.String("gmt_process_end", payload.GmtProcessEnd),
+		zap.Int64("current_page_num", payload.CurrentPageNum),
+		zap.Int64("page_size", payload.PageSize),
+	)
+	fmt.Printf("=== 支付宝投诉列表API查询条件 ===\n")
+	fmt.Printf("API名称: %s\n", APIComplaintBatchQuery)
+	fmt.Printf("查询开始时间: %s\n", payload.GmtComplaintStart)
+	fmt.Printf("查询结束时间: %s\n", payload.GmtComplaintEnd)
+	fmt.Printf("当前页码: %d\n", payload.CurrentPageNum)
+	fmt.Printf("每页数量: %d\n", payload.PageSize)
+	fmt.Printf("完整Payload JSON: %s\n\n", string(payloadJSON))
+
+	result, err := g.client.SecurityRiskComplaintInfoBatchQuery(ctx, payload)
+	if err != nil {
+		return nil, wrapSDKError(APIComplaintBatchQuery, err)
+	}
+
+	// 打印原始响应（用于调试）
+	responseJSON, _ := json.Marshal(result)
+	fmt.Printf("=== 支付宝API响应结果 ===\n")
+	fmt.Printf("响应码: %s\n", string(result.Code))
+	fmt.Printf("响应消息: %s\n", result.Msg)
+	fmt.Printf("子响应码: %s\n", result.SubCode)
+	fmt.Printf("子响应消息: %s\n", result.SubMsg)
+	fmt.Printf("总记录数: %d\n", result.TotalSize)
+	fmt.Printf("当前页记录数: %d\n", len(result.ComplaintList))
+	fmt.Printf("完整响应JSON: %s\n", string(responseJSON))
+	fmt.Printf("========================\n\n")
+
+	g.logger.Info("支付宝API原始响应",
+		zap.String("response", string(responseJSON)),
+		zap.String("code", string(result.Code)),
+		zap.String("msg", result.Msg),
+		zap.String("sub_code", result.SubCode),
+		zap.String("sub_msg", result.SubMsg),
+		zap.Int64("total_size", result.TotalSize),
+		zap.Int("complaint_count", len(result.ComplaintList)),
+	)
+
+	// 检查响应状态
+	if result.IsFailure() {
+		return nil, newAPIError(APIComplaintBatchQuery, result.Error)
+	}
+
+	// 转换投诉列表数据
+	complaintList := make([]ComplaintItem, 0, len(result.ComplaintList))
+	for _, item := range result.ComplaintList {
+		// 提取投诉单号（使用TaskId）
+		complaintEventID := item.TaskId
+		if complaintEventID == "" {
+			// 如果TaskId为空，使用ID作为备用
+			complaintEventID = fmt.Sprintf("%d", item.Id)
+		}
+
+		complaintList = append(complaintList, ComplaintItem{
+			ComplaintID:      item.Id,          // 投诉主表主键ID（用于查询详情）
+			ComplaintEventID: complaintEventID, // 投诉单号（TaskId）
+			Status:           item.Status,      // 投诉状态
+			ComplainantID:    item.OppositePid, // 对方PID（文档为被投诉方PID，可能是商户自身，拉黑前由 IdentityResolver 校验）
+			GmtCreate:        item.GmtComplain, // 投诉时间
+			GmtModified:      item.GmtProcess,  // 处理时间
+		})
+	}
+
+	return &ComplaintListResponse{
+		Total:         result.TotalSize,
+		ComplaintList: complaintList,
+	}, nil
+}
+
+// DetailQuery 查询投诉详情
+// 使用SDK提供的 SecurityRiskComplaintInfoQuery 方法
+// 注意：complaint_event_id 应该是投诉主表的主键ID（int64），而不是投诉单号
+func (g *SDKGateway) DetailQuery(ctx context.Context, req ComplaintDetailRequest) (*ComplaintDetailResponse, error) {
+	// 将complaint_event_id转换为int64（投诉主表的主键ID）
+	complainID, err := strconv.ParseInt(req.ComplaintEventID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("投诉单号格式错误，需要是数字ID: %s", req.ComplaintEventID)
+	}
+
+	// 构建请求参数（使用SDK提供的结构体）
+	payload := alipay.SecurityRiskComplaintInfoQueryReq{
+		ComplainId: complainID,
+	}
+
+	// 记录请求参数
+	g.logger.Info("支付宝投诉详情API请求参数",
+		zap.String("api_name", APIComplaintInfoQuery),
+		zap.Int64("complain_id", complainID),
+	)
+
+	result, err := g.client.SecurityRiskComplaintInfoQuery(ctx, payload)
+	if err != nil {
+		return nil, wrapSDKError(APIComplaintInfoQuery, err)
+	}
+
+	// 打印原始响应（完整的JSON，用于检查是否有SDK未映射的字段）
+	responseJSON, _ := json.Marshal(result)
+	fmt.Printf("\n=== 支付宝投诉详情API原始响应 ===\n")
+	fmt.Printf("响应码: %s\n", string(result.Code))
+	fmt.Printf("响应消息: %s\n", result.Msg)
+	fmt.Printf("完整响应JSON: %s\n", string(responseJSON))
+	fmt.Printf("投诉单号(TaskId): %s\n", result.TaskId)
+	fmt.Printf("投诉状态: %s\n", result.Status)
+	fmt.Printf("投诉总金额(ComplainAmount): %s\n", result.ComplainAmount)
+	fmt.Printf("投诉内容: %s\n", result.ComplainContent)
+	fmt.Printf("对方PID(OppositePid): %s\n", result.OppositePid)
+	fmt.Printf("对方名称(OppositeName): %s\n", result.OppositeName)
+	fmt.Printf("订单数量: %d\n", len(result.ComplaintTradeInfoList))
+	fmt.Printf("===============================\n\n")
+
+	g.logger.Info("支付宝投诉详情API原始响应",
+		zap.String("response", string(responseJSON)),
+		zap.String("code", string(result.Code)),
+		zap.String("msg", result.Msg),
+		zap.String("task_id", result.TaskId),
+		zap.String("status", result.Status),
+		zap.String("complain_amount", result.ComplainAmount),
+		zap.Int("trade_info_count", len(result.ComplaintTradeInfoList)),
+	)
+
+	// 检查响应状态
+	if result.IsFailure() {
+		return nil, newAPIError(APIComplaintInfoQuery, result.Error)
+	}
+
+	// 打印每个订单的详细信息
+	for i, tradeInfo := range result.ComplaintTradeInfoList {
+		g.logger.Info("投诉订单详情",
+			zap.Int("index", i),
+			zap.String("trade_no", tradeInfo.TradeNo),
+			zap.String("out_no", tradeInfo.OutNo),
+			zap.String("amount", tradeInfo.Amount),
+			zap.String("status", tradeInfo.Status),
+			zap.String("status_description", tradeInfo.StatusDescription),
+			zap.String("gmt_trade", tradeInfo.GmtTrade),
+			zap.String("gmt_refund", tradeInfo.GmtRefund),
+		)
+	}
+
+	// 转换订单列表数据
+	targetOrderList := make([]OrderItem, 0, len(result.ComplaintTradeInfoList))
+	for _, tradeInfo := range result.ComplaintTradeInfoList {
+		// 解析订单金额
+		amount := 0.0
+		if tradeInfo.Amount != "" {
+			if parsedAmount, err := strconv.ParseFloat(tradeInfo.Amount, 64); err == nil {
+				amount = parsedAmount
+			}
+		}
+
+		// 注意：SDK返回的SecurityRiskComplaintTradeInfo结构中没有单独的complaint_amount字段
+		// 因此这里使用订单金额作为投诉金额
+		targetOrderList = append(targetOrderList, OrderItem{
+			TradeNo:         tradeInfo.TradeNo,
+			OutTradeNo:      tradeInfo.OutNo,
+			Amount:          amount,
+			ComplaintAmount: amount,
+			Status:          tradeInfo.Status,
+			GmtRefund:       tradeInfo.GmtRefund,
+		})
+	}
+
+	return &ComplaintDetailResponse{
+		ComplaintEventID: result.TaskId, // 使用TaskId作为投诉单号
+		Status:           result.Status,
+		ComplainantID:    result.OppositePid, // 对方PID（文档为被投诉方PID，可能是商户自身，拉黑前由 IdentityResolver 校验）
+		ComplainantName:  result.OppositeName,
+		ComplaintReason:  result.ComplainContent,
+		GmtCreate:        result.GmtComplain,
+		GmtModified:      result.GmtProcess,
+		TargetOrderList:  targetOrderList,
+	}, nil
+}
+
+// Finish 完结投诉
+// 使用SDK提供的 SecurityRiskComplaintProcessFinish 方法（biz_content：id_list、process_code、remark）
+func (g *SDKGateway) Finish(ctx context.Context, req FinishRequest) error {
+	payload := alipay.SecurityRiskComplaintProcessFinishReq{
+		IdList:      []int64{req.AlipayComplainId},
+		ProcessCode: req.ProcessCode,
+		Remark:      req.Remark, // 为空时SDK的omitempty标签会忽略该参数
+	}
+
+	result, err := g.client.SecurityRiskComplaintProcessFinish(ctx, payload)
+	if err != nil {
+		return wrapSDKError(APIComplaintProcessFinish, err)
+	}
+	if result.IsFailure() {
+		return newAPIError(APIComplaintProcessFinish, result.Error)
+	}
+	if !result.ComplaintProcessSuccess {
+		return fmt.Errorf("支付宝未成功受理投诉处理: alipay_complain_id=%d", req.AlipayComplainId)
+	}
+	return nil
+}
+
+// complaintFeedbackResponse 回复投诉API响应
+type complaintFeedbackResponse struct {
+	alipay.Error
+}
+
+// Reply 回复投诉
+// SDK没有提供对应方法，通过SDK通用请求方法调用（biz_content：complain_id、feedback_content）
+func (g *SDKGateway) Reply(ctx context.Context, req ReplyRequest) error {
+	payload := alipay.NewPayload(APIComplaintFeedbackSubmit)
+	payload.AddBizField("complain_id", req.AlipayComplainId)
+	payload.AddBizField("feedback_content", req.Content)
+
+	var result complaintFeedbackResponse
+	if err := g.client.Request(ctx, payload, &result); err != nil {
+		return wrapSDKError(APIComplaintFeedbackSubmit, err)
+	}
+	if result.IsFailure() {
+		return newAPIError(APIComplaintFeedbackSubmit, result.Error)
+	}
+	return nil
+}
+
+// TradeQuery 查询交易
+// 使用SDK提供的 TradeQuery 方法，返回买家的 buyer_user_id / buyer_open_id
+func (g *SDKGateway) TradeQuery(ctx context.Context, req TradeQueryRequest) (*TradeQueryResponse, error) {
+	payload := alipay.TradeQuery{
+		TradeNo:    req.TradeNo,
+		OutTradeNo: req.OutTradeNo,
+	}
+	if payload.TradeNo != "" {
+		payload.OutTradeNo = "" // 二选一，优先使用支付宝订单号
+	}
+
+	result, err := g.client.TradeQuery(ctx, payload)
+	if err != nil {
+		return nil, wrapSDKError(APITradeQuery, err)
+	}
+	if result.IsFailure() {
+		return nil, newAPIError(APITradeQuery, result.Error)
+	}
+
+	return &TradeQueryResponse{
+		TradeNo:      result.TradeNo,
+		OutTradeNo:   result.OutTradeNo,
+		TradeStatus:  string(result.TradeStatus),
+		BuyerUserID:  result.BuyerUserId,
+		BuyerOpenID:  result.BuyerOpenId,
+		BuyerLogonID: result.BuyerLogonId,
+	}, nil
+}
+
+// VerifyNotification 使用主体证书中的支付宝公钥验证异步通知签名
+func (g *SDKGateway) VerifyNotification(values url.Values) error {
+	if err := g.client.VerifySign(values); err != nil {
+		return fmt.Errorf("验证通知签名失败: %w", err)
+	}
+	return nil
+}
+
+// newAPIError 将SDK的响应错误转换为网关业务错误
+func newAPIError(api string, sdkErr alipay.Error) *APIError {
+	return &APIError{
+		API:     api,
+		Code:    string(sdkErr.Code),
+		Msg:     sdkErr.Msg,
+		SubCode: sdkErr.SubCode,
+		SubMsg:  sdkErr.SubMsg,
+	}
+}
+
+// wrapSDKError 包装SDK调用错误（网关层的错误响应同样转换为业务错误）
+func wrapSDKError(api string, err error) error {
+	var sdkErr *alipay.Error
+	if errors.As(err, &sdkErr) && sdkErr != nil {
+		return newAPIError(api, *sdkErr)
+	}
+	return fmt.Errorf("调用%s失败: %w", api, err)
+}
@@ -0,0 +1,152 @@
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"complaint-monitor/internal/gateway"
+	"complaint-monitor/internal/model"
+)
+
+var (
+	_ gateway.ComplaintGateway = (*Gateway)(nil)
+	_ gateway.Provider         = (*Provider)(nil)
+)
+
+// Gateway 连接模拟网关的投诉网关实现
+type Gateway struct {
+	baseURL    string
+	appID      string
+	httpClient *http.Client
+}
+
+// NewGateway 创建连接模拟网关的投诉网关
+func NewGateway(baseURL, appID string, httpClient *http.Client) *Gateway {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Gateway{
+		baseURL:    baseURL,
+		appID:      appID,
+		httpClient: httpClient,
+	}
+}
+
+// AppID 网关对应的支付宝应用ID
+func (g *Gateway) AppID() string {
+	return g.appID
+}
+
+// BatchQuery 分页查询投诉列表
+func (g *Gateway) BatchQuery(ctx context.Context, req gateway.ComplaintListRequest) (*gateway.ComplaintListResponse, error) {
+	var result gateway.ComplaintListResponse
+	if err := g.call(ctx, APIBatchQuery, req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// DetailQuery 查询投诉详情
+func (g *Gateway) DetailQuery(ctx context.Context, req gateway.ComplaintDetailRequest) (*gateway.ComplaintDetailResponse, error) {
+	var result gateway.ComplaintDetailResponse
+	if err := g.call(ctx, APIInfoQuery, req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Finish 完结投诉
+func (g *Gateway) Finish(ctx context.Context, req gateway.FinishRequest) error {
+	return g.call(ctx, APIFinish, req, nil)
+}
+
+// Reply 回复投诉
+func (g *Gateway) Reply(ctx context.Context, req gateway.ReplyRequest) error {
+	return g.call(ctx, APIReply, req, nil)
+}
+
+// TradeQuery 查询交易
+func (g *Gateway) TradeQuery(ctx context.Context, req gateway.TradeQueryRequest) (*gateway.TradeQueryResponse, error) {
+	var result gateway.TradeQueryResponse
+	if err := g.call(ctx, APITradeQuery, req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// VerifyNotification 验证异步通知（模拟网关不签名，仅校验通知所属应用）
+func (g *Gateway) VerifyNotification(values url.Values) error {
+	if appID := values.Get("app_id"); appID != g.appID {
+		return fmt.Errorf("通知app_id不匹配: %s", appID)
+	}
+	return nil
+}
+
+// call 调用模拟网关（业务错误转换为 gateway.APIError）
+func (g *Gateway) call(ctx context.Context, method string, bizContent interface{}, result interface{}) error {
+	biz, err := json.Marshal(bizContent)
+	if err != nil {
+		return fmt.Errorf("序列化请求参数失败: %w", err)
+	}
+	body, err := json.Marshal(request{Method: method, BizContent: biz})
+	if err != nil {
+		return fmt.Errorf("序列化请求失败: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, g.baseURL+gatewayPath, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := g.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("调用%s失败: %w", method, err)
+	}
+	defer httpResp.Body.Close()
+
+	var resp response
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return fmt.Errorf("解析%s响应失败: http_status=%d, %w", method, httpResp.StatusCode, err)
+	}
+
+	if resp.Code != "10000" {
+		return &gateway.APIError{
+			API:     method,
+			Code:    resp.Code,
+			Msg:     resp.Msg,
+			SubCode: resp.SubCode,
+			SubMsg:  resp.SubMsg,
+		}
+	}
+
+	if result != nil && len(resp.Data) > 0 {
+		if err := json.Unmarshal(resp.Data, result); err != nil {
+			return fmt.Errorf("解析%s响应数据失败: %w", method, err)
+		}
+	}
+	return nil
+}
+
+// Provider 模拟网关提供者（所有主体共用同一个模拟网关）
+type Provider struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewProvider 创建模拟网关提供者
+func NewProvider(baseURL string, httpClient *http.Client) *Provider {
+	return &Provider{
+		baseURL:    baseURL,
+		httpClient: httpClient,
+	}
+}
+
+// Gateway 为主体创建连接模拟网关的投诉网关（实现 gateway.Provider）
+func (p *Provider) Gateway(subject *model.Subject) (gateway.ComplaintGateway, error) {
+	return NewGateway(p.baseURL, subject.AlipayAppID, p.httpClient), nil
+}
@@ -0,0 +1,161 @@
+package simulator
+
+import (
+	"fmt"
+	"time"
+
+	"complaint-monitor/internal/gateway"
+	"complaint-monitor/internal/model"
+)
+
+// timeLayout 支付宝接口时间格式
+const timeLayout = "2006-01-02 15:04:05"
+
+// 模拟网关支持的接口（与 gateway 包中的API名称一致）
+const (
+	APIBatchQuery = gateway.APIComplaintBatchQuery
+	APIInfoQuery  = gateway.APIComplaintInfoQuery
+	APIFinish     = gateway.APIComplaintProcessFinish
+	APIReply      = gateway.APIComplaintFeedbackSubmit
+	APITradeQuery = gateway.APITradeQuery
+)
+
+// Complaint 模拟投诉（列表项 + 详情）
+type Complaint struct {
+	Item    gateway.ComplaintItem
+	Detail  gateway.ComplaintDetailResponse
+	BuyerID string // 投诉订单的买家支付宝用户ID（交易查询返回，为空时交易查询返回交易不存在）
+}
+
+// Fault 脚本化故障
+// 命中的请求直接返回 Err（或 HTTPStatus），Times 次后失效
+type Fault struct {
+	API        string            // 接口名称
+	Page       int               // 仅对指定页生效（0表示所有页，仅列表接口有效）
+	Times      int               // 生效次数（<=0表示一直生效）
+	Err        *gateway.APIError // 返回的业务错误
+	HTTPStatus int               // 返回的HTTP状态码（Err为nil时生效）
+}
+
+// Scenario 模拟场景
+type Scenario struct {
+	Name       string
+	Complaints []Complaint
+	Faults     []Fault
+}
+
+// NewComplaint 生成一条模拟投诉
+// seq 用于生成唯一的投诉ID、投诉单号和订单号，orderCount 为涉及订单数
+func NewComplaint(seq int, complaintTime time.Time, orderCount int) Complaint {
+	id := int64(100000 + seq)
+	taskID := fmt.Sprintf("SIM%08d", seq)
+	gmt := complaintTime.Format(timeLayout)
+
+	orders := make([]gateway.OrderItem, 0, orderCount)
+	for i := 0; i < orderCount; i++ {
+		orders = append(orders, gateway.OrderItem{
+			TradeNo:         fmt.Sprintf("2025%08d%04d", seq, i),
+			OutTradeNo:      fmt.Sprintf("BY1%s%06d%02d", complaintTime.Format("20060102"), seq, i),
+			Amount:          100,
+			ComplaintAmount: 100,
+			Status:          model.ComplaintStatusWaitProcess,
+		})
+	}
+
+	return Complaint{
+		Item: gateway.ComplaintItem{
+			ComplaintID:      id,
+			ComplaintEventID: taskID,
+			Status:           model.ComplaintStatusWaitProcess,
+			ComplainantID:    fmt.Sprintf("2088%012d", seq),
+			GmtCreate:        gmt,
+			GmtModified:      gmt,
+		},
+		Detail: gateway.ComplaintDetailResponse{
+			ComplaintEventID: taskID,
+			Status:           model.ComplaintStatusWaitProcess,
+			ComplainantID:    fmt.Sprintf("2088%012d", seq),
+			ComplainantName:  "模拟商户",
+			ComplaintReason:  "模拟投诉内容",
+			GmtCreate:        gmt,
+			GmtModified:      gmt,
+			TargetOrderList:  orders,
+		},
+		BuyerID: fmt.Sprintf("2088%012d", 100000000000+seq),
+	}
+}
+
+// generate 生成 count 条投诉（投诉时间从 start 开始每分钟一条）
+func generate(count int, start time.Time) []Complaint {
+	complaints := make([]Complaint, 0, count)
+	for i := 1; i <= count; i++ {
+		complaints = append(complaints, NewComplaint(i, start.Add(time.Duration(i)*time.Minute), 1))
+	}
+	return complaints
+}
+
+// EmptyScenario 没有任何投诉
+func EmptyScenario() *Scenario {
+	return &Scenario{Name: "empty"}
+}
+
+// PagedScenario 共 total 条投诉（用于多页遍历）
+func PagedScenario(total int) *Scenario {
+	return &Scenario{
+		Name:       "paged",
+		Complaints: generate(total, time.Now().Add(-24*time.Hour)),
+	}
+}
+
+// PageBoundaryScenario 投诉数恰好等于页大小（最后一页满页，需要再请求一页才能确认结束）
+func PageBoundaryScenario(pageSize int) *Scenario {
+	scenario := PagedScenario(pageSize)
+	scenario.Name = "page_boundary"
+	return scenario
+}
+
+// RateLimitedScenario 列表接口前 times 次调用触发支付宝限流
+func RateLimitedScenario(total, times int) *Scenario {
+	scenario := PagedScenario(total)
+	scenario.Name = "rate_limited"
+	scenario.Faults = []Fault{{
+		API:   APIBatchQuery,
+		Times: times,
+		Err: &gateway.APIError{
+			API:     APIBatchQuery,
+			Code:    "40005",
+			Msg:     "Call Limited",
+			SubCode: "aop.ACQ.API_CALL_LIMITED",
+			SubMsg:  "调用频率超限",
+		},
+	}}
+	return scenario
+}
+
+// MultiOrderScenario 每条投诉涉及多个订单（用于拉黑流程）
+func MultiOrderScenario(total, orderCount int) *Scenario {
+	start := time.Now().Add(-24 * time.Hour)
+	complaints := make([]Complaint, 0, total)
+	for i := 1; i <= total; i++ {
+		complaints = append(complaints, NewComplaint(i, start.Add(time.Duration(i)*time.Minute), orderCount))
+	}
+	return &Scenario{Name: "multi_order", Complaints: complaints}
+}
+
+// ScenarioByName 根据名称创建内置场景（供命令行模拟器使用）
+func ScenarioByName(name string, total int) (*Scenario, error) {
+	switch name {
+	case "empty":
+		return EmptyScenario(), nil
+	case "paged":
+		return PagedScenario(total), nil
+	case "page_boundary":
+		return PageBoundaryScenario(total), nil
+	case "rate_limited":
+		return RateLimitedScenario(total, 3), nil
+	case "multi_order":
+		return MultiOrderScenario(total, 3), nil
+	default:
+		return nil, fmt.Errorf("未知的模拟场景: %s", name)
+	}
+}
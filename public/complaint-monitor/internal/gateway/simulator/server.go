@@ -0,0 +1,365 @@
+package simulator
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	"complaint-monitor/internal/gateway"
+	"complaint-monitor/internal/model"
+)
+
+// gatewayPath 模拟网关的请求路径
+const gatewayPath = "/gateway"
+
+// request 模拟网关请求（method 为支付宝API名称）
+type request struct {
+	Method     string          `json:"method"`
+	BizContent json.RawMessage `json:"biz_content"`
+}
+
+// response 模拟网关响应
+type response struct {
+	Code    string          `json:"code"`
+	Msg     string          `json:"msg"`
+	SubCode string          `json:"sub_code,omitempty"`
+	SubMsg  string          `json:"sub_msg,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// Server 模拟支付宝投诉网关
+// 按场景提供分页投诉列表、详情和投诉订单的交易查询，记录完结、回复请求，支持脚本化故障注入
+type Server struct {
+	mu         sync.Mutex
+	name       string
+	complaints []Complaint
+	faults     []Fault
+	calls      map[string]int
+	finished   map[int64]gateway.FinishRequest
+	replies    map[int64][]string
+	httpServer *httptest.Server
+}
+
+// New 根据场景创建模拟网关（不监听端口，可直接作为 http.Handler 使用）
+func New(scenario *Scenario) *Server {
+	complaints := make([]Complaint, len(scenario.Complaints))
+	copy(complaints, scenario.Complaints)
+	faults := make([]Fault, len(scenario.Faults))
+	copy(faults, scenario.Faults)
+
+	return &Server{
+		name:       scenario.Name,
+		complaints: complaints,
+		faults:     faults,
+		calls:      make(map[string]int),
+		finished:   make(map[int64]gateway.FinishRequest),
+		replies:    make(map[int64][]string),
+	}
+}
+
+// Start 根据场景创建并启动模拟网关（基于 httptest，监听本地随机端口）
+func Start(scenario *Scenario) *Server {
+	s := New(scenario)
+	s.httpServer = httptest.NewServer(s)
+	return s
+}
+
+// URL 模拟网关地址（仅 Start 启动时有效）
+func (s *Server) URL() string {
+	if s.httpServer == nil {
+		return ""
+	}
+	return s.httpServer.URL
+}
+
+// Close 关闭模拟网关
+func (s *Server) Close() {
+	if s.httpServer != nil {
+		s.httpServer.Close()
+	}
+}
+
+// Provider 返回指向本模拟网关的网关提供者
+func (s *Server) Provider() *Provider {
+	return NewProvider(s.URL(), s.httpServer.Client())
+}
+
+// Calls 接口被调用的次数
+func (s *Server) Calls(api string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[api]
+}
+
+// Finished 查询投诉的完结请求
+func (s *Server) Finished(alipayComplainId int64) (gateway.FinishRequest, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	req, ok := s.finished[alipayComplainId]
+	return req, ok
+}
+
+// Replies 查询投诉的回复内容
+func (s *Server) Replies(alipayComplainId int64) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.replies[alipayComplainId]...)
+}
+
+// AddComplaint 追加投诉（模拟轮询期间新产生的投诉）
+func (s *Server) AddComplaint(complaint Complaint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.complaints = append(s.complaints, complaint)
+}
+
+// UpdateStatus 修改投诉状态和处理时间（模拟超时、撤诉等状态变化）
+func (s *Server) UpdateStatus(taskID, status string, gmtModified time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.complaints {
+		if s.complaints[i].Item.ComplaintEventID == taskID {
+			s.setStatus(i, status, gmtModified)
+			return true
+		}
+	}
+	return false
+}
+
+// setStatus 修改第i条投诉的状态（调用方需持有锁）
+func (s *Server) setStatus(i int, status string, gmtModified time.Time) {
+	gmt := gmtModified.Format(timeLayout)
+	s.complaints[i].Item.Status = status
+	s.complaints[i].Item.GmtModified = gmt
+	s.complaints[i].Detail.Status = status
+	s.complaints[i].Detail.GmtModified = gmt
+}
+
+// ServeHTTP 处理模拟网关请求
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != gatewayPath || r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+
+	var req request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeResponse(w, http.StatusBadRequest, response{Code: "40004", Msg: "Business Failed", SubCode: "isv.invalid-request", SubMsg: err.Error()})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls[req.Method]++
+
+	if fault := s.takeFault(req); fault != nil {
+		if fault.Err != nil {
+			writeResponse(w, http.StatusOK, response{Code: fault.Err.Code, Msg: fault.Err.Msg, SubCode: fault.Err.SubCode, SubMsg: fault.Err.SubMsg})
+			return
+		}
+		statusCode := fault.HTTPStatus
+		if statusCode == 0 {
+			statusCode = http.StatusServiceUnavailable
+		}
+		writeResponse(w, statusCode, response{Code: "20000", Msg: "Service Currently Unavailable"})
+		return
+	}
+
+	var (
+		data interface{}
+		resp response
+	)
+	switch req.Method {
+	case APIBatchQuery:
+		var listReq gateway.ComplaintListRequest
+		if err := json.Unmarshal(req.BizContent, &listReq); err != nil {
+			resp = invalidParam(err.Error())
+			break
+		}
+		data = s.batchQuery(listReq)
+	case APIInfoQuery:
+		var detailReq gateway.ComplaintDetailRequest
+		if err := json.Unmarshal(req.BizContent, &detailReq); err != nil {
+			resp = invalidParam(err.Error())
+			break
+		}
+		detail, ok := s.detailQuery(detailReq)
+		if !ok {
+			resp = response{Code: "40004", Msg: "Business Failed", SubCode: "COMPLAINT_NOT_EXIST", SubMsg: "投诉单不存在"}
+			break
+		}
+		data = detail
+	case APIFinish:
+		var finishReq gateway.FinishRequest
+		if err := json.Unmarshal(req.BizContent, &finishReq); err != nil {
+			resp = invalidParam(err.Error())
+			break
+		}
+		if !s.finish(finishReq) {
+			resp = response{Code: "40004", Msg: "Business Failed", SubCode: "COMPLAINT_NOT_EXIST", SubMsg: "投诉单不存在"}
+		}
+	case APIReply:
+		var replyReq gateway.ReplyRequest
+		if err := json.Unmarshal(req.BizContent, &replyReq); err != nil {
+			resp = invalidParam(err.Error())
+			break
+		}
+		s.replies[replyReq.AlipayComplainId] = append(s.replies[replyReq.AlipayComplainId], replyReq.Content)
+	case APITradeQuery:
+		var tradeReq gateway.TradeQueryRequest
+		if err := json.Unmarshal(req.BizContent, &tradeReq); err != nil {
+			resp = invalidParam(err.Error())
+			break
+		}
+		trade, ok := s.tradeQuery(tradeReq)
+		if !ok {
+			resp = response{Code: "40004", Msg: "Business Failed", SubCode: "ACQ.TRADE_NOT_EXIST", SubMsg: "交易不存在"}
+			break
+		}
+		data = trade
+	default:
+		resp = response{Code: "40004", Msg: "Business Failed", SubCode: "isv.invalid-method", SubMsg: "不支持的接口: " + req.Method}
+	}
+
+	if resp.Code == "" {
+		resp = response{Code: "10000", Msg: "Success"}
+		if data != nil {
+			resp.Data, _ = json.Marshal(data)
+		}
+	}
+	writeResponse(w, http.StatusOK, resp)
+}
+
+// takeFault 查找命中的故障并扣减次数（调用方需持有锁）
+func (s *Server) takeFault(req request) *Fault {
+	for i := range s.faults {
+		fault := &s.faults[i]
+		if fault.API != req.Method {
+			continue
+		}
+		if fault.Page > 0 {
+			var listReq gateway.ComplaintListRequest
+			if err := json.Unmarshal(req.BizContent, &listReq); err != nil || listReq.PageNum != fault.Page {
+				continue
+			}
+		}
+		if fault.Times > 0 {
+			fault.Times--
+			if fault.Times == 0 {
+				// 次数用完后移除，避免再次命中
+				matched := *fault
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+				return &matched
+			}
+		}
+		return fault
+	}
+	return nil
+}
+
+// batchQuery 按投诉时间、处理时间过滤并分页（调用方需持有锁）
+// 时间格式固定为 yyyy-MM-dd HH:mm:ss，可直接按字符串比较
+func (s *Server) batchQuery(req gateway.ComplaintListRequest) gateway.ComplaintListResponse {
+	matched := make([]gateway.ComplaintItem, 0)
+	for _, complaint := range s.complaints {
+		item := complaint.Item
+		if req.BeginTime != "" && item.GmtCreate < req.BeginTime {
+			continue
+		}
+		if req.EndTime != "" && item.GmtCreate > req.EndTime {
+			continue
+		}
+		if req.ProcessBeginTime != "" && item.GmtModified < req.ProcessBeginTime {
+			continue
+		}
+		if req.ProcessEndTime != "" && item.GmtModified > req.ProcessEndTime {
+			continue
+		}
+		matched = append(matched, item)
+	}
+
+	pageNum, pageSize := req.PageNum, req.PageSize
+	if pageNum < 1 {
+		pageNum = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	start := (pageNum - 1) * pageSize
+	end := start + pageSize
+	if start > len(matched) {
+		start = len(matched)
+	}
+	if end > len(matched) {
+		end = len(matched)
+	}
+
+	return gateway.ComplaintListResponse{
+		Total:         int64(len(matched)),
+		ComplaintList: matched[start:end],
+	}
+}
+
+// detailQuery 查询投诉详情（调用方需持有锁）
+func (s *Server) detailQuery(req gateway.ComplaintDetailRequest) (gateway.ComplaintDetailResponse, bool) {
+	for _, complaint := range s.complaints {
+		if strconv.FormatInt(complaint.Item.ComplaintID, 10) == req.ComplaintEventID {
+			return complaint.Detail, true
+		}
+	}
+	return gateway.ComplaintDetailResponse{}, false
+}
+
+// tradeQuery 按支付宝订单号或商户订单号查询投诉订单的交易（调用方需持有锁）
+func (s *Server) tradeQuery(req gateway.TradeQueryRequest) (gateway.TradeQueryResponse, bool) {
+	for _, complaint := range s.complaints {
+		if complaint.BuyerID == "" {
+			continue
+		}
+		for _, order := range complaint.Detail.TargetOrderList {
+			if (req.TradeNo != "" && order.TradeNo == req.TradeNo) || (req.TradeNo == "" && req.OutTradeNo != "" && order.OutTradeNo == req.OutTradeNo) {
+				return gateway.TradeQueryResponse{
+					TradeNo:     order.TradeNo,
+					OutTradeNo:  order.OutTradeNo,
+					TradeStatus: "TRADE_SUCCESS",
+					BuyerUserID: complaint.BuyerID,
+				}, true
+			}
+		}
+	}
+	return gateway.TradeQueryResponse{}, false
+}
+
+// finish 完结投诉（调用方需持有锁）
+func (s *Server) finish(req gateway.FinishRequest) bool {
+	for i := range s.complaints {
+		if s.complaints[i].Item.ComplaintID != req.AlipayComplainId {
+			continue
+		}
+		status := model.ComplaintStatusProcessed
+		if s.complaints[i].Item.Status == model.ComplaintStatusOverdue {
+			status = model.ComplaintStatusOverdueProcessed
+		}
+		s.setStatus(i, status, time.Now())
+		s.finished[req.AlipayComplainId] = req
+		return true
+	}
+	return false
+}
+
+// invalidParam 参数错误响应
+func invalidParam(msg string) response {
+	return response{Code: "40004", Msg: "Business Failed", SubCode: "isv.invalid-parameter", SubMsg: msg}
+}
+
+// writeResponse 输出JSON响应
+func writeResponse(w http.ResponseWriter, statusCode int, resp response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,198 @@
+package simulator_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"complaint-monitor/internal/gateway"
+	"complaint-monitor/internal/gateway/simulator"
+	"complaint-monitor/internal/model"
+	"complaint-monitor/internal/service"
+
+	"go.uber.org/zap"
+)
+
+const pageSize = 200
+
+// newGateway 启动模拟网关并返回对应的投诉网关
+func newGateway(t *testing.T, scenario *simulator.Scenario) (*simulator.Server, gateway.ComplaintGateway) {
+	t.Helper()
+	srv := simulator.Start(scenario)
+	t.Cleanup(srv.Close)
+
+	gw, err := srv.Provider().Gateway(&model.Subject{ID: 1, AlipayAppID: "2021000000000001"})
+	if err != nil {
+		t.Fatalf("创建模拟网关失败: %v", err)
+	}
+	return srv, gw
+}
+
+// fetchAll 按Worker的方式分页拉取全部投诉（返回数量不足一页时结束）
+func fetchAll(t *testing.T, alipayService *service.AlipayService, gw gateway.ComplaintGateway, req gateway.ComplaintListRequest) []gateway.ComplaintItem {
+	t.Helper()
+	var items []gateway.ComplaintItem
+	for pageNum := 1; ; pageNum++ {
+		req.PageNum = pageNum
+		req.PageSize = pageSize
+		resp, err := alipayService.FetchComplaintList(context.Background(), gw, req)
+		if err != nil {
+			t.Fatalf("获取第%d页失败: %v", pageNum, err)
+		}
+		items = append(items, resp.ComplaintList...)
+		if len(resp.ComplaintList) < pageSize {
+			return items
+		}
+	}
+}
+
+func TestPagedScenario(t *testing.T) {
+	srv, gw := newGateway(t, simulator.PagedScenario(450))
+	alipayService := service.NewAlipayService(nil, zap.NewNop())
+
+	items := fetchAll(t, alipayService, gw, gateway.ComplaintListRequest{})
+
+	if len(items) != 450 {
+		t.Fatalf("投诉数量 = %d, 期望 450", len(items))
+	}
+	seen := make(map[string]bool)
+	for _, item := range items {
+		if seen[item.ComplaintEventID] {
+			t.Fatalf("投诉重复: %s", item.ComplaintEventID)
+		}
+		seen[item.ComplaintEventID] = true
+	}
+	if calls := srv.Calls(simulator.APIBatchQuery); calls != 3 {
+		t.Errorf("列表接口调用次数 = %d, 期望 3", calls)
+	}
+}
+
+func TestPageBoundaryScenario(t *testing.T) {
+	srv, gw := newGateway(t, simulator.PageBoundaryScenario(pageSize))
+	alipayService := service.NewAlipayService(nil, zap.NewNop())
+
+	items := fetchAll(t, alipayService, gw, gateway.ComplaintListRequest{})
+
+	if len(items) != pageSize {
+		t.Fatalf("投诉数量 = %d, 期望 %d", len(items), pageSize)
+	}
+	// 最后一页满页时需要再请求一页空数据才能确认结束
+	if calls := srv.Calls(simulator.APIBatchQuery); calls != 2 {
+		t.Errorf("列表接口调用次数 = %d, 期望 2", calls)
+	}
+}
+
+func TestRateLimitedScenario(t *testing.T) {
+	_, gw := newGateway(t, simulator.RateLimitedScenario(10, 2))
+	alipayService := service.NewAlipayService(nil, zap.NewNop())
+	req := gateway.ComplaintListRequest{PageNum: 1, PageSize: pageSize}
+
+	for i := 0; i < 2; i++ {
+		_, err := alipayService.FetchComplaintList(context.Background(), gw, req)
+		apiErr, ok := gateway.AsAPIError(err)
+		if !ok {
+			t.Fatalf("第%d次调用应返回业务错误, 实际: %v", i+1, err)
+		}
+		if apiErr.Code != "40005" {
+			t.Errorf("错误码 = %s, 期望 40005", apiErr.Code)
+		}
+	}
+
+	resp, err := alipayService.FetchComplaintList(context.Background(), gw, req)
+	if err != nil {
+		t.Fatalf("限流结束后调用失败: %v", err)
+	}
+	if len(resp.ComplaintList) != 10 {
+		t.Errorf("投诉数量 = %d, 期望 10", len(resp.ComplaintList))
+	}
+}
+
+func TestProcessTimeFilter(t *testing.T) {
+	srv, gw := newGateway(t, simulator.PagedScenario(5))
+	alipayService := service.NewAlipayService(nil, zap.NewNop())
+
+	since := time.Now()
+	if !srv.UpdateStatus("SIM00000003", model.ComplaintStatusOverdue, since.Add(time.Minute)) {
+		t.Fatal("修改投诉状态失败")
+	}
+
+	items := fetchAll(t, alipayService, gw, gateway.ComplaintListRequest{
+		ProcessBeginTime: since.Format("2006-01-02 15:04:05"),
+		ProcessEndTime:   since.Add(time.Hour).Format("2006-01-02 15:04:05"),
+	})
+
+	if len(items) != 1 {
+		t.Fatalf("按处理时间查询到 %d 条, 期望 1", len(items))
+	}
+	if items[0].Status != model.ComplaintStatusOverdue {
+		t.Errorf("状态 = %s, 期望 %s", items[0].Status, model.ComplaintStatusOverdue)
+	}
+}
+
+func TestFinishAndReply(t *testing.T) {
+	srv, gw := newGateway(t, simulator.MultiOrderScenario(1, 3))
+	alipayService := service.NewAlipayService(nil, zap.NewNop())
+	ctx := context.Background()
+
+	detail, err := alipayService.FetchComplaintDetail(ctx, gw, gateway.ComplaintDetailRequest{ComplaintEventID: "100001"})
+	if err != nil {
+		t.Fatalf("获取投诉详情失败: %v", err)
+	}
+	if len(detail.TargetOrderList) != 3 {
+		t.Fatalf("订单数量 = %d, 期望 3", len(detail.TargetOrderList))
+	}
+
+	if err := alipayService.ReplyComplaint(ctx, gw, 100001, "已联系用户"); err != nil {
+		t.Fatalf("回复投诉失败: %v", err)
+	}
+	if replies := srv.Replies(100001); len(replies) != 1 || replies[0] != "已联系用户" {
+		t.Errorf("回复内容 = %v", replies)
+	}
+
+	if err := alipayService.FinishComplaint(ctx, gw, 100001, "REFUND", "已退款"); err != nil {
+		t.Fatalf("完结投诉失败: %v", err)
+	}
+	if req, ok := srv.Finished(100001); !ok || req.ProcessCode != "REFUND" {
+		t.Errorf("完结请求 = %+v, %v", req, ok)
+	}
+
+	detail, err = alipayService.FetchComplaintDetail(ctx, gw, gateway.ComplaintDetailRequest{ComplaintEventID: "100001"})
+	if err != nil {
+		t.Fatalf("获取投诉详情失败: %v", err)
+	}
+	if detail.Status != model.ComplaintStatusProcessed {
+		t.Errorf("完结后状态 = %s, 期望 %s", detail.Status, model.ComplaintStatusProcessed)
+	}
+
+	if err := alipayService.FinishComplaint(ctx, gw, 999, "REFUND", ""); err == nil {
+		t.Error("完结不存在的投诉应返回错误")
+	}
+}
+
+func TestTradeQuery(t *testing.T) {
+	_, gw := newGateway(t, simulator.MultiOrderScenario(1, 2))
+	alipayService := service.NewAlipayService(nil, zap.NewNop())
+	ctx := context.Background()
+
+	detail, err := alipayService.FetchComplaintDetail(ctx, gw, gateway.ComplaintDetailRequest{ComplaintEventID: "100001"})
+	if err != nil {
+		t.Fatalf("获取投诉详情失败: %v", err)
+	}
+	order := detail.TargetOrderList[1]
+
+	// 按支付宝订单号或商户订单号查询都返回买家ID
+	for _, req := range []gateway.TradeQueryRequest{{TradeNo: order.TradeNo}, {OutTradeNo: order.OutTradeNo}} {
+		trade, err := alipayService.QueryTrade(ctx, gw, req.TradeNo, req.OutTradeNo)
+		if err != nil {
+			t.Fatalf("查询交易失败: %v", err)
+		}
+		if trade.OutTradeNo != order.OutTradeNo || trade.BuyerID() == "" || trade.BuyerID() == detail.ComplainantID {
+			t.Errorf("交易查询结果 = %+v", trade)
+		}
+	}
+
+	_, err = alipayService.QueryTrade(ctx, gw, "NOT_EXIST", "")
+	if apiErr, ok := gateway.AsAPIError(err); !ok || apiErr.SubCode != "ACQ.TRADE_NOT_EXIST" {
+		t.Errorf("查询不存在的交易应返回 ACQ.TRADE_NOT_EXIST，实际 %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package gateway
+
+// ComplaintListRequest 投诉列表请求参数
+type ComplaintListRequest struct {
+	BeginTime        string `json:"begin_time"`         // 查询开始时间（必填，格式：yyyy-MM-dd HH:mm:ss）
+	EndTime          string `json:"end_time"`           // 查询结束时间（必填，格式：yyyy-MM-dd HH:mm:ss）
+	ProcessBeginTime string `json:"process_begin_time"` // 处理时间范围下界（可选，设置后按处理时间过滤，用于发现状态变更）
+	ProcessEndTime   string `json:"process_end_time"`   // 处理时间范围上界（可选）
+	PageNum          int    `json:"page_num"`           // 页码（从1开始）
+	PageSize         int    `json:"page_size"`          // 每页数量（默认20，最大200）
+}
+
+// ComplaintListResponse 投诉列表响应
+type ComplaintListResponse struct {
+	Total         int64           `json:"total"`          // 总数量
+	ComplaintList []ComplaintItem `json:"complaint_list"` // 投诉列表
+}
+
+// ComplaintItem 投诉项
+type ComplaintItem struct {
+	ComplaintID      int64  `json:"complaint_id"`       // 投诉主表主键ID（用于查询详情）
+	ComplaintEventID string `json:"complaint_event_id"` // 投诉单号（TaskId）
+	Status           string `json:"status"`             // 投诉状态
+	ComplainantID    string `json:"complainant_id"`     // 投诉人ID（取自OppositePid，未经校验，可能是被投诉方PID）
+	GmtCreate        string `json:"gmt_create"`         // 创建时间（GmtComplain）
+	GmtModified      string `json:"gmt_modified"`       // 修改时间（GmtProcess）
+}
+
+// ComplaintDetailRequest 投诉详情请求参数
+type ComplaintDetailRequest struct {
+	ComplaintEventID string `json:"complaint_event_id"` // 投诉主表主键ID（必填，数字字符串）
+}
+
+// ComplaintDetailResponse 投诉详情响应
+type ComplaintDetailResponse struct {
+	ComplaintEventID string      `json:"complaint_event_id"` // 投诉单号
+	Status           string      `json:"status"`             // 投诉状态
+	ComplainantID    string      `json:"complainant_id"`     // 投诉人ID（取自OppositePid，未经校验，可能是被投诉方PID）
+	ComplainantName  string      `json:"complainant_name"`   // 投诉人姓名
+	ComplaintReason  string      `json:"complaint_reason"`   // 投诉原因
+	GmtCreate        string      `json:"gmt_create"`         // 创建时间
+	GmtModified      string      `json:"gmt_modified"`       // 修改时间
+	TargetOrderList  []OrderItem `json:"target_order_list"`  // 订单列表
+}
+
+// OrderItem 订单项
+type OrderItem struct {
+	TradeNo         string  `json:"trade_no"`         // 支付宝订单号
+	OutTradeNo      string  `json:"out_trade_no"`     // 商户订单号
+	Amount          float64 `json:"amount"`           // 订单金额
+	ComplaintAmount float64 `json:"complaint_amount"` // 投诉金额
+	Status          string  `json:"status"`           // 交易投诉状态
+	GmtRefund       string  `json:"gmt_refund"`       // 退款时间（为空表示未退款）
+}
+
+// IsRefunded 订单是否已退款
+func (o OrderItem) IsRefunded() bool {
+	return o.GmtRefund != ""
+}
+
+// RefundAmount 计算投诉涉及订单的已退款金额
+func (r *ComplaintDetailResponse) RefundAmount() float64 {
+	var total float64
+	for _, order := range r.TargetOrderList {
+		if order.IsRefunded() {
+			total += order.Amount
+		}
+	}
+	return total
+}
+
+// FinishRequest 完结投诉请求
+type FinishRequest struct {
+	AlipayComplainId int64  `json:"alipay_complain_id"` // 支付宝投诉主表ID（complaint_list中的id）
+	ProcessCode      string `json:"process_code"`       // 商家处理结果码（参见支付宝文档）
+	Remark           string `json:"remark"`             // 处理备注（展示给消费者）
+}
+
+// ReplyRequest 回复投诉请求
+type ReplyRequest struct {
+	AlipayComplainId int64  `json:"alipay_complain_id"` // 支付宝投诉主表ID（complaint_list中的id）
+	Content          string `json:"content"`            // 回复内容
+}
+
+// TradeQueryRequest 交易查询请求（支付宝订单号与商户订单号二选一，都有时以支付宝订单号为准）
+type TradeQueryRequest struct {
+	TradeNo    string `json:"trade_no,omitempty"`     // 支付宝订单号
+	OutTradeNo string `json:"out_trade_no,omitempty"` // 商户订单号
+}
+
+// TradeQueryResponse 交易查询响应
+type TradeQueryResponse struct {
+	TradeNo      string `json:"trade_no"`       // 支付宝订单号
+	OutTradeNo   string `json:"out_trade_no"`   // 商户订单号
+	TradeStatus  string `json:"trade_status"`   // 交易状态（WAIT_BUYER_PAY/TRADE_CLOSED/TRADE_SUCCESS/TRADE_FINISHED）
+	BuyerUserID  string `json:"buyer_user_id"`  // 买家支付宝用户ID（2088开头）
+	BuyerOpenID  string `json:"buyer_open_id"`  // 买家支付宝用户唯一标识（openid模式的应用只返回该字段）
+	BuyerLogonID string `json:"buyer_logon_id"` // 买家支付宝账号（脱敏）
+}
+
+// BuyerID 买家标识（优先返回 buyer_user_id，没有时返回 buyer_open_id）
+func (r *TradeQueryResponse) BuyerID() string {
+	if r.BuyerUserID != "" {
+		return r.BuyerUserID
+	}
+	return r.BuyerOpenID
+}
@@ -0,0 +1,163 @@
+// Package iprange 提供按CIDR网段匹配IP地址的基数树（radix tree），支持IPv4和IPv6
+package iprange
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// node 基数树节点（每层按地址的一个比特分支）
+type node[V any] struct {
+	children [2]*node[V]
+	value    V
+	has      bool // 该节点对应的网段是否有值
+}
+
+// Tree CIDR网段基数树
+// IPv4和IPv6分别使用一棵树，IPv4映射的IPv6地址（::ffff:a.b.c.d）按IPv4处理；非并发安全，由调用方加锁
+type Tree[V any] struct {
+	v4   *node[V]
+	v6   *node[V]
+	size int
+}
+
+// New 创建基数树
+func New[V any]() *Tree[V] {
+	return &Tree[V]{
+		v4: &node[V]{},
+		v6: &node[V]{},
+	}
+}
+
+// ParsePrefix 解析CIDR网段（也接受单个IP，视为/32或/128），返回主机位清零后的网段
+func ParsePrefix(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, "/") {
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("无效的IP地址: %s", s)
+		}
+		addr = addr.Unmap()
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("无效的CIDR网段: %s", s)
+	}
+	if prefix.Addr().Is4In6() {
+		// ::ffff:a.b.c.d/n 转为 a.b.c.d/(n-96)
+		bits := prefix.Bits() - 96
+		if bits < 0 {
+			return netip.Prefix{}, fmt.Errorf("无效的CIDR网段: %s", s)
+		}
+		prefix = netip.PrefixFrom(prefix.Addr().Unmap(), bits)
+	}
+	return prefix.Masked(), nil
+}
+
+// Insert 写入网段（已存在时覆盖）
+func (t *Tree[V]) Insert(prefix netip.Prefix, value V) {
+	prefix = prefix.Masked()
+	n := t.root(prefix.Addr())
+	bytes := addrBytes(prefix.Addr())
+	for i := 0; i < prefix.Bits(); i++ {
+		b := bit(bytes, i)
+		if n.children[b] == nil {
+			n.children[b] = &node[V]{}
+		}
+		n = n.children[b]
+	}
+	if !n.has {
+		t.size++
+	}
+	n.value = value
+	n.has = true
+}
+
+// Delete 删除网段，返回是否存在
+func (t *Tree[V]) Delete(prefix netip.Prefix) bool {
+	prefix = prefix.Masked()
+	bytes := addrBytes(prefix.Addr())
+	deleted := false
+
+	// 递归删除并回收不再有值的空分支
+	var remove func(n *node[V], depth int) bool
+	remove = func(n *node[V], depth int) bool {
+		if depth == prefix.Bits() {
+			if n.has {
+				var zero V
+				n.value = zero
+				n.has = false
+				deleted = true
+			}
+		} else {
+			b := bit(bytes, depth)
+			child := n.children[b]
+			if child == nil {
+				return false
+			}
+			if remove(child, depth+1) {
+				n.children[b] = nil
+			}
+		}
+		return !n.has && n.children[0] == nil && n.children[1] == nil
+	}
+	remove(t.root(prefix.Addr()), 0)
+
+	if deleted {
+		t.size--
+	}
+	return deleted
+}
+
+// Lookup 查询包含该地址的所有网段的值（按网段从大到小排列）
+func (t *Tree[V]) Lookup(addr netip.Addr) []V {
+	addr = addr.Unmap()
+	n := t.root(addr)
+	bytes := addrBytes(addr)
+
+	var values []V
+	for i := 0; ; i++ {
+		if n.has {
+			values = append(values, n.value)
+		}
+		if i == addr.BitLen() {
+			break
+		}
+		n = n.children[bit(bytes, i)]
+		if n == nil {
+			break
+		}
+	}
+	return values
+}
+
+// Len 网段数量
+func (t *Tree[V]) Len() int {
+	return t.size
+}
+
+// root 地址所属的树
+func (t *Tree[V]) root(addr netip.Addr) *node[V] {
+	if addr.Is4() {
+		return t.v4
+	}
+	return t.v6
+}
+
+// addrBytes 地址的字节表示（IPv4为4字节，IPv6为16字节）
+func addrBytes(addr netip.Addr) []byte {
+	if addr.Is4() {
+		b := addr.As4()
+		return b[:]
+	}
+	b := addr.As16()
+	return b[:]
+}
+
+// bit 取第i个比特（从最高位开始）
+func bit(bytes []byte, i int) int {
+	return int(bytes[i/8]>>(7-uint(i%8))) & 1
+}
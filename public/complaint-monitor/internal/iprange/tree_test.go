@@ -0,0 +1,83 @@
+package iprange
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func mustPrefix(t *testing.T, s string) netip.Prefix {
+	t.Helper()
+	prefix, err := ParsePrefix(s)
+	if err != nil {
+		t.Fatalf("ParsePrefix(%q) error = %v", s, err)
+	}
+	return prefix
+}
+
+func TestParsePrefix(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"1.2.3.4", "1.2.3.4/32"},
+		{"1.2.3.4/24", "1.2.3.0/24"},
+		{"::ffff:1.2.3.4/120", "1.2.3.0/24"},
+		{"2001:db8::1/64", "2001:db8::/64"},
+		{"2001:db8::1", "2001:db8::1/128"},
+	}
+	for _, tt := range tests {
+		if got := mustPrefix(t, tt.in).String(); got != tt.want {
+			t.Errorf("ParsePrefix(%q) = %s, 期望 %s", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "1.2.3", "1.2.3.4/33", "abc/8"} {
+		if _, err := ParsePrefix(in); err == nil {
+			t.Errorf("ParsePrefix(%q) 应返回错误", in)
+		}
+	}
+}
+
+func TestTreeLookup(t *testing.T) {
+	tree := New[string]()
+	tree.Insert(mustPrefix(t, "10.0.0.0/8"), "a")
+	tree.Insert(mustPrefix(t, "10.1.2.0/24"), "b")
+	tree.Insert(mustPrefix(t, "2001:db8::/32"), "c")
+
+	tests := []struct {
+		addr string
+		want []string
+	}{
+		{"10.1.2.3", []string{"a", "b"}},
+		{"10.9.9.9", []string{"a"}},
+		{"::ffff:10.1.2.3", []string{"a", "b"}},
+		{"11.0.0.1", nil},
+		{"2001:db8:1::1", []string{"c"}},
+		{"2001:db9::1", nil},
+	}
+	for _, tt := range tests {
+		got := tree.Lookup(netip.MustParseAddr(tt.addr))
+		if len(got) != len(tt.want) {
+			t.Errorf("Lookup(%s) = %v, 期望 %v", tt.addr, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Lookup(%s) = %v, 期望 %v", tt.addr, got, tt.want)
+			}
+		}
+	}
+
+	if tree.Len() != 3 {
+		t.Errorf("Len() = %d, 期望 3", tree.Len())
+	}
+	if !tree.Delete(mustPrefix(t, "10.0.0.0/8")) || tree.Delete(mustPrefix(t, "10.0.0.0/8")) {
+		t.Error("Delete 应只删除一次")
+	}
+	if got := tree.Lookup(netip.MustParseAddr("10.1.2.3")); len(got) != 1 || got[0] != "b" {
+		t.Errorf("删除后 Lookup = %v", got)
+	}
+	if tree.Len() != 2 {
+		t.Errorf("删除后 Len() = %d, 期望 2", tree.Len())
+	}
+}
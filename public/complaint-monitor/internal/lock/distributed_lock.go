@@ -21,9 +21,10 @@ type DistributedLock struct {
 
 // LockResult 锁结果
 type LockResult struct {
-	Key      string
-	Value    string // UUID
-	acquired bool
+	Key        string
+	Value      string // UUID
+	FenceToken int64  // fencing token（仅 WithLock 获取的锁有值）
+	acquired   bool
 }
 
 // NewDistributedLock 创建分布式锁管理器
@@ -110,13 +111,13 @@ func (dl *DistributedLock) RenewLock(ctx context.Context, lockResult *LockResult
 	// 使用Lua脚本续期（只有持有锁的协程才能续期）
 	luaScript := `
 		if redis.call("get", KEYS[1]) == ARGV[1] then
-			return redis.call("expire", KEYS[1], ARGV[2])
+			return redis.call("pexpire", KEYS[1], ARGV[2])
 		else
 			return 0
 		end
 	`
 
-	result, err := dl.redis.Eval(ctx, luaScript, []string{lockResult.Key}, lockResult.Value, int(ttl.Seconds())).Result()
+	result, err := dl.redis.Eval(ctx, luaScript, []string{lockResult.Key}, lockResult.Value, ttl.Milliseconds()).Result()
 	if err != nil {
 		return fmt.Errorf("续期锁失败: %w", err)
 	}
@@ -126,7 +127,7 @@ func (dl *DistributedLock) RenewLock(ctx context.Context, lockResult *LockResult
 			zap.String("key", lockResult.Key),
 			zap.Duration("ttl", ttl))
 	} else {
-		return fmt.Errorf("锁已丢失或已过期")
+		return ErrLockLost
 	}
 
 	return nil
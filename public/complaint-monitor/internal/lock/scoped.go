@@ -0,0 +1,141 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// fenceCounterKey 全局fencing token计数器
+// 所有锁共用一个计数器，token全局单调递增，不会因计数器过期而回退
+const fenceCounterKey = "lock:fence:counter"
+
+// releaseTimeout 释放锁的超时时间（调用方上下文可能已取消）
+const releaseTimeout = 5 * time.Second
+
+var (
+	// ErrNotAcquired 锁已被其他持有者占用
+	ErrNotAcquired = errors.New("锁已被其他持有者占用")
+	// ErrLockLost 锁在持有期间丢失（续期失败或已过期）
+	ErrLockLost = errors.New("锁已丢失或已过期")
+)
+
+// acquireWithFenceScript 获取锁并分配fencing token（原子操作）
+// KEYS[1]: 锁键，KEYS[2]: token计数器；ARGV[1]: 锁值，ARGV[2]: TTL（毫秒）
+// 返回0表示锁已被占用，否则返回token
+var acquireWithFenceScript = redis.NewScript(`
+if redis.call("set", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return redis.call("incr", KEYS[2])
+end
+return 0
+`)
+
+// Options WithLock 选项
+type Options struct {
+	TTL           time.Duration // 锁TTL（为0时使用基础TTL）
+	RenewInterval time.Duration // 续期间隔（为0时为TTL的1/3）
+}
+
+// WithLock 持有锁执行 fn，执行期间自动续期
+// fn 收到的 fenceToken 全局单调递增，写库时携带该token，拒绝比已写入token更旧的写入
+// 锁丢失时取消 fn 的上下文并返回 ErrLockLost；锁被占用时返回 ErrNotAcquired
+func (dl *DistributedLock) WithLock(ctx context.Context, key string, opts Options, fn func(ctx context.Context, fenceToken int64) error) error {
+	ttl := opts.TTL
+	if ttl <= 0 {
+		ttl = dl.baseTTL
+	}
+	renewInterval := opts.RenewInterval
+	if renewInterval <= 0 {
+		renewInterval = ttl / 3
+	}
+
+	lockValue := generateUUID()
+	token, err := acquireWithFenceScript.Run(ctx, dl.redis, []string{key, fenceCounterKey}, lockValue, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return fmt.Errorf("获取锁失败: %w", err)
+	}
+	if token == 0 {
+		return ErrNotAcquired
+	}
+
+	lockResult := &LockResult{
+		Key:        key,
+		Value:      lockValue,
+		FenceToken: token,
+		acquired:   true,
+	}
+
+	dl.logger.Debug("获取锁成功",
+		zap.String("key", key),
+		zap.Int64("fence_token", token),
+		zap.Duration("ttl", ttl))
+
+	fnCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	stopRenew := make(chan struct{})
+	renewDone := make(chan struct{})
+	go func() {
+		defer close(renewDone)
+		dl.keepAlive(fnCtx, lockResult, ttl, renewInterval, stopRenew, cancel)
+	}()
+
+	fnErr := fn(fnCtx, token)
+
+	close(stopRenew)
+	<-renewDone
+
+	releaseCtx, releaseCancel := context.WithTimeout(context.WithoutCancel(ctx), releaseTimeout)
+	defer releaseCancel()
+	if err := dl.ReleaseLock(releaseCtx, lockResult); err != nil {
+		dl.logger.Error("释放锁失败", zap.String("key", key), zap.Error(err))
+	}
+
+	if errors.Is(context.Cause(fnCtx), ErrLockLost) {
+		if fnErr != nil {
+			return fmt.Errorf("%w: %v", ErrLockLost, fnErr)
+		}
+		return ErrLockLost
+	}
+	return fnErr
+}
+
+// keepAlive 定期续期锁
+// 锁已被他人持有，或连续续期失败超过TTL（锁必然已过期）时，以 ErrLockLost 取消上下文
+func (dl *DistributedLock) keepAlive(ctx context.Context, lockResult *LockResult, ttl, interval time.Duration, stop <-chan struct{}, cancel context.CancelCauseFunc) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	lastRenewed := time.Now()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := dl.RenewLock(ctx, lockResult, ttl)
+			if err == nil {
+				lastRenewed = time.Now()
+				continue
+			}
+
+			if errors.Is(err, ErrLockLost) || time.Since(lastRenewed) >= ttl {
+				dl.logger.Error("锁已丢失，取消持锁任务",
+					zap.String("key", lockResult.Key),
+					zap.Int64("fence_token", lockResult.FenceToken),
+					zap.Error(err))
+				cancel(ErrLockLost)
+				return
+			}
+
+			dl.logger.Warn("续期锁失败，稍后重试",
+				zap.String("key", lockResult.Key),
+				zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"complaint-monitor/internal/testutil"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// newTestLock 创建连接测试Redis的锁管理器，返回本次测试独占的锁键
+func newTestLock(t *testing.T) (*DistributedLock, *redis.Client, string) {
+	client := testutil.NewTestRedis(t)
+	key := fmt.Sprintf("test:lock:%d", time.Now().UnixNano())
+	t.Cleanup(func() {
+		client.Del(context.Background(), key)
+	})
+	return NewDistributedLock(client, time.Second, 10*time.Second, zap.NewNop()), client, key
+}
+
+func TestWithLockRenewsBeyondTTL(t *testing.T) {
+	dl, client, key := newTestLock(t)
+	ctx := context.Background()
+
+	opts := Options{TTL: 300 * time.Millisecond, RenewInterval: 100 * time.Millisecond}
+	err := dl.WithLock(ctx, key, opts, func(ctx context.Context, fenceToken int64) error {
+		// 持有时间超过TTL的3倍，续期正常时锁不会过期
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("持锁期间上下文被取消: %w", context.Cause(ctx))
+		case <-time.After(time.Second):
+		}
+
+		exists, err := client.Exists(ctx, key).Result()
+		if err != nil {
+			return err
+		}
+		if exists != 1 {
+			return errors.New("续期后锁已过期")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithLock 返回错误: %v", err)
+	}
+
+	exists, err := client.Exists(ctx, key).Result()
+	if err != nil {
+		t.Fatalf("查询锁失败: %v", err)
+	}
+	if exists != 0 {
+		t.Error("WithLock 返回后锁未释放")
+	}
+}
+
+func TestWithLockLost(t *testing.T) {
+	dl, client, key := newTestLock(t)
+	ctx := context.Background()
+
+	opts := Options{TTL: time.Second, RenewInterval: 50 * time.Millisecond}
+	err := dl.WithLock(ctx, key, opts, func(ctx context.Context, fenceToken int64) error {
+		// 模拟锁过期后被其他持有者获取
+		if err := client.Set(ctx, key, "other-holder", time.Minute).Err(); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			if !errors.Is(context.Cause(ctx), ErrLockLost) {
+				return fmt.Errorf("上下文取消原因 = %v, 期望 ErrLockLost", context.Cause(ctx))
+			}
+			return nil
+		case <-time.After(time.Second):
+			return errors.New("锁丢失后上下文未被取消")
+		}
+	})
+	if !errors.Is(err, ErrLockLost) {
+		t.Fatalf("WithLock 返回 %v, 期望 ErrLockLost", err)
+	}
+
+	// 释放锁时不能删除其他持有者的锁
+	value, err := client.Get(ctx, key).Result()
+	if err != nil {
+		t.Fatalf("查询锁失败: %v", err)
+	}
+	if value != "other-holder" {
+		t.Errorf("锁的值 = %q, 期望仍为其他持有者", value)
+	}
+}
+
+func TestWithLockNotAcquired(t *testing.T) {
+	dl, client, key := newTestLock(t)
+	ctx := context.Background()
+
+	if err := client.Set(ctx, key, "other-holder", time.Minute).Err(); err != nil {
+		t.Fatalf("写入锁失败: %v", err)
+	}
+
+	called := false
+	err := dl.WithLock(ctx, key, Options{}, func(ctx context.Context, fenceToken int64) error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, ErrNotAcquired) {
+		t.Fatalf("WithLock 返回 %v, 期望 ErrNotAcquired", err)
+	}
+	if called {
+		t.Error("锁被占用时不应执行 fn")
+	}
+}
+
+func TestWithLockFenceTokenMonotonic(t *testing.T) {
+	dl, client, key := newTestLock(t)
+	ctx := context.Background()
+
+	otherKey := key + ":other"
+	t.Cleanup(func() {
+		client.Del(context.Background(), otherKey)
+	})
+
+	// 同一个键反复获取、不同键交替获取，token都严格递增
+	keys := []string{key, key, otherKey, key, otherKey}
+	var previous int64
+	for i, k := range keys {
+		var token int64
+		err := dl.WithLock(ctx, k, Options{}, func(ctx context.Context, fenceToken int64) error {
+			token = fenceToken
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("第%d次 WithLock 返回错误: %v", i+1, err)
+		}
+		if token <= previous {
+			t.Fatalf("第%d次获取的token = %d，未大于上一次的 %d", i+1, token, previous)
+		}
+		previous = token
+	}
+}
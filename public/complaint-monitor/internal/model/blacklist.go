@@ -1,22 +1,41 @@
 package model
 
-import "time"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
 
 // AlipayBlacklist 支付宝黑名单模型
 // 注意：此模型匹配现有的数据库表结构
 // 唯一索引：uniq_blacklist (alipay_user_id, device_code, ip_address)
+// MySQL唯一索引中NULL互不相等，设备码或IP为空的记录不受 uniq_blacklist 约束，实际去重依靠 uk_unique_key (unique_key)
 type AlipayBlacklist struct {
-	ID           uint       `gorm:"column:id;primaryKey" json:"id"`
-	AlipayUserID string     `gorm:"column:alipay_user_id;not null;size:64;uniqueIndex:uniq_blacklist,priority:1;index:idx_alipay_user_id" json:"alipay_user_id"`
-	DeviceCode   *string    `gorm:"column:device_code;size:128;uniqueIndex:uniq_blacklist,priority:2;index:idx_device_code" json:"device_code"` // 使用指针类型，空值时存储NULL
-	IPAddress    *string    `gorm:"column:ip_address;size:64;uniqueIndex:uniq_blacklist,priority:3;index:idx_ip_address" json:"ip_address"`     // 使用指针类型，空值时存储NULL
-	RiskCount    int        `gorm:"column:risk_count;default:1" json:"risk_count"`                                                              // 风险触发次数
-	LastRiskTime *time.Time `gorm:"column:last_risk_time;index:idx_last_risk_time" json:"last_risk_time"`                                       // 最后一次触发风险时间
-	Remark       string     `gorm:"column:remark;size:255" json:"remark"`                                                                       // 备注信息
-	CreatedAt    time.Time  `gorm:"column:created_at" json:"created_at"`
-	UpdatedAt    time.Time  `gorm:"column:updated_at" json:"updated_at"`
+	ID             uint       `gorm:"column:id;primaryKey" json:"id"`
+	AlipayUserID   string     `gorm:"column:alipay_user_id;not null;size:64;uniqueIndex:uniq_blacklist,priority:1;index:idx_alipay_user_id" json:"alipay_user_id"`
+	DeviceCode     *string    `gorm:"column:device_code;size:128;uniqueIndex:uniq_blacklist,priority:2;index:idx_device_code" json:"device_code"` // 使用指针类型，空值时存储NULL
+	IPAddress      *string    `gorm:"column:ip_address;size:64;uniqueIndex:uniq_blacklist,priority:3;index:idx_ip_address" json:"ip_address"`     // 使用指针类型，空值时存储NULL
+	UniqueKey      *string    `gorm:"column:unique_key;->;size:64;uniqueIndex:uk_unique_key" json:"-"`                                            // 唯一键哈希（见 BlacklistUniqueKey，数据库生成列，只读）
+	RiskCount      int        `gorm:"column:risk_count;default:1" json:"risk_count"`                                                              // 风险触发次数
+	LastRiskTime   *time.Time `gorm:"column:last_risk_time;index:idx_last_risk_time" json:"last_risk_time"`                                       // 最后一次触发风险时间
+	Remark         string     `gorm:"column:remark;size:255" json:"remark"`                                                                       // 备注信息
+	RiskLevel      string     `gorm:"column:risk_level;size:16;not null;default:'low';index:idx_risk_level" json:"risk_level"`                    // 风险等级（low/medium/high/critical，只升不降）
+	ExpireAt       *time.Time `gorm:"column:expire_at;index:idx_expire_at" json:"expire_at"`                                                      // 过期时间（按风险等级计算，为空表示永久有效）
+	IdentitySource string     `gorm:"column:identity_source;size:16;not null;default:''" json:"identity_source"`                                  // 买家身份来源（order/trade_query/complainant/buyer_graph/import，为空表示未知）
+	FenceToken     int64      `gorm:"column:fence_token;not null;default:0" json:"-"`                                                             // 最后一次写入时持有的买家黑名单锁fencing token（拒绝更旧的写入）
+	CreatedAt      time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"column:updated_at" json:"updated_at"`
 }
 
+// 黑名单买家身份来源
+const (
+	IdentitySourceOrder       = "order"       // 订单表的 buyer_id
+	IdentitySourceTradeQuery  = "trade_query" // 支付宝交易查询的 buyer_user_id
+	IdentitySourceComplainant = "complainant" // 投诉详情中的投诉人字段（已校验不是商户PID）
+	IdentitySourceBuyerGraph  = "buyer_graph" // 买家关联分析
+	IdentitySourceImport      = "import"      // 批量导入
+)
+
 // TableName 指定表名
 func (AlipayBlacklist) TableName() string {
 	return "alipay_blacklist"
@@ -28,3 +47,12 @@ func (b *AlipayBlacklist) IncrementRiskCount() {
 	now := time.Now()
 	b.LastRiskTime = &now
 }
+
+// BlacklistUniqueKey 计算黑名单唯一键：SHA-256(alipay_user_id + "\x1f" + device_code + "\x1f" + ip_address) 的十六进制
+// 设备码和IP为空时按空字符串参与计算，与数据库生成列 unique_key 的以下表达式一致（用于内存中去重）：
+//
+//	SHA2(CONCAT(alipay_user_id, CHAR(31), IFNULL(device_code, ''), CHAR(31), IFNULL(ip_address, '')), 256)
+func BlacklistUniqueKey(alipayUserID, deviceCode, ipAddress string) string {
+	sum := sha256.Sum256([]byte(alipayUserID + "\x1f" + deviceCode + "\x1f" + ipAddress))
+	return hex.EncodeToString(sum[:])
+}
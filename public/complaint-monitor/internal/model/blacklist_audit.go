@@ -0,0 +1,96 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// 黑名单审计动作
+const (
+	BlacklistAuditInsert    = "insert"    // 新增拉黑
+	BlacklistAuditIncrement = "increment" // 再次触发，风险计数加1
+	BlacklistAuditDecrement = "decrement" // 撤诉，风险计数减1
+	BlacklistAuditUnblock   = "unblock"   // 解除拉黑（过期、撤诉、手动删除）
+	BlacklistAuditEdit      = "edit"      // 管理接口手动修改
+	BlacklistAuditImport    = "import"    // 批量导入（新增或合并更新）
+)
+
+// 黑名单审计操作方类型
+const (
+	BlacklistActorSystem   = "system"   // 系统规则（投诉拉黑、关联分析、过期清理等）
+	BlacklistActorOperator = "operator" // 管理接口操作人
+	BlacklistActorImport   = "import"   // 导入命令
+)
+
+// BlacklistAudit 黑名单变更审计记录（只追加，不更新不删除）
+// 每条记录的 Hash 由上一条记录的 Hash 和本条内容计算，按 Seq 连续成链：
+// 修改或删除任意一条记录都会导致之后的校验失败
+type BlacklistAudit struct {
+	ID           uint      `gorm:"column:id;primaryKey" json:"id"`
+	Seq          int64     `gorm:"column:seq;not null;uniqueIndex:uk_seq" json:"seq"`                            // 链序号（从1开始连续递增）
+	BlacklistID  uint      `gorm:"column:blacklist_id;index:idx_blacklist_id" json:"blacklist_id"`               // 黑名单记录ID
+	AlipayUserID string    `gorm:"column:alipay_user_id;size:64;index:idx_alipay_user_id" json:"alipay_user_id"` // 买家支付宝用户ID
+	DeviceCode   string    `gorm:"column:device_code;size:128" json:"device_code"`
+	IPAddress    string    `gorm:"column:ip_address;size:64" json:"ip_address"`
+	Action       string    `gorm:"column:action;size:16;not null" json:"action"`         // 变更动作（insert/increment/decrement/unblock/edit/import）
+	ActorType    string    `gorm:"column:actor_type;size:16;not null" json:"actor_type"` // 操作方类型（system/operator/import）
+	Actor        string    `gorm:"column:actor;size:128" json:"actor"`                   // 操作方（规则名、操作人ID、导入文件）
+	ComplaintNo  string    `gorm:"column:complaint_no;size:64" json:"complaint_no"`      // 来源投诉单号
+	Before       string    `gorm:"column:before_value;type:text" json:"before"`          // 变更前的值（JSON，新增时为空）
+	After        string    `gorm:"column:after_value;type:text" json:"after"`            // 变更后的值（JSON，解除时为空）
+	Reason       string    `gorm:"column:reason;size:255" json:"reason"`                 // 变更原因
+	PrevHash     string    `gorm:"column:prev_hash;size:64;not null" json:"prev_hash"`   // 上一条记录的Hash（第一条为空）
+	Hash         string    `gorm:"column:hash;size:64;not null" json:"hash"`             // 本条记录的Hash
+	CreatedAt    time.Time `gorm:"column:created_at" json:"created_at"`                  // 写入时间（精确到秒，参与Hash计算）
+}
+
+// TableName 指定表名
+func (BlacklistAudit) TableName() string {
+	return "alipay_blacklist_audit"
+}
+
+// ComputeHash 计算记录的Hash：SHA-256(上一条Hash和各字段的JSON数组)
+// 使用JSON数组编码，字段内容中的分隔符不会造成歧义；创建时间按秒级Unix时间参与计算，与数据库精度一致
+func (a *BlacklistAudit) ComputeHash() string {
+	payload, _ := json.Marshal([]string{
+		a.PrevHash,
+		strconv.FormatInt(a.Seq, 10),
+		strconv.FormatUint(uint64(a.BlacklistID), 10),
+		a.AlipayUserID,
+		a.DeviceCode,
+		a.IPAddress,
+		a.Action,
+		a.ActorType,
+		a.Actor,
+		a.ComplaintNo,
+		a.Before,
+		a.After,
+		a.Reason,
+		strconv.FormatInt(a.CreatedAt.Unix(), 10),
+	})
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyLink 校验记录与上一条记录的链接关系（prev 为nil表示本条应为第一条），返回问题描述（正常返回空字符串）
+func (a *BlacklistAudit) VerifyLink(prev *BlacklistAudit) string {
+	if a.Hash != a.ComputeHash() {
+		return "记录内容与Hash不一致（记录被修改）"
+	}
+	if prev == nil {
+		if a.Seq != 1 || a.PrevHash != "" {
+			return "链起点不是第一条记录（之前的记录被删除）"
+		}
+		return ""
+	}
+	if a.Seq != prev.Seq+1 {
+		return "序号不连续（记录被删除）"
+	}
+	if a.PrevHash != prev.Hash {
+		return "上一条Hash不匹配（上一条记录被修改或替换）"
+	}
+	return ""
+}
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+// buildAuditChain 构建n条链接好的审计记录
+func buildAuditChain(n int) []*BlacklistAudit {
+	base := time.Date(2025, 10, 1, 12, 0, 0, 0, time.Local)
+	chain := make([]*BlacklistAudit, 0, n)
+	prevHash := ""
+	for i := 1; i <= n; i++ {
+		audit := &BlacklistAudit{
+			ID:           uint(i),
+			Seq:          int64(i),
+			BlacklistID:  7,
+			AlipayUserID: "2088001",
+			Action:       BlacklistAuditIncrement,
+			ActorType:    BlacklistActorSystem,
+			Actor:        "blacklist_rule",
+			After:        `{"risk_count":1,"risk_level":"low"}`,
+			PrevHash:     prevHash,
+			CreatedAt:    base.Add(time.Duration(i) * time.Minute),
+		}
+		audit.Hash = audit.ComputeHash()
+		prevHash = audit.Hash
+		chain = append(chain, audit)
+	}
+	return chain
+}
+
+// verifyChain 返回第一处问题的序号（无问题返回0）
+func verifyChain(chain []*BlacklistAudit) int64 {
+	var prev *BlacklistAudit
+	for _, audit := range chain {
+		if audit.VerifyLink(prev) != "" {
+			return audit.Seq
+		}
+		prev = audit
+	}
+	return 0
+}
+
+func TestBlacklistAuditChain(t *testing.T) {
+	if seq := verifyChain(buildAuditChain(5)); seq != 0 {
+		t.Fatalf("完整的链在 seq=%d 校验失败", seq)
+	}
+
+	// 修改内容
+	chain := buildAuditChain(5)
+	chain[2].After = `{"risk_count":1,"risk_level":"critical"}`
+	if seq := verifyChain(chain); seq != 3 {
+		t.Errorf("修改内容应在 seq=3 发现，实际 %d", seq)
+	}
+
+	// 修改内容并重新计算Hash：下一条的 prev_hash 不匹配
+	chain = buildAuditChain(5)
+	chain[2].Reason = "篡改"
+	chain[2].Hash = chain[2].ComputeHash()
+	if seq := verifyChain(chain); seq != 4 {
+		t.Errorf("重算Hash应在 seq=4 发现，实际 %d", seq)
+	}
+
+	// 删除中间记录
+	chain = buildAuditChain(5)
+	chain = append(chain[:1], chain[2:]...)
+	if seq := verifyChain(chain); seq != 3 {
+		t.Errorf("删除记录应在 seq=3 发现，实际 %d", seq)
+	}
+
+	// 删除第一条记录
+	if seq := verifyChain(buildAuditChain(3)[1:]); seq != 2 {
+		t.Errorf("删除第一条记录应在 seq=2 发现，实际 %d", seq)
+	}
+}
+
+func TestBlacklistAuditHashIgnoresSubSecond(t *testing.T) {
+	audit := buildAuditChain(1)[0]
+	hash := audit.Hash
+	audit.CreatedAt = audit.CreatedAt.Add(500 * time.Millisecond).UTC()
+	if audit.ComputeHash() != hash {
+		t.Error("Hash应只取秒级时间且与时区无关")
+	}
+}
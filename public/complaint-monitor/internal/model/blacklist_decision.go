@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// BlacklistDecision 拉黑规则决策记录模型
+// 新投诉入库后按拉黑规则决定拉黑、人工审核或忽略，每次决策写入一条记录，便于追溯命中的规则
+type BlacklistDecision struct {
+	ID              uint      `gorm:"column:id;primaryKey" json:"id"`
+	SubjectID       int       `gorm:"column:subject_id;not null;index:idx_subject_id" json:"subject_id"`
+	AgentID         int       `gorm:"column:agent_id" json:"agent_id"`
+	AlipayTaskId    string    `gorm:"column:alipay_task_id;not null;size:64;index:idx_alipay_task_id" json:"alipay_task_id"` // 支付宝投诉单号（TaskId）
+	ComplainantID   string    `gorm:"column:complainant_id;size:64;index:idx_complainant_id" json:"complainant_id"`          // 投诉人支付宝用户ID
+	ComplaintStatus string    `gorm:"column:complaint_status;size:32" json:"complaint_status"`
+	ComplaintAmount float64   `gorm:"column:complaint_amount;type:decimal(15,2);default:0" json:"complaint_amount"` // 投诉涉及订单总金额
+	HistoryCount    int64     `gorm:"column:history_count;default:0" json:"history_count"`                          // 投诉人历史投诉次数（含本次）
+	Action          string    `gorm:"column:action;size:16;index:idx_action" json:"action"`                         // 决策动作（block/review/ignore）
+	RuleName        string    `gorm:"column:rule_name;size:64" json:"rule_name"`                                    // 命中的规则名称（为空表示使用默认动作）
+	MatchDetail     string    `gorm:"column:match_detail;size:255" json:"match_detail"`                             // 命中的条件
+	CreatedAt       time.Time `gorm:"column:created_at;index:idx_created_at" json:"created_at"`
+}
+
+// TableName 指定表名
+func (BlacklistDecision) TableName() string {
+	return "alipay_blacklist_decision"
+}
@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// 黑名单网段来源
+const (
+	IPRangeSourceManual = "manual" // 管理接口手动添加
+	IPRangeSourceAuto   = "auto"   // 同一网段内黑名单IP达到阈值后自动升级
+)
+
+// AlipayBlacklistIPRange 黑名单IP网段模型
+// 与 alipay_blacklist 的单个IP分开存储，支持IPv4/IPv6 CIDR，查询时按网段匹配
+type AlipayBlacklistIPRange struct {
+	ID        uint       `gorm:"column:id;primaryKey" json:"id"`
+	CIDR      string     `gorm:"column:cidr;not null;size:64;uniqueIndex:uk_cidr" json:"cidr"`       // 网段（主机位已清零，如 1.2.3.0/24）
+	Source    string     `gorm:"column:source;size:16;not null;default:'manual'" json:"source"`      // 来源（manual/auto）
+	IPCount   int        `gorm:"column:ip_count;default:0" json:"ip_count"`                          // 自动升级时网段内的黑名单IP数
+	RiskLevel string     `gorm:"column:risk_level;size:16;not null;default:'low'" json:"risk_level"` // 风险等级
+	Remark    string     `gorm:"column:remark;size:255" json:"remark"`                               // 备注信息
+	ExpireAt  *time.Time `gorm:"column:expire_at;index:idx_expire_at" json:"expire_at"`              // 过期时间（为空表示永久有效）
+	CreatedAt time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt time.Time  `gorm:"column:updated_at" json:"updated_at"`
+}
+
+// TableName 指定表名
+func (AlipayBlacklistIPRange) TableName() string {
+	return "alipay_blacklist_ip_range"
+}
+
+// IsExpired 是否已过期
+func (r *AlipayBlacklistIPRange) IsExpired(now time.Time) bool {
+	return r.ExpireAt != nil && !r.ExpireAt.After(now)
+}
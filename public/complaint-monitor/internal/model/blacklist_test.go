@@ -0,0 +1,29 @@
+package model
+
+import "testing"
+
+func TestBlacklistUniqueKey(t *testing.T) {
+	key := BlacklistUniqueKey("2088001", "dev-1", "1.2.3.4")
+	if len(key) != 64 {
+		t.Fatalf("唯一键应为64位十六进制，实际 %q", key)
+	}
+	if key != BlacklistUniqueKey("2088001", "dev-1", "1.2.3.4") {
+		t.Fatal("相同输入应得到相同的唯一键")
+	}
+
+	// 空字段参与计算，字段位置不同不应冲突
+	distinct := []string{
+		key,
+		BlacklistUniqueKey("2088001", "", ""),
+		BlacklistUniqueKey("2088001", "dev-1", ""),
+		BlacklistUniqueKey("2088001", "", "dev-1"),
+		BlacklistUniqueKey("2088001dev-1", "", ""),
+	}
+	seen := make(map[string]int)
+	for i, k := range distinct {
+		if j, ok := seen[k]; ok {
+			t.Fatalf("第%d个和第%d个唯一键冲突: %s", j, i, k)
+		}
+		seen[k] = i
+	}
+}
@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// 白名单匹配类型
+const (
+	AllowlistTypeUser   = "user"   // 支付宝用户ID
+	AllowlistTypeIP     = "ip"     // IP地址
+	AllowlistTypeDevice = "device" // 设备码
+)
+
+// BuyerAllowlist 买家白名单模型
+// 自有测试账号、VIP复购客户等，命中后投诉照常入库但不自动拉黑
+type BuyerAllowlist struct {
+	ID        uint       `gorm:"column:id;primaryKey" json:"id"`
+	MatchType string     `gorm:"column:match_type;size:16;not null;uniqueIndex:uk_match,priority:1" json:"match_type"` // 匹配类型（user/ip/device）
+	Value     string     `gorm:"column:value;size:128;not null;uniqueIndex:uk_match,priority:2" json:"value"`          // 支付宝用户ID、IP或设备码
+	Reason    string     `gorm:"column:reason;size:255" json:"reason"`                                                 // 加入白名单的原因
+	ExpireAt  *time.Time `gorm:"column:expire_at" json:"expire_at"`                                                    // 过期时间（为空表示永久有效）
+	CreatedBy int        `gorm:"column:created_by;default:0" json:"created_by"`                                        // 添加人ID
+	CreatedAt time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt time.Time  `gorm:"column:updated_at" json:"updated_at"`
+}
+
+// TableName 指定表名
+func (BuyerAllowlist) TableName() string {
+	return "alipay_buyer_allowlist"
+}
+
+// IsExpired 是否已过期
+func (a *BuyerAllowlist) IsExpired(now time.Time) bool {
+	return a.ExpireAt != nil && !a.ExpireAt.After(now)
+}
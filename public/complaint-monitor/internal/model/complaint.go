@@ -20,6 +20,14 @@ const (
 	ComplaintStatusDropOverdueProcessed = "DROP_OVERDUE_PROCESSED" // 超时处理完成用户撤诉
 )
 
+// ComplaintOpenStatuses 未结束的投诉状态（商家尚未处理完成且用户未撤诉）
+var ComplaintOpenStatuses = []string{
+	ComplaintStatusWaitProcess,
+	ComplaintStatusProcessing,
+	ComplaintStatusOverdue,
+	ComplaintStatusPartOverdue,
+}
+
 // Complaint 投诉主表模型
 type Complaint struct {
 	ID               uint       `gorm:"column:id;primaryKey" json:"id"`
@@ -39,6 +47,7 @@ type Complaint struct {
 	HandlerID        int        `gorm:"column:handler_id;index:idx_handler_id" json:"handler_id"`               // 处理人ID
 	GmtCreate        string     `gorm:"column:gmt_create;size:32" json:"gmt_create"`
 	GmtModified      string     `gorm:"column:gmt_modified;size:32" json:"gmt_modified"`
+	FenceToken       int64      `gorm:"column:fence_token;not null;default:0" json:"-"` // 最后一次写入时持有的fencing token（拒绝更旧的写入）
 	CreatedAt        time.Time  `gorm:"column:created_at" json:"created_at"`
 	UpdatedAt        time.Time  `gorm:"column:updated_at" json:"updated_at"`
 
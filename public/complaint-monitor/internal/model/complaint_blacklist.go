@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// ComplaintBlacklist 投诉拉黑时新增或累加的黑名单记录
+// 撤诉时只撤销该投诉自己新增或累加的记录，不影响其他投诉、导入或关联分析写入的同一买家的记录；撤销后删除对应的关联
+type ComplaintBlacklist struct {
+	ID           uint      `gorm:"column:id;primaryKey" json:"id"`
+	ComplaintID  uint      `gorm:"column:complaint_id;not null;uniqueIndex:uk_complaint_blacklist,priority:1" json:"complaint_id"` // 投诉主表ID
+	SubjectID    int       `gorm:"column:subject_id;not null" json:"subject_id"`
+	AlipayTaskId string    `gorm:"column:alipay_task_id;not null;size:64" json:"alipay_task_id"`                                                          // 支付宝投诉单号（TaskId）
+	BlacklistID  uint      `gorm:"column:blacklist_id;not null;uniqueIndex:uk_complaint_blacklist,priority:2;index:idx_blacklist_id" json:"blacklist_id"` // 黑名单记录ID
+	Action       string    `gorm:"column:action;size:16;not null" json:"action"`                                                                          // 拉黑动作（insert/increment，同 BlacklistAuditXxx）
+	CreatedAt    time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+// TableName 指定表名
+func (ComplaintBlacklist) TableName() string {
+	return "alipay_complaint_blacklist"
+}
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// ComplaintBuyer 投诉解析出的买家身份
+// 投诉入库时按 IdentityResolver 解析（订单 buyer_id → 交易查询 → 投诉人字段，已排除主体PID），一个投诉可能涉及多个买家；
+// 买家风险画像、近期投诉次数和撤诉时的未结束投诉统计都按此表汇总，不使用未经校验的 complainant_id
+type ComplaintBuyer struct {
+	ID             uint      `gorm:"column:id;primaryKey" json:"id"`
+	ComplaintID    uint      `gorm:"column:complaint_id;not null;uniqueIndex:uk_complaint_buyer,priority:1" json:"complaint_id"` // 投诉主表ID
+	SubjectID      int       `gorm:"column:subject_id;not null;index:idx_subject_id" json:"subject_id"`
+	AlipayTaskId   string    `gorm:"column:alipay_task_id;not null;size:64;index:idx_alipay_task_id" json:"alipay_task_id"`                         // 支付宝投诉单号（TaskId）
+	BuyerID        string    `gorm:"column:buyer_id;not null;size:64;uniqueIndex:uk_complaint_buyer,priority:2;index:idx_buyer_id" json:"buyer_id"` // 买家支付宝用户ID
+	IdentitySource string    `gorm:"column:identity_source;size:16;not null;default:''" json:"identity_source"`                                     // 身份来源（order/trade_query/complainant）
+	CreatedAt      time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+// TableName 指定表名
+func (ComplaintBuyer) TableName() string {
+	return "alipay_complaint_buyer"
+}
@@ -0,0 +1,54 @@
+package model
+
+import "time"
+
+// ComplaintRetry 投诉处理重试记录模型
+// 投诉处理失败（详情API错误、详情无订单、数据库错误等）时写入，按指数退避重试，超过最大次数后转入死信
+type ComplaintRetry struct {
+	ID               uint       `gorm:"column:id;primaryKey" json:"id"`
+	SubjectID        int        `gorm:"column:subject_id;not null;uniqueIndex:uniq_subject_task" json:"subject_id"`
+	AlipayTaskId     string     `gorm:"column:alipay_task_id;not null;size:64;uniqueIndex:uniq_subject_task" json:"alipay_task_id"` // 支付宝投诉单号（TaskId）
+	AlipayComplainId int64      `gorm:"column:alipay_complain_id;not null;default:0" json:"alipay_complain_id"`                     // 支付宝投诉主表ID（用于查询详情）
+	Status           string     `gorm:"column:status;size:16;not null;index:idx_status_next" json:"status"`                         // pending：等待重试，dead：死信，resolved：已恢复
+	Attempts         int        `gorm:"column:attempts;not null;default:0" json:"attempts"`                                         // 已失败次数
+	LastError        string     `gorm:"column:last_error;type:text" json:"last_error"`                                              // 最后一次失败原因
+	NextAttemptAt    time.Time  `gorm:"column:next_attempt_at;index:idx_status_next" json:"next_attempt_at"`                        // 下次重试时间
+	DeadAt           *time.Time `gorm:"column:dead_at" json:"dead_at"`                                                              // 转入死信的时间
+	CreatedAt        time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt        time.Time  `gorm:"column:updated_at" json:"updated_at"`
+}
+
+// TableName 指定表名
+func (ComplaintRetry) TableName() string {
+	return "alipay_complaint_retry"
+}
+
+// 重试状态常量
+const (
+	RetryStatusPending  = "pending"  // 等待重试
+	RetryStatusDead     = "dead"     // 超过最大重试次数（死信）
+	RetryStatusResolved = "resolved" // 重试成功
+)
+
+// RecordFailure 记录一次处理失败
+// 未超过最大次数时在 delay 后重试，达到最大次数时转入死信并返回true
+func (r *ComplaintRetry) RecordFailure(errMsg string, now time.Time, delay time.Duration, maxAttempts int) bool {
+	r.Attempts++
+	r.LastError = errMsg
+	r.UpdatedAt = now
+
+	if maxAttempts > 0 && r.Attempts >= maxAttempts {
+		r.Status = RetryStatusDead
+		r.DeadAt = &now
+		return true
+	}
+
+	r.Status = RetryStatusPending
+	r.NextAttemptAt = now.Add(delay)
+	return false
+}
+
+// IsDead 是否已转入死信
+func (r *ComplaintRetry) IsDead() bool {
+	return r.Status == RetryStatusDead
+}
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComplaintRetryRecordFailure(t *testing.T) {
+	now := time.Date(2025, 10, 29, 12, 0, 0, 0, time.Local)
+	retry := &ComplaintRetry{}
+
+	if dead := retry.RecordFailure("详情API错误", now, time.Minute, 3); dead {
+		t.Fatal("第1次失败不应转入死信")
+	}
+	if retry.Status != RetryStatusPending || retry.Attempts != 1 || !retry.NextAttemptAt.Equal(now.Add(time.Minute)) {
+		t.Errorf("第1次失败后状态错误: %+v", retry)
+	}
+
+	retry.RecordFailure("详情API错误", now, 2*time.Minute, 3)
+	if dead := retry.RecordFailure("数据库错误", now, 4*time.Minute, 3); !dead {
+		t.Fatal("达到最大次数应转入死信")
+	}
+	if !retry.IsDead() || retry.DeadAt == nil || retry.LastError != "数据库错误" {
+		t.Errorf("转入死信后状态错误: %+v", retry)
+	}
+}
+
+func TestComplaintRetryUnlimitedAttempts(t *testing.T) {
+	retry := &ComplaintRetry{}
+	for i := 0; i < 100; i++ {
+		if retry.RecordFailure("err", time.Now(), time.Second, 0) {
+			t.Fatal("最大次数为0时不应转入死信")
+		}
+	}
+}
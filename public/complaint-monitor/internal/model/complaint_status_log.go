@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// ComplaintStatusLog 投诉状态变更记录模型
+// 每次投诉状态或处理时间发生变化时写入一条记录，用于追溯投诉何时超时、何时撤诉
+type ComplaintStatusLog struct {
+	ID              uint      `gorm:"column:id;primaryKey" json:"id"`
+	ComplaintID     uint      `gorm:"column:complaint_id;not null;index:idx_complaint_id" json:"complaint_id"` // 投诉主表ID
+	SubjectID       int       `gorm:"column:subject_id;not null;index:idx_subject_id" json:"subject_id"`
+	AlipayTaskId    string    `gorm:"column:alipay_task_id;not null;size:64;index:idx_alipay_task_id" json:"alipay_task_id"` // 支付宝投诉单号（TaskId）
+	FromStatus      string    `gorm:"column:from_status;size:32" json:"from_status"`                                         // 变更前状态
+	ToStatus        string    `gorm:"column:to_status;size:32;index:idx_to_status" json:"to_status"`                         // 变更后状态
+	FromGmtModified string    `gorm:"column:from_gmt_modified;size:32" json:"from_gmt_modified"`                             // 变更前处理时间
+	ToGmtModified   string    `gorm:"column:to_gmt_modified;size:32" json:"to_gmt_modified"`                                 // 变更后处理时间
+	RefundAmount    float64   `gorm:"column:refund_amount;type:decimal(10,2);default:0" json:"refund_amount"`                // 变更后已退款金额
+	Source          string    `gorm:"column:source;size:32" json:"source"`                                                   // 变更来源（poll：轮询，notify：消息通知，manual：商家处理）
+	CreatedAt       time.Time `gorm:"column:created_at;index:idx_created_at" json:"created_at"`
+}
+
+// TableName 指定表名
+func (ComplaintStatusLog) TableName() string {
+	return "alipay_complaint_status_log"
+}
+
+// 状态变更来源常量
+const (
+	StatusChangeSourcePoll   = "poll"   // 轮询发现
+	StatusChangeSourceNotify = "notify" // 支付宝消息通知
+	StatusChangeSourceManual = "manual" // 商家手动处理
+)
@@ -14,6 +14,7 @@ type Order struct {
 	PayTime         *time.Time `gorm:"column:pay_time;index:idx_pay_time" json:"pay_time"`
 	FirstOpenIP     string     `gorm:"column:first_open_ip;size:45" json:"first_open_ip"`
 	PayIP           string     `gorm:"column:pay_ip;size:45" json:"pay_ip"`
+	DeviceCode      string     `gorm:"-" json:"device_code"` // 买家设备码（来自 order_log，由 OrderRepository 查询时填充）
 	CreatedAt       time.Time  `gorm:"column:created_at" json:"created_at"`
 	UpdatedAt       time.Time  `gorm:"column:updated_at" json:"updated_at"`
 }
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// deviceCodeMaxLen 黑名单设备码字段长度（alipay_blacklist.device_code varchar(128)）
+const deviceCodeMaxLen = 128
+
+// OrderLogBuyerTypes 买家端访问产生的订单日志类型（商户下单、回调等服务端请求的UA不是买家设备）
+var OrderLogBuyerTypes = []string{"OAuth", "访问", "支付"}
+
+// OrderLog 订单日志模型（对应PHP OrderLogService 写入的 order_log 表）
+// 收银台在买家访问、授权和支付时记录IP和UA，黑名单检查节点的日志内容中记录设备码（device_code）
+type OrderLog struct {
+	ID              uint      `gorm:"column:id;primaryKey" json:"id"`
+	OrderID         uint      `gorm:"column:order_id" json:"order_id"`
+	PlatformOrderNo string    `gorm:"column:platform_order_no" json:"platform_order_no"`
+	MerchantOrderNo string    `gorm:"column:merchant_order_no" json:"merchant_order_no"`
+	LogType         string    `gorm:"column:log_type" json:"log_type"`
+	Node            string    `gorm:"column:node" json:"node"`
+	Content         string    `gorm:"column:content" json:"content"` // 日志内容JSON
+	IP              string    `gorm:"column:ip" json:"ip"`
+	UserAgent       string    `gorm:"column:user_agent" json:"user_agent"`
+	CreatedAt       time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+// TableName 指定表名
+func (OrderLog) TableName() string {
+	return "order_log"
+}
+
+// DeviceCode 获取日志记录的设备码
+// 取日志内容中的 device_code（返回值已规范化）；没有时返回空，请求UA为同型号设备共用，不作为设备码
+func (l *OrderLog) DeviceCode() string {
+	if l.Content == "" {
+		return ""
+	}
+	var content struct {
+		DeviceCode string `json:"device_code"`
+	}
+	if err := json.Unmarshal([]byte(l.Content), &content); err != nil {
+		return ""
+	}
+	return NormalizeDeviceCode(content.DeviceCode)
+}
+
+// NormalizeDeviceCode 规范化设备码
+// 去除首尾空白；超过黑名单字段长度时使用MD5（32位十六进制），保证同一设备得到相同的设备码
+func NormalizeDeviceCode(raw string) string {
+	code := strings.TrimSpace(raw)
+	if len(code) <= deviceCodeMaxLen {
+		return code
+	}
+	sum := md5.Sum([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOrderLogDeviceCode(t *testing.T) {
+	ua := "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AlipayClient/10.5.0"
+
+	tests := []struct {
+		name     string
+		log      OrderLog
+		expected string
+	}{
+		{"日志内容中的设备码", OrderLog{Content: `{"device_code":"fp-123","ip_address":"1.2.3.4"}`, UserAgent: ua}, "fp-123"},
+		{"内容无设备码时不使用UA", OrderLog{Content: `{"ip_address":"1.2.3.4"}`, UserAgent: ua}, ""},
+		{"内容不是JSON时不使用UA", OrderLog{Content: "invalid", UserAgent: " " + ua + " "}, ""},
+		{"均为空", OrderLog{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.log.DeviceCode(); got != tt.expected {
+				t.Errorf("DeviceCode() = %q, 期望 %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNormalizeDeviceCode(t *testing.T) {
+	long := strings.Repeat("a", deviceCodeMaxLen+1)
+	got := NormalizeDeviceCode(long)
+	if len(got) != 32 {
+		t.Fatalf("超长设备码应转换为32位MD5，实际长度 %d", len(got))
+	}
+	if NormalizeDeviceCode(long) != got {
+		t.Error("同一设备码规范化结果应一致")
+	}
+
+	exact := strings.Repeat("b", deviceCodeMaxLen)
+	if NormalizeDeviceCode(exact) != exact {
+		t.Error("未超长的设备码应保持不变")
+	}
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// SubjectSyncState 主体投诉同步水位模型
+// Redis中的水位丢失时，以此表中的记录作为兜底
+type SubjectSyncState struct {
+	ID               uint       `gorm:"column:id;primaryKey" json:"id"`
+	SubjectID        int        `gorm:"column:subject_id;not null;uniqueIndex:uniq_subject_id" json:"subject_id"`
+	LastSyncTime     *time.Time `gorm:"column:last_sync_time" json:"last_sync_time"`           // 最后一次成功同步的时间（水位）
+	LastFullScanTime *time.Time `gorm:"column:last_full_scan_time" json:"last_full_scan_time"` // 最后一次全量扫描的时间
+	CreatedAt        time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt        time.Time  `gorm:"column:updated_at" json:"updated_at"`
+}
+
+// TableName 指定表名
+func (SubjectSyncState) TableName() string {
+	return "alipay_complaint_sync_state"
+}
@@ -0,0 +1,187 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"complaint-monitor/internal/config"
+	"complaint-monitor/pkg/metrics"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+const (
+	bucketKeyPrefix       = "ratelimit:bucket:"  // 令牌桶键前缀
+	backoffKeyPrefix      = "ratelimit:backoff:" // 退避键前缀（存在即处于退避期）
+	backoffLevelKeyPrefix = "ratelimit:level:"   // 连续限流次数键前缀
+)
+
+// tokenBucketScript 令牌桶脚本（原子地补充令牌并尝试取出一个）
+// KEYS[1]: 令牌桶键  KEYS[2]: 退避键
+// ARGV[1]: 每秒令牌数  ARGV[2]: 桶容量
+// 返回需要等待的毫秒数（0表示已取得令牌）
+// 使用Redis服务器时间，避免多实例间时钟偏差
+var tokenBucketScript = redis.NewScript(`
+if redis.replicate_commands then redis.replicate_commands() end
+
+local backoff = redis.call('PTTL', KEYS[2])
+if backoff > 0 then
+  return backoff
+end
+
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local data = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(data[1])
+local ts = tonumber(data[2])
+if tokens == nil or ts == nil then
+  tokens = burst
+  ts = now
+end
+
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000)
+
+local wait = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+else
+  wait = math.ceil((1 - tokens) * 1000 / rate)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return wait
+`)
+
+// Limiter 支付宝API限流器
+// 按 AppID + 接口名 维护令牌桶，多实例通过Redis共享；支付宝返回限流错误时指数退避
+type Limiter struct {
+	redis       *redis.Client
+	rate        float64
+	burst       int
+	backoffBase time.Duration
+	backoffMax  time.Duration
+	logger      *zap.Logger
+}
+
+// NewLimiter 创建限流器
+func NewLimiter(redisClient *redis.Client, cfg config.RateLimitConfig, logger *zap.Logger) *Limiter {
+	return &Limiter{
+		redis:       redisClient,
+		rate:        cfg.Rate,
+		burst:       cfg.Burst,
+		backoffBase: cfg.GetBackoffBase(),
+		backoffMax:  cfg.GetBackoffMax(),
+		logger:      logger,
+	}
+}
+
+// Wait 等待获取令牌（处于退避期时等待退避结束）
+// Redis不可用时放行，避免限流器故障导致投诉拉取停止
+func (l *Limiter) Wait(ctx context.Context, appID, api string) error {
+	startTime := time.Now()
+	waited := false // 是否因限流或退避实际等待过
+	keys := []string{bucketKey(appID, api), backoffKey(appID, api)}
+
+	for {
+		waitMs, err := tokenBucketScript.Run(ctx, l.redis, keys, l.rate, l.burst).Int64()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			metrics.RecordRateLimitError(appID, api)
+			l.logger.Warn("限流器访问Redis失败，本次放行",
+				zap.String("app_id", appID),
+				zap.String("api", api),
+				zap.Error(err))
+			return nil
+		}
+
+		if waitMs <= 0 {
+			metrics.RecordRateLimitWait(appID, api, waited, time.Since(startTime).Seconds())
+			return nil
+		}
+
+		waited = true
+		timer := time.NewTimer(time.Duration(waitMs) * time.Millisecond)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("等待限流令牌超时: %w", ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// ReportThrottled 上报支付宝限流错误，进入退避期
+// 连续触发时退避时间指数增长（backoff_base * 2^(n-1)，不超过 backoff_max）
+// 退避期结束后 2 * backoff_max 内未再触发，则重置连续次数
+func (l *Limiter) ReportThrottled(ctx context.Context, appID, api string) {
+	metrics.RecordRateLimitThrottled(appID, api)
+
+	levelKey := backoffLevelKey(appID, api)
+	level, err := l.redis.Incr(ctx, levelKey).Result()
+	if err != nil {
+		metrics.RecordRateLimitError(appID, api)
+		l.logger.Warn("记录限流次数失败", zap.String("app_id", appID), zap.String("api", api), zap.Error(err))
+		level = 1
+	} else {
+		l.redis.Expire(ctx, levelKey, 2*l.backoffMax)
+	}
+
+	backoff := BackoffDuration(int(level), l.backoffBase, l.backoffMax)
+	if err := l.redis.Set(ctx, backoffKey(appID, api), level, backoff).Err(); err != nil {
+		metrics.RecordRateLimitError(appID, api)
+		l.logger.Warn("写入退避状态失败", zap.String("app_id", appID), zap.String("api", api), zap.Error(err))
+	}
+
+	metrics.UpdateRateLimitBackoff(appID, api, backoff.Seconds())
+	l.logger.Warn("支付宝返回限流错误，进入退避",
+		zap.String("app_id", appID),
+		zap.String("api", api),
+		zap.Int64("level", level),
+		zap.Duration("backoff", backoff))
+}
+
+// ReportSuccess 上报调用成功（清除退避指标）
+func (l *Limiter) ReportSuccess(appID, api string) {
+	metrics.UpdateRateLimitBackoff(appID, api, 0)
+}
+
+// BackoffDuration 计算第 level 次连续限流的退避时间
+func BackoffDuration(level int, base, max time.Duration) time.Duration {
+	if level < 1 {
+		level = 1
+	}
+	backoff := base
+	for i := 1; i < level; i++ {
+		backoff *= 2
+		if backoff >= max {
+			return max
+		}
+	}
+	if backoff > max {
+		return max
+	}
+	return backoff
+}
+
+// bucketKey 令牌桶键
+func bucketKey(appID, api string) string {
+	return bucketKeyPrefix + appID + ":" + api
+}
+
+// backoffKey 退避键
+func backoffKey(appID, api string) string {
+	return backoffKeyPrefix + appID + ":" + api
+}
+
+// backoffLevelKey 连续限流次数键
+func backoffLevelKey(appID, api string) string {
+	return backoffLevelKeyPrefix + appID + ":" + api
+}
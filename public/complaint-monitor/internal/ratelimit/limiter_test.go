@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"complaint-monitor/internal/config"
+	"complaint-monitor/internal/testutil"
+	"complaint-monitor/pkg/metrics"
+
+	dto "github.com/prometheus/client_model/go"
+	"go.uber.org/zap"
+)
+
+func TestBackoffDuration(t *testing.T) {
+	base := 2 * time.Second
+	max := 60 * time.Second
+
+	tests := []struct {
+		level int
+		want  time.Duration
+	}{
+		{0, 2 * time.Second},
+		{1, 2 * time.Second},
+		{2, 4 * time.Second},
+		{3, 8 * time.Second},
+		{5, 32 * time.Second},
+		{6, 60 * time.Second},
+		{100, 60 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := BackoffDuration(tt.level, base, max); got != tt.want {
+			t.Errorf("BackoffDuration(%d) = %v, 期望 %v", tt.level, got, tt.want)
+		}
+	}
+}
+
+// testAPI 限流测试使用的接口名
+const testAPI = "alipay.security.risk.complaint.info.batchquery"
+
+// newTestLimiter 创建连接测试Redis的限流器，返回本次测试独占的AppID
+func newTestLimiter(t *testing.T, rate float64, burst int) (*Limiter, string) {
+	client := testutil.NewTestRedis(t)
+	appID := fmt.Sprintf("test-%d", time.Now().UnixNano())
+	t.Cleanup(func() {
+		client.Del(context.Background(),
+			bucketKey(appID, testAPI), backoffKey(appID, testAPI), backoffLevelKey(appID, testAPI))
+	})
+
+	limiter := NewLimiter(client, config.RateLimitConfig{
+		Rate:        rate,
+		Burst:       burst,
+		BackoffBase: 1,
+		BackoffMax:  4,
+	}, zap.NewNop())
+	return limiter, appID
+}
+
+func TestWaitCountsOnlyActualWaits(t *testing.T) {
+	limiter, appID := newTestLimiter(t, 5, 1)
+	ctx := context.Background()
+	waits := func() float64 {
+		var m dto.Metric
+		if err := metrics.RateLimitWaitTotal.WithLabelValues(appID, testAPI).Write(&m); err != nil {
+			t.Fatalf("读取等待次数指标失败: %v", err)
+		}
+		return m.GetCounter().GetValue()
+	}
+
+	// 桶内有令牌：立即取得，不计入等待次数
+	if err := limiter.Wait(ctx, appID, testAPI); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if got := waits(); got != 0 {
+		t.Fatalf("取得令牌未等待，等待次数 = %v, 期望 0", got)
+	}
+
+	// 令牌已用完：每秒5个，需等待约200ms
+	start := time.Now()
+	if err := limiter.Wait(ctx, appID, testAPI); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("令牌用完后应等待补充，实际耗时 %v", elapsed)
+	}
+	if got := waits(); got != 1 {
+		t.Errorf("等待次数 = %v, 期望 1", got)
+	}
+}
+
+func TestReportThrottledBacksOff(t *testing.T) {
+	limiter, appID := newTestLimiter(t, 100, 10)
+	ctx := context.Background()
+
+	// 第一次限流退避 backoff_base（1秒）：退避期内等待直到超时
+	limiter.ReportThrottled(ctx, appID, testAPI)
+	waitCtx, cancel := context.WithTimeout(ctx, 300*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(waitCtx, appID, testAPI); err == nil {
+		t.Fatal("退避期内 Wait() 应等待至超时")
+	}
+
+	// 连续限流时退避时间翻倍
+	limiter.ReportThrottled(ctx, appID, testAPI)
+	ttl, err := limiter.redis.PTTL(ctx, backoffKey(appID, testAPI)).Result()
+	if err != nil {
+		t.Fatalf("读取退避键失败: %v", err)
+	}
+	if ttl <= time.Second || ttl > 2*time.Second {
+		t.Errorf("第二次限流的退避时间 = %v, 期望 (1s, 2s]", ttl)
+	}
+}
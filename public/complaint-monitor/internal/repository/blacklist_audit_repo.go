@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"fmt"
+
+	"complaint-monitor/internal/model"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BlacklistAuditRepository 黑名单变更审计仓库（只追加）
+type BlacklistAuditRepository struct {
+	*BaseRepository
+}
+
+// NewBlacklistAuditRepository 创建黑名单变更审计仓库
+func NewBlacklistAuditRepository(db *gorm.DB, logger *zap.Logger) *BlacklistAuditRepository {
+	return &BlacklistAuditRepository{
+		BaseRepository: NewBaseRepository(db, logger),
+	}
+}
+
+// FindLast 查询链上最后一条记录（不存在返回nil）
+func (r *BlacklistAuditRepository) FindLast() (*model.BlacklistAudit, error) {
+	var audit model.BlacklistAudit
+	err := r.db.Order("seq DESC").First(&audit).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil // 不存在返回nil
+		}
+		return nil, fmt.Errorf("查询最后一条审计记录失败: %w", err)
+	}
+	return &audit, nil
+}
+
+// Append 追加审计记录
+// seq 已被其他实例占用时不写入并返回false（由调用方重新读取链尾后重试）
+func (r *BlacklistAuditRepository) Append(audit *model.BlacklistAudit) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(audit)
+	if result.Error != nil {
+		return false, fmt.Errorf("写入审计记录失败: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// FindByAlipayUserID 查询买家的全部审计记录（按链序号正序）
+func (r *BlacklistAuditRepository) FindByAlipayUserID(alipayUserID string) ([]*model.BlacklistAudit, error) {
+	var audits []*model.BlacklistAudit
+	err := r.db.Where("alipay_user_id = ?", alipayUserID).Order("seq ASC").Find(&audits).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询买家审计记录失败: %w", err)
+	}
+	return audits, nil
+}
+
+// FindBatchAfterSeq 按链序号顺序分批查询审计记录（用于校验哈希链）
+func (r *BlacklistAuditRepository) FindBatchAfterSeq(afterSeq int64, limit int) ([]*model.BlacklistAudit, error) {
+	var audits []*model.BlacklistAudit
+	err := r.db.Where("seq > ?", afterSeq).Order("seq ASC").Limit(limit).Find(&audits).Error
+	if err != nil {
+		return nil, fmt.Errorf("分批查询审计记录失败: %w", err)
+	}
+	return audits, nil
+}
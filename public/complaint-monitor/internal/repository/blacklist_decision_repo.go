@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"fmt"
+
+	"complaint-monitor/internal/model"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// BlacklistDecisionRepository 拉黑规则决策记录仓库
+type BlacklistDecisionRepository struct {
+	*BaseRepository
+}
+
+// NewBlacklistDecisionRepository 创建拉黑规则决策记录仓库
+func NewBlacklistDecisionRepository(db *gorm.DB, logger *zap.Logger) *BlacklistDecisionRepository {
+	return &BlacklistDecisionRepository{
+		BaseRepository: NewBaseRepository(db, logger),
+	}
+}
+
+// Create 创建决策记录
+func (r *BlacklistDecisionRepository) Create(decision *model.BlacklistDecision) error {
+	if err := r.db.Create(decision).Error; err != nil {
+		return fmt.Errorf("创建拉黑决策记录失败: %w", err)
+	}
+	return nil
+}
+
+// FindLatestByTask 查找投诉最近一次的决策记录（不存在返回nil）
+func (r *BlacklistDecisionRepository) FindLatestByTask(subjectID int, alipayTaskId string) (*model.BlacklistDecision, error) {
+	var decision model.BlacklistDecision
+	err := r.db.Where("subject_id = ? AND alipay_task_id = ?", subjectID, alipayTaskId).
+		Order("id DESC").
+		First(&decision).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil // 不存在返回nil
+		}
+		return nil, fmt.Errorf("查询拉黑决策记录失败: %w", err)
+	}
+	return &decision, nil
+}
+
+// FindByAction 分页查询指定动作的决策记录（subjectID 为0时查询所有主体，按创建时间倒序）
+func (r *BlacklistDecisionRepository) FindByAction(subjectID int, action string, offset, limit int) ([]*model.BlacklistDecision, int64, error) {
+	query := r.db.Model(&model.BlacklistDecision{}).Where("action = ?", action)
+	if subjectID > 0 {
+		query = query.Where("subject_id = ?", subjectID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计拉黑决策记录失败: %w", err)
+	}
+
+	var decisions []*model.BlacklistDecision
+	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&decisions).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询拉黑决策记录失败: %w", err)
+	}
+	return decisions, total, nil
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"complaint-monitor/internal/model"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// BlacklistIPRangeRepository 黑名单IP网段仓库
+type BlacklistIPRangeRepository struct {
+	*BaseRepository
+}
+
+// NewBlacklistIPRangeRepository 创建黑名单IP网段仓库
+func NewBlacklistIPRangeRepository(db *gorm.DB, logger *zap.Logger) *BlacklistIPRangeRepository {
+	return &BlacklistIPRangeRepository{
+		BaseRepository: NewBaseRepository(db, logger),
+	}
+}
+
+// Create 创建网段记录
+func (r *BlacklistIPRangeRepository) Create(ipRange *model.AlipayBlacklistIPRange) error {
+	if err := r.db.Create(ipRange).Error; err != nil {
+		return fmt.Errorf("创建黑名单网段失败: %w", err)
+	}
+	return nil
+}
+
+// FindByID 根据ID查询网段（不存在返回nil）
+func (r *BlacklistIPRangeRepository) FindByID(id uint) (*model.AlipayBlacklistIPRange, error) {
+	var ipRange model.AlipayBlacklistIPRange
+	err := r.db.Where("id = ?", id).First(&ipRange).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil // 不存在返回nil
+		}
+		return nil, fmt.Errorf("查询黑名单网段失败: %w", err)
+	}
+	return &ipRange, nil
+}
+
+// FindByCIDR 根据网段查询（不存在返回nil）
+func (r *BlacklistIPRangeRepository) FindByCIDR(cidr string) (*model.AlipayBlacklistIPRange, error) {
+	var ipRange model.AlipayBlacklistIPRange
+	err := r.db.Where("cidr = ?", cidr).First(&ipRange).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil // 不存在返回nil
+		}
+		return nil, fmt.Errorf("查询黑名单网段失败: %w", err)
+	}
+	return &ipRange, nil
+}
+
+// FindAll 查询所有网段（按ID排序）
+func (r *BlacklistIPRangeRepository) FindAll() ([]*model.AlipayBlacklistIPRange, error) {
+	var ranges []*model.AlipayBlacklistIPRange
+	if err := r.db.Order("id ASC").Find(&ranges).Error; err != nil {
+		return nil, fmt.Errorf("查询黑名单网段失败: %w", err)
+	}
+	return ranges, nil
+}
+
+// FindExpired 查找已过期的网段
+func (r *BlacklistIPRangeRepository) FindExpired(now time.Time, limit int) ([]*model.AlipayBlacklistIPRange, error) {
+	var ranges []*model.AlipayBlacklistIPRange
+	err := r.db.Where("expire_at IS NOT NULL AND expire_at <= ?", now).
+		Order("expire_at ASC").
+		Limit(limit).
+		Find(&ranges).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询过期黑名单网段失败: %w", err)
+	}
+	return ranges, nil
+}
+
+// DeleteExpired 删除已过期的网段（多实例并发清理时只有一个实例删除成功，返回是否删除）
+func (r *BlacklistIPRangeRepository) DeleteExpired(id uint, now time.Time) (bool, error) {
+	result := r.db.Where("id = ? AND expire_at IS NOT NULL AND expire_at <= ?", id, now).
+		Delete(&model.AlipayBlacklistIPRange{})
+	if result.Error != nil {
+		return false, fmt.Errorf("删除过期黑名单网段失败: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Delete 删除网段，返回是否删除
+func (r *BlacklistIPRangeRepository) Delete(id uint) (bool, error) {
+	result := r.db.Where("id = ?", id).Delete(&model.AlipayBlacklistIPRange{})
+	if result.Error != nil {
+		return false, fmt.Errorf("删除黑名单网段失败: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
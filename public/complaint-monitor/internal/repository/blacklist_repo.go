@@ -2,6 +2,7 @@ package repository
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"complaint-monitor/internal/model"
@@ -23,7 +24,7 @@ func NewBlacklistRepository(db *gorm.DB, logger *zap.Logger) *BlacklistRepositor
 	}
 }
 
-// Create 创建黑名单记录
+// Create 创建黑名单记录（唯一键已存在时返回错误）
 func (r *BlacklistRepository) Create(blacklist *model.AlipayBlacklist) error {
 	err := r.db.Create(blacklist).Error
 	if err != nil {
@@ -32,6 +33,19 @@ func (r *BlacklistRepository) Create(blacklist *model.AlipayBlacklist) error {
 	return nil
 }
 
+// FindByID 根据ID查找（不存在返回nil）
+func (r *BlacklistRepository) FindByID(id uint) (*model.AlipayBlacklist, error) {
+	var blacklist model.AlipayBlacklist
+	err := r.db.Where("id = ?", id).First(&blacklist).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil // 不存在返回nil
+		}
+		return nil, fmt.Errorf("查询黑名单失败: %w", err)
+	}
+	return &blacklist, nil
+}
+
 // FindByAlipayUserID 根据支付宝用户ID查找
 // 注意：现有表结构使用 (alipay_user_id, device_code, ip_address) 作为唯一键
 func (r *BlacklistRepository) FindByAlipayUserID(alipayUserID string) (*model.AlipayBlacklist, error) {
@@ -116,9 +130,12 @@ func (r *BlacklistRepository) ExistsByUniqueKey(alipayUserID, deviceCode, ipAddr
 	return count > 0, nil
 }
 
-// Upsert 插入或更新（使用ON DUPLICATE KEY UPDATE）
-// 注意：唯一键是 (alipay_user_id, device_code, ip_address)
-func (r *BlacklistRepository) Upsert(blacklist *model.AlipayBlacklist) error {
+// Upsert 批量插入或更新（使用ON DUPLICATE KEY UPDATE）
+// 按主键或唯一键（unique_key）冲突时更新；需要合并已有记录的取值时由调用方先按 FindByUniqueKey 查询并设置ID
+func (r *BlacklistRepository) Upsert(blacklists ...*model.AlipayBlacklist) error {
+	if len(blacklists) == 0 {
+		return nil
+	}
 	// 使用Clauses实现ON DUPLICATE KEY UPDATE
 	err := r.db.Clauses(clause.OnConflict{
 		Columns: []clause.Column{
@@ -130,8 +147,11 @@ func (r *BlacklistRepository) Upsert(blacklist *model.AlipayBlacklist) error {
 			"risk_count",
 			"last_risk_time",
 			"remark",
+			"risk_level",
+			"expire_at",
+			"updated_at",
 		}),
-	}).Create(blacklist).Error
+	}).Create(&blacklists).Error
 
 	if err != nil {
 		return fmt.Errorf("插入或更新黑名单失败: %w", err)
@@ -148,36 +168,229 @@ func (r *BlacklistRepository) Update(blacklist *model.AlipayBlacklist) error {
 	return nil
 }
 
-// IncrementRiskCount 增加风险触发次数
-// 注意：device_code 和 ip_address 可能为 NULL（空字符串会被转换为 NULL）
-func (r *BlacklistRepository) IncrementRiskCount(alipayUserID, deviceCode, ipAddress string) error {
-	query := r.db.Model(&model.AlipayBlacklist{}).
-		Where("alipay_user_id = ?", alipayUserID)
+// UpdateManual 手动修改风险等级、过期时间（nil表示永久有效）和备注
+// 只更新这三个字段，不影响并发写入的风险计数
+func (r *BlacklistRepository) UpdateManual(id uint, riskLevel string, expireAt *time.Time, remark string) error {
+	err := r.db.Model(&model.AlipayBlacklist{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"risk_level": riskLevel,
+		"expire_at":  expireAt,
+		"remark":     remark,
+		"updated_at": time.Now(),
+	}).Error
+	if err != nil {
+		return fmt.Errorf("修改黑名单失败: %w", err)
+	}
+	return nil
+}
 
-	// 处理 device_code（可能为 NULL）
-	if deviceCode == "" {
-		query = query.Where("device_code IS NULL")
-	} else {
-		query = query.Where("device_code = ?", deviceCode)
+// blacklistRiskLevels 风险等级从低到高（与 service.RiskLevel 一致，用于SQL中比较等级）
+const blacklistRiskLevels = "'low','medium','high','critical'"
+
+// UpsertRisk 按唯一键（unique_key，数据库按买家ID、设备码和IP生成）原子地新增或累加黑名单，返回是否新增
+// 并发实例处理同一买家时只有一个实例新增成功，其余累加风险计数；已存在时：
+// 风险计数加1、更新最后触发时间，风险等级取原等级和 blacklist.RiskLevel 中较高者，过期时间取 expireByLevel 中合并后等级对应的值（nil表示永久有效）；
+// 身份来源保留新增时的值（原记录为空时补充）
+// fenceToken 为写入方持有的买家黑名单锁的fencing token，不为0时只更新fencing token不比其新的记录，否则返回 ErrStaleFenceToken
+func (r *BlacklistRepository) UpsertRisk(blacklist *model.AlipayBlacklist, expireByLevel map[string]*time.Time, fenceToken int64) (bool, error) {
+	now := time.Now()
+
+	args := []interface{}{
+		blacklist.AlipayUserID, blacklist.DeviceCode, blacklist.IPAddress,
+		blacklist.RiskCount, blacklist.LastRiskTime, blacklist.Remark, blacklist.RiskLevel,
+		blacklist.ExpireAt, blacklist.IdentitySource, fenceToken, now, now,
 	}
 
-	// 处理 ip_address（可能为 NULL）
-	if ipAddress == "" {
-		query = query.Where("ip_address IS NULL")
-	} else {
-		query = query.Where("ip_address = ?", ipAddress)
+	// guarded 包装更新表达式：fenceToken 不为0时只在记录的fencing token不比其新时更新，否则保持原值
+	guarded := func(column, expr string, exprArgs ...interface{}) string {
+		if fenceToken > 0 {
+			args = append(args, fenceToken)
+			args = append(args, exprArgs...)
+			return fmt.Sprintf("%s = IF(fence_token <= ?, %s, %s)", column, expr, column)
+		}
+		args = append(args, exprArgs...)
+		return fmt.Sprintf("%s = %s", column, expr)
 	}
 
-	err := query.Updates(map[string]interface{}{
-		"risk_count":     gorm.Expr("risk_count + 1"),
-		"last_risk_time": time.Now(),
-	}).Error
+	// ON DUPLICATE KEY UPDATE 按从左到右的顺序赋值：expire_at 使用已合并的 risk_level，fence_token 最后更新
+	assignments := []string{
+		guarded("risk_count", "risk_count + 1"),
+		guarded("last_risk_time", "VALUES(last_risk_time)"),
+		guarded("risk_level", "ELT(GREATEST(FIELD(risk_level, "+blacklistRiskLevels+"), FIELD(VALUES(risk_level), "+blacklistRiskLevels+")), "+blacklistRiskLevels+")"),
+		guarded("expire_at", "CASE risk_level WHEN 'low' THEN ? WHEN 'medium' THEN ? WHEN 'high' THEN ? WHEN 'critical' THEN ? ELSE expire_at END",
+			expireByLevel["low"], expireByLevel["medium"], expireByLevel["high"], expireByLevel["critical"]),
+		guarded("identity_source", "IF(identity_source = '', VALUES(identity_source), identity_source)"),
+		guarded("updated_at", "VALUES(updated_at)"),
+	}
+	if fenceToken > 0 {
+		assignments = append(assignments, guarded("fence_token", "VALUES(fence_token)"))
+	}
+
+	sql := "INSERT INTO alipay_blacklist " +
+		"(alipay_user_id, device_code, ip_address, risk_count, last_risk_time, remark, risk_level, expire_at, identity_source, fence_token, created_at, updated_at) " +
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) " +
+		"ON DUPLICATE KEY UPDATE " + strings.Join(assignments, ", ")
+
+	result := r.db.Exec(sql, args...)
+	if result.Error != nil {
+		return false, fmt.Errorf("新增或累加黑名单失败: %w", result.Error)
+	}
+
+	// MySQL影响行数：新增为1，更新为2，fencing token过期未修改为0（risk_count 每次累加都会变化）
+	switch result.RowsAffected {
+	case 1:
+		return true, nil
+	case 0:
+		return false, fmt.Errorf("%w: token=%d", ErrStaleFenceToken, fenceToken)
+	default:
+		return false, nil
+	}
+}
+
+// FindAllByAlipayUserID 查找支付宝用户ID的所有黑名单记录（不同设备码、IP各一条）
+func (r *BlacklistRepository) FindAllByAlipayUserID(alipayUserID string) ([]*model.AlipayBlacklist, error) {
+	var blacklists []*model.AlipayBlacklist
+	err := r.db.Where("alipay_user_id = ?", alipayUserID).Find(&blacklists).Error
 	if err != nil {
-		return fmt.Errorf("增加风险触发次数失败: %w", err)
+		return nil, fmt.Errorf("查询黑名单失败: %w", err)
+	}
+	return blacklists, nil
+}
+
+// FindBatchAfterID 按ID顺序分批查询黑名单记录（用于加载内存索引）
+func (r *BlacklistRepository) FindBatchAfterID(afterID uint, limit int) ([]*model.AlipayBlacklist, error) {
+	var blacklists []*model.AlipayBlacklist
+	err := r.db.Where("id > ?", afterID).Order("id ASC").Limit(limit).Find(&blacklists).Error
+	if err != nil {
+		return nil, fmt.Errorf("分批查询黑名单失败: %w", err)
+	}
+	return blacklists, nil
+}
+
+// FindUpdatedSince 查询更新时间不早于指定时间的黑名单记录（按更新时间正序，用于增量同步内存索引）
+func (r *BlacklistRepository) FindUpdatedSince(since time.Time, limit int) ([]*model.AlipayBlacklist, error) {
+	var blacklists []*model.AlipayBlacklist
+	err := r.db.Where("updated_at >= ?", since).
+		Order("updated_at ASC, id ASC").
+		Limit(limit).
+		Find(&blacklists).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询更新的黑名单失败: %w", err)
+	}
+	return blacklists, nil
+}
+
+// FindByIPAddress 查找IP地址的所有黑名单记录
+func (r *BlacklistRepository) FindByIPAddress(ipAddress string) ([]*model.AlipayBlacklist, error) {
+	var blacklists []*model.AlipayBlacklist
+	err := r.db.Where("ip_address = ?", ipAddress).Find(&blacklists).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询黑名单失败: %w", err)
+	}
+	return blacklists, nil
+}
+
+// FindByDeviceCode 查找设备码的所有黑名单记录
+func (r *BlacklistRepository) FindByDeviceCode(deviceCode string) ([]*model.AlipayBlacklist, error) {
+	var blacklists []*model.AlipayBlacklist
+	err := r.db.Where("device_code = ?", deviceCode).Find(&blacklists).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询黑名单失败: %w", err)
+	}
+	return blacklists, nil
+}
+
+// BlacklistExportFilter 黑名单导出筛选条件（零值表示不筛选）
+type BlacklistExportFilter struct {
+	MinRiskCount int       // 风险计数下限（含）
+	From         time.Time // 最后触发时间下限（含）
+	To           time.Time // 最后触发时间上限（不含）
+	SubjectID    int       // 只导出投诉过该主体的买家（按投诉买家身份 alipay_complaint_buyer 匹配）
+}
+
+// FindForExport 按ID顺序分批查询符合筛选条件的黑名单记录
+func (r *BlacklistRepository) FindForExport(filter BlacklistExportFilter, afterID uint, limit int) ([]*model.AlipayBlacklist, error) {
+	query := r.db.Where("id > ?", afterID)
+	if filter.MinRiskCount > 0 {
+		query = query.Where("risk_count >= ?", filter.MinRiskCount)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("last_risk_time >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("last_risk_time < ?", filter.To)
+	}
+	if filter.SubjectID > 0 {
+		query = query.Where("alipay_user_id IN (?)",
+			r.db.Model(&model.ComplaintBuyer{}).Select("buyer_id").Where("subject_id = ?", filter.SubjectID))
+	}
+
+	var blacklists []*model.AlipayBlacklist
+	if err := query.Order("id ASC").Limit(limit).Find(&blacklists).Error; err != nil {
+		return nil, fmt.Errorf("查询导出黑名单失败: %w", err)
+	}
+	return blacklists, nil
+}
+
+// FindExpired 查找已过期的黑名单记录（按过期时间正序）
+func (r *BlacklistRepository) FindExpired(now time.Time, limit int) ([]*model.AlipayBlacklist, error) {
+	var blacklists []*model.AlipayBlacklist
+	err := r.db.Where("expire_at IS NOT NULL AND expire_at <= ?", now).
+		Order("expire_at ASC").
+		Limit(limit).
+		Find(&blacklists).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询过期黑名单失败: %w", err)
+	}
+	return blacklists, nil
+}
+
+// DeleteExpired 删除已过期的黑名单记录
+// 返回是否删除成功（记录已被再次触发延长有效期或已被其他实例删除时返回false）
+func (r *BlacklistRepository) DeleteExpired(id uint, now time.Time) (bool, error) {
+	result := r.db.Where("id = ? AND expire_at IS NOT NULL AND expire_at <= ?", id, now).
+		Delete(&model.AlipayBlacklist{})
+	if result.Error != nil {
+		return false, fmt.Errorf("删除过期黑名单失败: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// DecrementRiskCount 减少风险触发次数（不低于1）
+// fenceToken 不为0时只更新fencing token不比其新的记录，否则返回 ErrStaleFenceToken
+func (r *BlacklistRepository) DecrementRiskCount(id uint, fenceToken int64) error {
+	query := r.db.Model(&model.AlipayBlacklist{}).Where("id = ? AND risk_count > 1", id)
+	updates := map[string]interface{}{
+		"risk_count": gorm.Expr("risk_count - 1"),
+	}
+	if fenceToken > 0 {
+		query = query.Where("fence_token <= ?", fenceToken)
+		updates["fence_token"] = fenceToken
+	}
+
+	result := query.Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("减少风险触发次数失败: %w", result.Error)
+	}
+	if fenceToken > 0 && result.RowsAffected == 0 {
+		return fmt.Errorf("%w: token=%d", ErrStaleFenceToken, fenceToken)
 	}
 	return nil
 }
 
+// DeleteByID 删除黑名单记录，返回是否删除成功（记录已被其他实例删除时返回false）
+// fenceToken 不为0时只删除fencing token不比其新的记录（为0表示管理接口等未持有锁的删除，不校验）
+func (r *BlacklistRepository) DeleteByID(id uint, fenceToken int64) (bool, error) {
+	query := r.db.Where("id = ?", id)
+	if fenceToken > 0 {
+		query = query.Where("fence_token <= ?", fenceToken)
+	}
+	result := query.Delete(&model.AlipayBlacklist{})
+	if result.Error != nil {
+		return false, fmt.Errorf("删除黑名单失败: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
 // CountAll 统计所有黑名单数量
 func (r *BlacklistRepository) CountAll() (int64, error) {
 	var count int64
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"complaint-monitor/internal/model"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// BuyerAllowlistRepository 买家白名单仓库
+type BuyerAllowlistRepository struct {
+	*BaseRepository
+}
+
+// NewBuyerAllowlistRepository 创建买家白名单仓库
+func NewBuyerAllowlistRepository(db *gorm.DB, logger *zap.Logger) *BuyerAllowlistRepository {
+	return &BuyerAllowlistRepository{
+		BaseRepository: NewBaseRepository(db, logger),
+	}
+}
+
+// Create 创建白名单记录
+func (r *BuyerAllowlistRepository) Create(entry *model.BuyerAllowlist) error {
+	if err := r.db.Create(entry).Error; err != nil {
+		return fmt.Errorf("创建白名单记录失败: %w", err)
+	}
+	return nil
+}
+
+// FindByID 根据ID查询（不存在返回nil）
+func (r *BuyerAllowlistRepository) FindByID(id uint) (*model.BuyerAllowlist, error) {
+	var entry model.BuyerAllowlist
+	err := r.db.Where("id = ?", id).First(&entry).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil // 不存在返回nil
+		}
+		return nil, fmt.Errorf("查询白名单记录失败: %w", err)
+	}
+	return &entry, nil
+}
+
+// FindByValue 根据匹配类型和值查询（不存在返回nil）
+func (r *BuyerAllowlistRepository) FindByValue(matchType, value string) (*model.BuyerAllowlist, error) {
+	var entry model.BuyerAllowlist
+	err := r.db.Where("match_type = ? AND value = ?", matchType, value).First(&entry).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil // 不存在返回nil
+		}
+		return nil, fmt.Errorf("查询白名单记录失败: %w", err)
+	}
+	return &entry, nil
+}
+
+// FindAll 查询所有白名单记录（按ID排序）
+func (r *BuyerAllowlistRepository) FindAll() ([]*model.BuyerAllowlist, error) {
+	var entries []*model.BuyerAllowlist
+	if err := r.db.Order("id ASC").Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("查询白名单失败: %w", err)
+	}
+	return entries, nil
+}
+
+// FindActiveMatches 查询与买家ID、IP、设备码任一匹配且未过期的白名单记录（参数为空时不参与匹配）
+func (r *BuyerAllowlistRepository) FindActiveMatches(alipayUserID, ipAddress, deviceCode string, now time.Time) ([]*model.BuyerAllowlist, error) {
+	if alipayUserID == "" && ipAddress == "" && deviceCode == "" {
+		return nil, nil
+	}
+
+	// 以恒假条件开头，按非空参数依次 OR 拼接
+	conditions := r.db.Where("1 = 0")
+	if alipayUserID != "" {
+		conditions = conditions.Or("match_type = ? AND value = ?", model.AllowlistTypeUser, alipayUserID)
+	}
+	if ipAddress != "" {
+		conditions = conditions.Or("match_type = ? AND value = ?", model.AllowlistTypeIP, ipAddress)
+	}
+	if deviceCode != "" {
+		conditions = conditions.Or("match_type = ? AND value = ?", model.AllowlistTypeDevice, deviceCode)
+	}
+
+	var entries []*model.BuyerAllowlist
+	err := r.db.Where(conditions).
+		Where("expire_at IS NULL OR expire_at > ?", now).
+		Find(&entries).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询白名单匹配失败: %w", err)
+	}
+	return entries, nil
+}
+
+// FindActiveByType 查询指定匹配类型且未过期的白名单记录
+func (r *BuyerAllowlistRepository) FindActiveByType(matchType string, now time.Time) ([]*model.BuyerAllowlist, error) {
+	var entries []*model.BuyerAllowlist
+	err := r.db.Where("match_type = ?", matchType).
+		Where("expire_at IS NULL OR expire_at > ?", now).
+		Find(&entries).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询白名单失败: %w", err)
+	}
+	return entries, nil
+}
+
+// Delete 删除白名单记录，返回是否删除
+func (r *BuyerAllowlistRepository) Delete(id uint) (bool, error) {
+	result := r.db.Where("id = ?", id).Delete(&model.BuyerAllowlist{})
+	if result.Error != nil {
+		return false, fmt.Errorf("删除白名单记录失败: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -2,11 +2,13 @@ package repository
 
 import (
 	"fmt"
+	"time"
 
 	"complaint-monitor/internal/model"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ComplaintRepository 投诉仓库
@@ -52,6 +54,19 @@ func (r *ComplaintRepository) FindByComplaintNo(subjectID int, complaintNo strin
 	return &complaint, nil
 }
 
+// FindByID 根据ID查找投诉
+func (r *ComplaintRepository) FindByID(id uint) (*model.Complaint, error) {
+	var complaint model.Complaint
+	err := r.db.Where("id = ?", id).First(&complaint).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil // 不存在返回nil
+		}
+		return nil, fmt.Errorf("查询投诉失败: %w", err)
+	}
+	return &complaint, nil
+}
+
 // FindByAlipayTaskId 根据支付宝投诉单号（TaskId）查找
 func (r *ComplaintRepository) FindByAlipayTaskId(subjectID int, alipayTaskId string) (*model.Complaint, error) {
 	var complaint model.Complaint
@@ -110,6 +125,74 @@ func (r *ComplaintRepository) UpdateComplaint(complaint *model.Complaint) error
 	return nil
 }
 
+// UpdateStatusWithLog 更新投诉状态并写入状态变更记录（事务）
+// complaint.FenceToken 不为0时校验fencing token，比已写入的token旧时返回 ErrStaleFenceToken
+func (r *ComplaintRepository) UpdateStatusWithLog(complaint *model.Complaint, statusLog *model.ComplaintStatusLog) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkFence(tx, &model.Complaint{}, complaint.ID, complaint.FenceToken); err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{
+			"complaint_status": complaint.ComplaintStatus,
+			"gmt_modified":     complaint.GmtModified,
+			"refund_amount":    complaint.RefundAmount,
+			"updated_at":       gorm.Expr("NOW()"),
+		}
+		if complaint.FenceToken > 0 {
+			updates["fence_token"] = complaint.FenceToken
+		}
+
+		err := tx.Model(&model.Complaint{}).Where("id = ?", complaint.ID).Updates(updates).Error
+		if err != nil {
+			return fmt.Errorf("更新投诉状态失败: %w", err)
+		}
+
+		statusLog.ComplaintID = complaint.ID
+		if err := tx.Create(statusLog).Error; err != nil {
+			return fmt.Errorf("写入投诉状态变更记录失败: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// UpdateHandleResult 更新商家处理结果（反馈内容、反馈时间、处理人、状态）
+// statusLog 不为nil时在同一事务中写入状态变更记录
+func (r *ComplaintRepository) UpdateHandleResult(complaint *model.Complaint, statusLog *model.ComplaintStatusLog) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.Complaint{}).Where("id = ?", complaint.ID).Updates(map[string]interface{}{
+			"merchant_feedback": complaint.MerchantFeedback,
+			"feedback_time":     complaint.FeedbackTime,
+			"handler_id":        complaint.HandlerID,
+			"complaint_status":  complaint.ComplaintStatus,
+			"updated_at":        gorm.Expr("NOW()"),
+		}).Error
+		if err != nil {
+			return fmt.Errorf("更新投诉处理结果失败: %w", err)
+		}
+
+		if statusLog != nil {
+			statusLog.ComplaintID = complaint.ID
+			if err := tx.Create(statusLog).Error; err != nil {
+				return fmt.Errorf("写入投诉状态变更记录失败: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// FindStatusLogs 查询投诉的状态变更记录（按时间正序）
+func (r *ComplaintRepository) FindStatusLogs(complaintID uint) ([]*model.ComplaintStatusLog, error) {
+	var logs []*model.ComplaintStatusLog
+	err := r.db.Where("complaint_id = ?", complaintID).Order("id ASC").Find(&logs).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询投诉状态变更记录失败: %w", err)
+	}
+	return logs, nil
+}
+
 // ExistsDetail 检查投诉详情是否存在
 // complaintID: 投诉主表ID
 // merchantOrderNo: 商户订单号
@@ -146,7 +229,7 @@ func (r *ComplaintRepository) CreateWithDetails(complaint *model.Complaint, deta
 			"alipay_complain_id", "complaint_status", "complainant_id", 
 			"complaint_time", "complaint_reason", "refund_amount", 
 			"merchant_feedback", "feedback_images", "feedback_time", 
-			"handler_id", "gmt_create", "gmt_modified", "created_at", "updated_at",
+			"handler_id", "gmt_create", "gmt_modified", "fence_token", "created_at", "updated_at",
 		).Create(complaint)
 		
 		if result.Error != nil {
@@ -214,6 +297,20 @@ func (r *ComplaintRepository) CountBySubject(subjectID int) (int64, error) {
 	return count, nil
 }
 
+// CountOpenByBuyer 统计买家在所有主体未结束的投诉数量（按投诉买家身份汇总，排除指定的投诉）
+func (r *ComplaintRepository) CountOpenByBuyer(buyerID string, excludeComplaintID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.Complaint{}).
+		Joins("JOIN alipay_complaint_buyer ON alipay_complaint_buyer.complaint_id = alipay_complaint.id").
+		Where("alipay_complaint_buyer.buyer_id = ? AND alipay_complaint.id <> ?", buyerID, excludeComplaintID).
+		Where("alipay_complaint.complaint_status IN ?", model.ComplaintOpenStatuses).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("统计买家未结束投诉数量失败: %w", err)
+	}
+	return count, nil
+}
+
 // CountByComplainant 统计投诉人的投诉次数
 func (r *ComplaintRepository) CountByComplainant(subjectID int, complainantID string) (int64, error) {
 	var count int64
@@ -225,3 +322,97 @@ func (r *ComplaintRepository) CountByComplainant(subjectID int, complainantID st
 	}
 	return count, nil
 }
+
+// BuyerRiskProfile 买家跨主体投诉画像（汇总所有主体和代理商）
+type BuyerRiskProfile struct {
+	TotalComplaints int64      `json:"total_complaints"` // 投诉总次数
+	SubjectCount    int64      `json:"subject_count"`    // 投诉过的不同主体数
+	AgentCount      int64      `json:"agent_count"`      // 投诉过的不同代理商数（不含未知代理商）
+	TotalAmount     float64    `json:"total_amount"`     // 投诉总金额（订单维度投诉金额之和，元）
+	FirstSeen       *time.Time `json:"first_seen"`       // 首次投诉时间
+	LastSeen        *time.Time `json:"last_seen"`        // 最近投诉时间
+}
+
+// SaveBuyers 保存投诉解析出的买家身份（已存在的买家忽略）
+func (r *ComplaintRepository) SaveBuyers(buyers []*model.ComplaintBuyer) error {
+	if len(buyers) == 0 {
+		return nil
+	}
+	err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&buyers).Error
+	if err != nil {
+		return fmt.Errorf("保存投诉买家身份失败: %w", err)
+	}
+	return nil
+}
+
+// SaveBlacklistLink 记录投诉拉黑时新增或累加的黑名单记录（已记录时忽略）
+func (r *ComplaintRepository) SaveBlacklistLink(link *model.ComplaintBlacklist) error {
+	err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(link).Error
+	if err != nil {
+		return fmt.Errorf("保存投诉拉黑记录失败: %w", err)
+	}
+	return nil
+}
+
+// FindBlacklistLinks 查询投诉拉黑时新增或累加的黑名单记录
+func (r *ComplaintRepository) FindBlacklistLinks(complaintID uint) ([]*model.ComplaintBlacklist, error) {
+	var links []*model.ComplaintBlacklist
+	err := r.db.Where("complaint_id = ?", complaintID).Order("id ASC").Find(&links).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询投诉拉黑记录失败: %w", err)
+	}
+	return links, nil
+}
+
+// DeleteBlacklistLink 删除投诉拉黑记录（撤诉撤销后删除，避免重复撤销）
+func (r *ComplaintRepository) DeleteBlacklistLink(id uint) error {
+	if err := r.db.Delete(&model.ComplaintBlacklist{}, id).Error; err != nil {
+		return fmt.Errorf("删除投诉拉黑记录失败: %w", err)
+	}
+	return nil
+}
+
+// CountByBuyerSince 统计买家在所有主体被投诉的次数（按投诉买家身份汇总，since 为nil时统计全部历史）
+func (r *ComplaintRepository) CountByBuyerSince(buyerID string, since *time.Time) (int64, error) {
+	var count int64
+	query := r.db.Model(&model.Complaint{}).
+		Joins("JOIN alipay_complaint_buyer ON alipay_complaint_buyer.complaint_id = alipay_complaint.id").
+		Where("alipay_complaint_buyer.buyer_id = ?", buyerID)
+	if since != nil {
+		query = query.Where("alipay_complaint.complaint_time >= ?", *since)
+	}
+	if err := query.Distinct("alipay_complaint.id").Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("统计买家投诉次数失败: %w", err)
+	}
+	return count, nil
+}
+
+// FindBuyerRiskProfile 汇总买家在所有主体和代理商的投诉画像（按投诉买家身份汇总，没有投诉时各项为零值）
+func (r *ComplaintRepository) FindBuyerRiskProfile(buyerID string) (*BuyerRiskProfile, error) {
+	// 买家身份在同一投诉中唯一（uk_complaint_buyer），JOIN 不会重复计数
+	var profile BuyerRiskProfile
+	err := r.db.Model(&model.Complaint{}).
+		Select("COUNT(*) AS total_complaints, "+
+			"COUNT(DISTINCT alipay_complaint.subject_id) AS subject_count, "+
+			"COUNT(DISTINCT NULLIF(alipay_complaint.agent_id, 0)) AS agent_count, "+
+			"MIN(alipay_complaint.complaint_time) AS first_seen, "+
+			"MAX(alipay_complaint.complaint_time) AS last_seen").
+		Joins("JOIN alipay_complaint_buyer ON alipay_complaint_buyer.complaint_id = alipay_complaint.id").
+		Where("alipay_complaint_buyer.buyer_id = ?", buyerID).
+		Scan(&profile).Error
+	if err != nil {
+		return nil, fmt.Errorf("汇总买家投诉画像失败: %w", err)
+	}
+
+	if profile.TotalComplaints > 0 {
+		err = r.db.Model(&model.ComplaintDetail{}).
+			Select("COALESCE(SUM(alipay_complaint_detail.complaint_amount), 0)").
+			Joins("JOIN alipay_complaint_buyer ON alipay_complaint_buyer.complaint_id = alipay_complaint_detail.complaint_id").
+			Where("alipay_complaint_buyer.buyer_id = ?", buyerID).
+			Scan(&profile.TotalAmount).Error
+		if err != nil {
+			return nil, fmt.Errorf("汇总买家投诉金额失败: %w", err)
+		}
+	}
+	return &profile, nil
+}
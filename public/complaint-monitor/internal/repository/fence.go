@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrStaleFenceToken 写入携带的fencing token比记录中已写入的旧（锁已过期并被其他实例接管）
+var ErrStaleFenceToken = errors.New("fencing token已过期，锁已被其他实例接管")
+
+// checkFence 在事务中锁定记录并校验fencing token
+// token为0表示写入方未持有锁（如管理接口），不校验
+func checkFence(tx *gorm.DB, table interface{}, id uint, token int64) error {
+	if token == 0 {
+		return nil
+	}
+
+	var row struct {
+		FenceToken int64
+	}
+	err := tx.Model(table).
+		Select("fence_token").
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).
+		Take(&row).Error
+	if err != nil {
+		return fmt.Errorf("查询fencing token失败: %w", err)
+	}
+
+	if token < row.FenceToken {
+		return fmt.Errorf("%w: token=%d, current=%d", ErrStaleFenceToken, token, row.FenceToken)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"fmt"
+	"time"
 
 	"complaint-monitor/internal/model"
 
@@ -31,6 +32,7 @@ func (r *OrderRepository) FindByMerchantOrderNo(merchantOrderNo string) (*model.
 		}
 		return nil, fmt.Errorf("查询订单失败: %w", err)
 	}
+	r.fillDeviceCodes([]*model.Order{&order})
 	return &order, nil
 }
 
@@ -44,6 +46,7 @@ func (r *OrderRepository) FindByPlatformOrderNo(platformOrderNo string) (*model.
 		}
 		return nil, fmt.Errorf("查询订单失败: %w", err)
 	}
+	r.fillDeviceCodes([]*model.Order{&order})
 	return &order, nil
 }
 
@@ -69,9 +72,66 @@ func (r *OrderRepository) FindByOrderNos(merchantOrderNos []string, platformOrde
 	if err != nil {
 		return nil, fmt.Errorf("批量查询订单失败: %w", err)
 	}
+	r.fillDeviceCodes(orders)
 	return orders, nil
 }
 
+// FindPaidBuyerOrdersAfterID 按ID顺序分批查询指定时间后支付且有buyer_id的订单（用于买家关联分析）
+// 只查询关联分析需要的字段，设备码从订单日志填充
+func (r *OrderRepository) FindPaidBuyerOrdersAfterID(afterID uint, since time.Time, limit int) ([]*model.Order, error) {
+	var orders []*model.Order
+	err := r.db.Select("id", "platform_order_no", "buyer_id", "first_open_ip", "pay_ip", "pay_status", "pay_time").
+		Where("id > ? AND pay_status = 1 AND buyer_id <> '' AND pay_time >= ?", afterID, since).
+		Order("id ASC").
+		Limit(limit).
+		Find(&orders).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询已支付订单失败: %w", err)
+	}
+	r.fillDeviceCodes(orders)
+	return orders, nil
+}
+
+// fillDeviceCodes 从订单日志（order_log）填充订单的买家设备码
+// 只使用买家端访问产生的日志，取每个订单最近一条有设备码的记录；查询失败时设备码保持为空，不影响订单查询
+func (r *OrderRepository) fillDeviceCodes(orders []*model.Order) {
+	platformOrderNos := make([]string, 0, len(orders))
+	for _, order := range orders {
+		if order.PlatformOrderNo != "" {
+			platformOrderNos = append(platformOrderNos, order.PlatformOrderNo)
+		}
+	}
+	if len(platformOrderNos) == 0 {
+		return
+	}
+
+	var logs []*model.OrderLog
+	err := r.db.Select("id", "platform_order_no", "content").
+		Where("platform_order_no IN ? AND log_type IN ?", platformOrderNos, model.OrderLogBuyerTypes).
+		Order("id DESC").
+		Find(&logs).Error
+	if err != nil {
+		r.logger.Warn("查询订单日志失败，设备码为空",
+			zap.Strings("platform_order_nos", platformOrderNos),
+			zap.Error(err))
+		return
+	}
+
+	deviceCodes := make(map[string]string, len(platformOrderNos))
+	for _, orderLog := range logs {
+		if _, exists := deviceCodes[orderLog.PlatformOrderNo]; exists {
+			continue
+		}
+		if code := orderLog.DeviceCode(); code != "" {
+			deviceCodes[orderLog.PlatformOrderNo] = code
+		}
+	}
+
+	for _, order := range orders {
+		order.DeviceCode = deviceCodes[order.PlatformOrderNo]
+	}
+}
+
 // GetBuyerIDsByOrderNos 根据订单号列表获取购买者UID列表（去重，只返回已支付订单的buyer_id）
 func (r *OrderRepository) GetBuyerIDsByOrderNos(merchantOrderNos []string, platformOrderNos []string) ([]string, error) {
 	orders, err := r.FindByOrderNos(merchantOrderNos, platformOrderNos)
@@ -97,9 +157,33 @@ func (r *OrderRepository) GetBuyerIDsByOrderNos(merchantOrderNos []string, platf
 	return buyerIDs, nil
 }
 
+// FillBuyerID 回写订单的购买者UID（只更新 buyer_id 为空的订单，不覆盖已有值），返回更新的订单数
+// merchantOrderNo 匹配 merchant_order_no，alipayOrderNo 匹配 alipay_order_no（为空时不参与匹配）
+func (r *OrderRepository) FillBuyerID(merchantOrderNo, alipayOrderNo, buyerID string) (int64, error) {
+	if buyerID == "" || (merchantOrderNo == "" && alipayOrderNo == "") {
+		return 0, nil
+	}
+
+	query := r.db.Model(&model.Order{}).Where("buyer_id IS NULL OR buyer_id = ''")
+	switch {
+	case merchantOrderNo != "" && alipayOrderNo != "":
+		query = query.Where("merchant_order_no = ? OR alipay_order_no = ?", merchantOrderNo, alipayOrderNo)
+	case merchantOrderNo != "":
+		query = query.Where("merchant_order_no = ?", merchantOrderNo)
+	default:
+		query = query.Where("alipay_order_no = ?", alipayOrderNo)
+	}
+
+	result := query.Update("buyer_id", buyerID)
+	if result.Error != nil {
+		return 0, fmt.Errorf("回写订单购买者UID失败: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
 // GetOrderIPAndDevice 获取订单的IP地址和设备信息（用于拉黑）
 // 优先返回支付IP（pay_ip），如果没有则返回首次打开IP（first_open_ip）
-// 注意：订单表中暂时没有设备码字段，设备码返回空字符串
+// 设备码来自订单日志（order_log）中买家访问时记录的设备码，没有时返回空字符串
 func (r *OrderRepository) GetOrderIPAndDevice(merchantOrderNo string, platformOrderNo string) (ipAddress string, deviceCode string, err error) {
 	var order *model.Order
 
@@ -130,9 +214,5 @@ func (r *OrderRepository) GetOrderIPAndDevice(merchantOrderNo string, platformOr
 		ipAddress = order.FirstOpenIP
 	}
 
-	// 设备码：订单表中暂时没有设备码字段，返回空字符串
-	// 如果将来订单表添加了设备码字段，需要在这里读取
-	deviceCode = ""
-
-	return ipAddress, deviceCode, nil
+	return ipAddress, order.DeviceCode, nil
 }
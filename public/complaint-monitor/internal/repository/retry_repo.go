@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"complaint-monitor/internal/model"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// RetryRepository 投诉处理重试仓库
+type RetryRepository struct {
+	*BaseRepository
+}
+
+// NewRetryRepository 创建投诉处理重试仓库
+func NewRetryRepository(db *gorm.DB, logger *zap.Logger) *RetryRepository {
+	return &RetryRepository{
+		BaseRepository: NewBaseRepository(db, logger),
+	}
+}
+
+// FindByID 根据ID查找重试记录
+func (r *RetryRepository) FindByID(id uint) (*model.ComplaintRetry, error) {
+	var retry model.ComplaintRetry
+	err := r.db.Where("id = ?", id).First(&retry).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil // 不存在返回nil
+		}
+		return nil, fmt.Errorf("查询重试记录失败: %w", err)
+	}
+	return &retry, nil
+}
+
+// FindByTask 根据主体和支付宝投诉单号查找重试记录
+func (r *RetryRepository) FindByTask(subjectID int, alipayTaskId string) (*model.ComplaintRetry, error) {
+	var retry model.ComplaintRetry
+	err := r.db.Where("subject_id = ? AND alipay_task_id = ?", subjectID, alipayTaskId).First(&retry).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil // 不存在返回nil
+		}
+		return nil, fmt.Errorf("查询重试记录失败: %w", err)
+	}
+	return &retry, nil
+}
+
+// Save 保存重试记录（ID为0时创建）
+func (r *RetryRepository) Save(retry *model.ComplaintRetry) error {
+	if err := r.db.Save(retry).Error; err != nil {
+		return fmt.Errorf("保存重试记录失败: %w", err)
+	}
+	return nil
+}
+
+// FindDue 查找主体已到重试时间的记录（按下次重试时间正序）
+func (r *RetryRepository) FindDue(subjectID int, now time.Time, limit int) ([]*model.ComplaintRetry, error) {
+	var retries []*model.ComplaintRetry
+	query := r.db.Where("subject_id = ? AND status = ? AND next_attempt_at <= ?", subjectID, model.RetryStatusPending, now).
+		Order("next_attempt_at ASC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&retries).Error; err != nil {
+		return nil, fmt.Errorf("查询待重试记录失败: %w", err)
+	}
+	return retries, nil
+}
+
+// MarkResolved 标记重试成功
+func (r *RetryRepository) MarkResolved(id uint) error {
+	err := r.db.Model(&model.ComplaintRetry{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":     model.RetryStatusResolved,
+		"updated_at": gorm.Expr("NOW()"),
+	}).Error
+	if err != nil {
+		return fmt.Errorf("标记重试成功失败: %w", err)
+	}
+	return nil
+}
+
+// FindDead 分页查询死信记录（subjectID 为0时查询所有主体）
+func (r *RetryRepository) FindDead(subjectID int, offset, limit int) ([]*model.ComplaintRetry, int64, error) {
+	query := r.db.Model(&model.ComplaintRetry{}).Where("status = ?", model.RetryStatusDead)
+	if subjectID > 0 {
+		query = query.Where("subject_id = ?", subjectID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计死信记录失败: %w", err)
+	}
+
+	var retries []*model.ComplaintRetry
+	if err := query.Order("dead_at DESC").Offset(offset).Limit(limit).Find(&retries).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询死信记录失败: %w", err)
+	}
+	return retries, total, nil
+}
+
+// Requeue 死信重新入队（重置失败次数，立即重试）
+func (r *RetryRepository) Requeue(id uint) error {
+	result := r.db.Model(&model.ComplaintRetry{}).
+		Where("id = ? AND status = ?", id, model.RetryStatusDead).
+		Updates(map[string]interface{}{
+			"status":          model.RetryStatusPending,
+			"attempts":        0,
+			"next_attempt_at": gorm.Expr("NOW()"),
+			"dead_at":         nil,
+			"updated_at":      gorm.Expr("NOW()"),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("死信重新入队失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("死信记录不存在: id=%d", id)
+	}
+	return nil
+}
+
+// CountByStatus 统计各状态的重试记录数量
+func (r *RetryRepository) CountByStatus(status string) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.ComplaintRetry{}).Where("status = ?", status).Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("统计重试记录失败: %w", err)
+	}
+	return count, nil
+}
@@ -67,6 +67,16 @@ func (r *SubjectRepository) FindAll() ([]*model.Subject, error) {
 	return subjects, nil
 }
 
+// FindAllAlipayPIDs 查询所有主体的支付宝PID（不含空值）
+func (r *SubjectRepository) FindAllAlipayPIDs() ([]string, error) {
+	var pids []string
+	err := r.db.Model(&model.Subject{}).Where("alipay_pid <> ''").Distinct().Pluck("alipay_pid", &pids).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询主体支付宝PID失败: %w", err)
+	}
+	return pids, nil
+}
+
 // FindActiveWithCert 查找所有激活且有证书的主体
 func (r *SubjectRepository) FindActiveWithCert() ([]*model.Subject, error) {
 	var subjects []*model.Subject
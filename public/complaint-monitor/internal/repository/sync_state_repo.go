@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"complaint-monitor/internal/model"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SyncStateRepository 同步水位仓库
+type SyncStateRepository struct {
+	*BaseRepository
+}
+
+// NewSyncStateRepository 创建同步水位仓库
+func NewSyncStateRepository(db *gorm.DB, logger *zap.Logger) *SyncStateRepository {
+	return &SyncStateRepository{
+		BaseRepository: NewBaseRepository(db, logger),
+	}
+}
+
+// FindBySubjectID 根据主体ID查找同步水位
+func (r *SyncStateRepository) FindBySubjectID(subjectID int) (*model.SubjectSyncState, error) {
+	var state model.SubjectSyncState
+	err := r.db.Where("subject_id = ?", subjectID).First(&state).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil // 不存在返回nil
+		}
+		return nil, fmt.Errorf("查询同步水位失败: %w", err)
+	}
+	return &state, nil
+}
+
+// SaveSyncTime 保存同步水位（使用ON DUPLICATE KEY UPDATE）
+// fullScan 为true时同时更新最后全量扫描时间
+func (r *SyncStateRepository) SaveSyncTime(subjectID int, syncTime time.Time, fullScan bool) error {
+	state := &model.SubjectSyncState{
+		SubjectID:    subjectID,
+		LastSyncTime: &syncTime,
+	}
+
+	updateColumns := []string{"last_sync_time", "updated_at"}
+	if fullScan {
+		state.LastFullScanTime = &syncTime
+		updateColumns = append(updateColumns, "last_full_scan_time")
+	}
+
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "subject_id"}},
+		DoUpdates: clause.AssignmentColumns(updateColumns),
+	}).Create(state).Error
+	if err != nil {
+		return fmt.Errorf("保存同步水位失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+
+	"complaint-monitor/internal/model"
+	"complaint-monitor/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// AllowlistService 买家白名单服务
+// 白名单按支付宝用户ID、IP或设备码匹配，优先于自动拉黑：命中的买家投诉照常入库，但不新增或累加黑名单
+type AllowlistService struct {
+	allowlistRepo *repository.BuyerAllowlistRepository
+	logger        *zap.Logger
+}
+
+// NewAllowlistService 创建买家白名单服务
+func NewAllowlistService(allowlistRepo *repository.BuyerAllowlistRepository, logger *zap.Logger) *AllowlistService {
+	return &AllowlistService{
+		allowlistRepo: allowlistRepo,
+		logger:        logger,
+	}
+}
+
+// Match 查询买家是否命中未过期的白名单（参数为空时不参与匹配），按用户ID、设备码、IP的优先级返回命中的记录（未命中返回nil）
+func (s *AllowlistService) Match(alipayUserID, ipAddress, deviceCode string) (*model.BuyerAllowlist, error) {
+	if ipAddress != "" {
+		if normalized, err := normalizeAllowlistValue(model.AllowlistTypeIP, ipAddress); err == nil {
+			ipAddress = normalized
+		}
+	}
+
+	entries, err := s.allowlistRepo.FindActiveMatches(alipayUserID, ipAddress, model.NormalizeDeviceCode(deviceCode), time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	var matched *model.BuyerAllowlist
+	for _, entry := range entries {
+		if matched == nil || allowlistTypeRank(entry.MatchType) < allowlistTypeRank(matched.MatchType) {
+			matched = entry
+		}
+	}
+	return matched, nil
+}
+
+// MatchSubnet 查询网段内是否有未过期的白名单IP，返回第一条命中的记录（未命中返回nil）
+func (s *AllowlistService) MatchSubnet(subnet netip.Prefix) (*model.BuyerAllowlist, error) {
+	entries, err := s.allowlistRepo.FindActiveByType(model.AllowlistTypeIP, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return allowlistedIPIn(entries, subnet), nil
+}
+
+// List 查询所有白名单记录
+func (s *AllowlistService) List() ([]*model.BuyerAllowlist, error) {
+	return s.allowlistRepo.FindAll()
+}
+
+// Add 添加白名单（已存在相同的匹配类型和值时返回错误）
+func (s *AllowlistService) Add(matchType, value, reason string, expireAt *time.Time, operatorID int) (*model.BuyerAllowlist, error) {
+	value, err := normalizeAllowlistValue(matchType, value)
+	if err != nil {
+		return nil, err
+	}
+	if expireAt != nil && !expireAt.After(time.Now()) {
+		return nil, fmt.Errorf("过期时间必须晚于当前时间")
+	}
+
+	existing, err := s.allowlistRepo.FindByValue(matchType, value)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("白名单已存在: %s %s", matchType, value)
+	}
+
+	entry := &model.BuyerAllowlist{
+		MatchType: matchType,
+		Value:     value,
+		Reason:    truncateRunes(reason, 255),
+		ExpireAt:  expireAt,
+		CreatedBy: operatorID,
+	}
+	if err := s.allowlistRepo.Create(entry); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("新增买家白名单",
+		zap.Uint("allowlist_id", entry.ID),
+		zap.String("match_type", matchType),
+		zap.String("value", value),
+		zap.String("reason", entry.Reason),
+		zap.Int("operator_id", operatorID))
+	return entry, nil
+}
+
+// Remove 删除白名单，返回被删除的记录（不存在返回nil）
+func (s *AllowlistService) Remove(id uint) (*model.BuyerAllowlist, error) {
+	entry, err := s.allowlistRepo.FindByID(id)
+	if err != nil || entry == nil {
+		return nil, err
+	}
+
+	deleted, err := s.allowlistRepo.Delete(id)
+	if err != nil || !deleted {
+		return nil, err
+	}
+
+	s.logger.Info("买家白名单已删除",
+		zap.Uint("allowlist_id", entry.ID),
+		zap.String("match_type", entry.MatchType),
+		zap.String("value", entry.Value))
+	return entry, nil
+}
+
+// normalizeAllowlistValue 校验并规范化白名单的值（IP转为标准格式，设备码与黑名单使用相同的规范化）
+func normalizeAllowlistValue(matchType, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", fmt.Errorf("白名单的值不能为空")
+	}
+
+	switch matchType {
+	case model.AllowlistTypeUser:
+		return value, nil
+	case model.AllowlistTypeIP:
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			return "", fmt.Errorf("无效的IP地址: %s", value)
+		}
+		return addr.Unmap().String(), nil
+	case model.AllowlistTypeDevice:
+		return model.NormalizeDeviceCode(value), nil
+	default:
+		return "", fmt.Errorf("无效的白名单类型: %s（支持 user/ip/device）", matchType)
+	}
+}
+
+// allowlistedIPIn 返回第一条IP在网段内的白名单记录（无法解析的IP跳过）
+func allowlistedIPIn(entries []*model.BuyerAllowlist, subnet netip.Prefix) *model.BuyerAllowlist {
+	for _, entry := range entries {
+		addr, err := netip.ParseAddr(entry.Value)
+		if err != nil {
+			continue
+		}
+		if subnet.Contains(addr.Unmap()) {
+			return entry
+		}
+	}
+	return nil
+}
+
+// allowlistTypeRank 白名单匹配类型的优先级（数值越小越优先）
+func allowlistTypeRank(matchType string) int {
+	switch matchType {
+	case model.AllowlistTypeUser:
+		return 0
+	case model.AllowlistTypeDevice:
+		return 1
+	default:
+		return 2
+	}
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+
+	"complaint-monitor/internal/model"
+)
+
+func TestNormalizeAllowlistValue(t *testing.T) {
+	tests := []struct {
+		matchType string
+		value     string
+		want      string
+		wantErr   bool
+	}{
+		{model.AllowlistTypeUser, " 2088001 ", "2088001", false},
+		{model.AllowlistTypeIP, "::ffff:1.2.3.4", "1.2.3.4", false},
+		{model.AllowlistTypeIP, "2001:DB8::1", "2001:db8::1", false},
+		{model.AllowlistTypeIP, "1.2.3", "", true},
+		{model.AllowlistTypeDevice, strings.Repeat("a", 200), model.NormalizeDeviceCode(strings.Repeat("a", 200)), false},
+		{"phone", "138", "", true},
+		{model.AllowlistTypeUser, "  ", "", true},
+	}
+	for _, tt := range tests {
+		got, err := normalizeAllowlistValue(tt.matchType, tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("normalizeAllowlistValue(%s, %q) error = %v, 期望错误 %v", tt.matchType, tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("normalizeAllowlistValue(%s, %q) = %q, 期望 %q", tt.matchType, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestAllowlistedIPIn(t *testing.T) {
+	entries := []*model.BuyerAllowlist{
+		{ID: 1, MatchType: model.AllowlistTypeIP, Value: "bad-ip"},
+		{ID: 2, MatchType: model.AllowlistTypeIP, Value: "10.0.1.5"},
+		{ID: 3, MatchType: model.AllowlistTypeIP, Value: "2001:db8::1"},
+	}
+
+	tests := []struct {
+		subnet string
+		want   uint
+	}{
+		{"10.0.1.0/24", 2},
+		{"10.0.2.0/24", 0},
+		{"2001:db8::/64", 3},
+	}
+	for _, tt := range tests {
+		got := allowlistedIPIn(entries, netip.MustParsePrefix(tt.subnet))
+		var gotID uint
+		if got != nil {
+			gotID = got.ID
+		}
+		if gotID != tt.want {
+			t.Errorf("allowlistedIPIn(%s) = %d, 期望 %d", tt.subnet, gotID, tt.want)
+		}
+	}
+}
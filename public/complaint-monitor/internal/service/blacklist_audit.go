@@ -0,0 +1,242 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"complaint-monitor/internal/model"
+	"complaint-monitor/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// auditAppendRetries 追加审计记录时链尾被其他实例抢先写入的最大重试次数
+	auditAppendRetries = 5
+	// auditMaxProblems 校验哈希链时最多记录的问题数
+	auditMaxProblems = 100
+)
+
+// 系统操作方名称
+const (
+	auditActorRule       = "blacklist_rule" // 投诉拉黑规则（命中具名规则时为 blacklist_rule:规则名）
+	auditActorBuyerGraph = "buyer_graph"    // 买家关联分析
+	auditActorExpiry     = "expiry_sweeper" // 过期清理
+	auditActorWithdrawal = "withdrawal"     // 用户撤诉
+)
+
+// BlacklistActor 黑名单变更的操作方
+type BlacklistActor struct {
+	Type string // 操作方类型（model.BlacklistActorXxx）
+	Name string // 规则名、操作人ID或导入文件
+}
+
+// SystemActor 系统规则操作方
+func SystemActor(name string) BlacklistActor {
+	return BlacklistActor{Type: model.BlacklistActorSystem, Name: name}
+}
+
+// ruleActor 投诉拉黑规则操作方
+func ruleActor(ruleName string) BlacklistActor {
+	if ruleName == "" {
+		return SystemActor(auditActorRule)
+	}
+	return SystemActor(auditActorRule + ":" + ruleName)
+}
+
+// OperatorActor 管理接口操作人
+func OperatorActor(operatorID int) BlacklistActor {
+	return BlacklistActor{Type: model.BlacklistActorOperator, Name: strconv.Itoa(operatorID)}
+}
+
+// ImportActor 导入命令（source 为导入文件）
+func ImportActor(source string) BlacklistActor {
+	return BlacklistActor{Type: model.BlacklistActorImport, Name: source}
+}
+
+// BlacklistChange 一次黑名单变更
+type BlacklistChange struct {
+	Action      string                 // 变更动作（model.BlacklistAuditXxx）
+	Actor       BlacklistActor         // 操作方
+	ComplaintNo string                 // 来源投诉单号
+	Reason      string                 // 变更原因
+	Before      *model.AlipayBlacklist // 变更前的记录（新增时为nil）
+	After       *model.AlipayBlacklist // 变更后的记录（解除时为nil）
+}
+
+// blacklistAuditSnapshot 审计记录中保存的黑名单取值
+type blacklistAuditSnapshot struct {
+	RiskCount    int    `json:"risk_count"`
+	RiskLevel    string `json:"risk_level"`
+	LastRiskTime string `json:"last_risk_time,omitempty"`
+	ExpireAt     string `json:"expire_at,omitempty"` // 为空表示永久有效
+	Remark       string `json:"remark,omitempty"`
+}
+
+// BlacklistAuditor 黑名单变更审计
+// 每次变更追加一条记录到 alipay_blacklist_audit，记录按 seq 串成哈希链；
+// 多实例并发追加时依靠 seq 唯一索引保证链不分叉，冲突时重新读取链尾重试
+type BlacklistAuditor struct {
+	auditRepo *repository.BlacklistAuditRepository
+	mu        sync.Mutex // 减少同一实例内的seq冲突
+	logger    *zap.Logger
+}
+
+// NewBlacklistAuditor 创建黑名单变更审计
+func NewBlacklistAuditor(auditRepo *repository.BlacklistAuditRepository, logger *zap.Logger) *BlacklistAuditor {
+	return &BlacklistAuditor{
+		auditRepo: auditRepo,
+		logger:    logger,
+	}
+}
+
+// Record 追加一条变更审计记录
+func (a *BlacklistAuditor) Record(change BlacklistChange) error {
+	audit := newBlacklistAudit(change, time.Now())
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for attempt := 1; attempt <= auditAppendRetries; attempt++ {
+		last, err := a.auditRepo.FindLast()
+		if err != nil {
+			return err
+		}
+		audit.ID = 0
+		audit.Seq = 1
+		audit.PrevHash = ""
+		if last != nil {
+			audit.Seq = last.Seq + 1
+			audit.PrevHash = last.Hash
+		}
+		audit.Hash = audit.ComputeHash()
+
+		ok, err := a.auditRepo.Append(audit)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+		a.logger.Debug("审计记录序号已被占用，重试",
+			zap.Int64("seq", audit.Seq),
+			zap.Int("attempt", attempt))
+	}
+	return fmt.Errorf("写入审计记录失败: 重试%d次后链尾仍被占用", auditAppendRetries)
+}
+
+// History 查询买家的全部变更审计记录（按时间正序）
+func (a *BlacklistAuditor) History(alipayUserID string) ([]*model.BlacklistAudit, error) {
+	return a.auditRepo.FindByAlipayUserID(alipayUserID)
+}
+
+// BlacklistAuditProblem 哈希链校验发现的问题
+type BlacklistAuditProblem struct {
+	Seq     int64  `json:"seq"`
+	ID      uint   `json:"id"`
+	Problem string `json:"problem"`
+}
+
+// BlacklistAuditVerifyResult 哈希链校验结果
+// 链尾被整体截断无法从链本身发现，需定期把 LastSeq/LastHash 记录到库外比对
+type BlacklistAuditVerifyResult struct {
+	Checked  int64                   `json:"checked"`   // 校验的记录数
+	LastSeq  int64                   `json:"last_seq"`  // 链尾序号
+	LastHash string                  `json:"last_hash"` // 链尾Hash
+	Problems []BlacklistAuditProblem `json:"problems,omitempty"`
+}
+
+// OK 是否未发现问题
+func (r *BlacklistAuditVerifyResult) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// Verify 从第一条记录开始逐条校验哈希链
+func (a *BlacklistAuditor) Verify(batchSize int) (*BlacklistAuditVerifyResult, error) {
+	result := &BlacklistAuditVerifyResult{}
+	var prev *model.BlacklistAudit
+	var afterSeq int64
+
+	for {
+		batch, err := a.auditRepo.FindBatchAfterSeq(afterSeq, batchSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, audit := range batch {
+			if problem := audit.VerifyLink(prev); problem != "" && len(result.Problems) < auditMaxProblems {
+				result.Problems = append(result.Problems, BlacklistAuditProblem{Seq: audit.Seq, ID: audit.ID, Problem: problem})
+			}
+			// 出现问题后以当前记录为新的起点继续校验，便于一次找出所有断点
+			prev = audit
+			result.Checked++
+		}
+		if len(batch) < batchSize {
+			break
+		}
+		afterSeq = batch[len(batch)-1].Seq
+	}
+
+	if prev != nil {
+		result.LastSeq = prev.Seq
+		result.LastHash = prev.Hash
+	}
+	return result, nil
+}
+
+// newBlacklistAudit 根据变更构建审计记录（seq、prev_hash、hash 在追加时填写）
+func newBlacklistAudit(change BlacklistChange, now time.Time) *model.BlacklistAudit {
+	audit := &model.BlacklistAudit{
+		Action:      change.Action,
+		ActorType:   change.Actor.Type,
+		Actor:       truncateRunes(change.Actor.Name, 128),
+		ComplaintNo: change.ComplaintNo,
+		Before:      blacklistAuditValue(change.Before),
+		After:       blacklistAuditValue(change.After),
+		Reason:      truncateRunes(change.Reason, 255),
+		CreatedAt:   now.Truncate(time.Second), // 与数据库datetime精度一致，保证Hash可复算
+	}
+
+	entry := change.After
+	if entry == nil {
+		entry = change.Before
+	}
+	if entry != nil {
+		audit.BlacklistID = entry.ID
+		audit.AlipayUserID = entry.AlipayUserID
+		audit.DeviceCode = stringValue(entry.DeviceCode)
+		audit.IPAddress = stringValue(entry.IPAddress)
+	}
+	return audit
+}
+
+// blacklistAuditValue 黑名单记录的取值快照（JSON，记录为nil时返回空字符串）
+func blacklistAuditValue(entry *model.AlipayBlacklist) string {
+	if entry == nil {
+		return ""
+	}
+	snapshot := blacklistAuditSnapshot{
+		RiskCount: entry.RiskCount,
+		RiskLevel: entry.RiskLevel,
+		Remark:    entry.Remark,
+	}
+	if entry.LastRiskTime != nil {
+		snapshot.LastRiskTime = entry.LastRiskTime.Format(blacklistTimeLayout)
+	}
+	if entry.ExpireAt != nil {
+		snapshot.ExpireAt = entry.ExpireAt.Format(blacklistTimeLayout)
+	}
+	value, _ := json.Marshal(snapshot)
+	return string(value)
+}
+
+// truncateRunes 按字符截断字符串
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
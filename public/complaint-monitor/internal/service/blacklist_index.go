@@ -0,0 +1,494 @@
+package service
+
+import (
+	"context"
+	"net/netip"
+	"sync"
+	"time"
+
+	"complaint-monitor/internal/config"
+	"complaint-monitor/internal/iprange"
+	"complaint-monitor/internal/model"
+	"complaint-monitor/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// blacklistIndexBatchSize 加载和增量同步内存索引时每批查询的记录数
+	blacklistIndexBatchSize = 1000
+	// blacklistIndexSyncOverlap 增量同步时向前重叠的时间（容忍多实例间的时钟偏差，重复写入索引无副作用）
+	blacklistIndexSyncOverlap = 5 * time.Second
+)
+
+// BlacklistListener 黑名单变更监听
+// 本服务写入或删除黑名单后回调，用于同步内存索引等副本
+type BlacklistListener interface {
+	OnBlacklistSaved(entry *model.AlipayBlacklist)
+	OnBlacklistRemoved(entry *model.AlipayBlacklist)
+}
+
+// IPRangeListener 黑名单网段变更监听
+type IPRangeListener interface {
+	OnIPRangeSaved(ipRange *model.AlipayBlacklistIPRange)
+	OnIPRangeRemoved(ipRange *model.AlipayBlacklistIPRange)
+}
+
+// 黑名单命中字段
+const (
+	BlacklistFieldBuyerID    = "buyer_id"
+	BlacklistFieldIPAddress  = "ip"
+	BlacklistFieldIPRange    = "ip_range"
+	BlacklistFieldDeviceCode = "device_code"
+)
+
+// BlacklistMatch 黑名单命中项
+type BlacklistMatch struct {
+	Field       string    `json:"field"`          // 命中字段（buyer_id/ip/ip_range/device_code）
+	BlacklistID uint      `json:"blacklist_id"`   // 命中网段时为网段ID
+	CIDR        string    `json:"cidr,omitempty"` // 命中的网段
+	RiskLevel   RiskLevel `json:"risk_level"`
+}
+
+// BlacklistCheckResult 黑名单查询结果
+type BlacklistCheckResult struct {
+	Blocked   bool             `json:"blocked"`
+	RiskLevel RiskLevel        `json:"risk_level,omitempty"` // 命中记录中的最高风险等级
+	Matches   []BlacklistMatch `json:"matches"`
+}
+
+// indexedBlacklist 内存索引中的黑名单记录
+type indexedBlacklist struct {
+	id           uint
+	alipayUserID string
+	deviceCode   string
+	ipAddress    string
+	riskLevel    RiskLevel
+	expireAt     *time.Time
+}
+
+// indexedIPRange 内存索引中的黑名单网段
+type indexedIPRange struct {
+	id        uint
+	prefix    netip.Prefix
+	riskLevel RiskLevel
+	expireAt  *time.Time
+}
+
+// blacklistKeys 按字段值索引的黑名单ID集合
+type blacklistKeys map[string]map[uint]struct{}
+
+func (k blacklistKeys) add(value string, id uint) {
+	if value == "" {
+		return
+	}
+	ids, ok := k[value]
+	if !ok {
+		ids = make(map[uint]struct{})
+		k[value] = ids
+	}
+	ids[id] = struct{}{}
+}
+
+func (k blacklistKeys) remove(value string, id uint) {
+	if ids, ok := k[value]; ok {
+		delete(ids, id)
+		if len(ids) == 0 {
+			delete(k, value)
+		}
+	}
+}
+
+// BlacklistIndex 黑名单内存索引
+// 启动时从 alipay_blacklist 全量加载，本服务的写入通过 BlacklistListener 实时同步，
+// 其他实例和PHP侧的写入通过按更新时间增量同步，删除通过定期全量重建同步；
+// 黑名单网段（alipay_blacklist_ip_range）数量少，每次同步时全量加载到基数树
+type BlacklistIndex struct {
+	blacklistRepo *repository.BlacklistRepository
+	rangeRepo     *repository.BlacklistIPRangeRepository
+	cfg           config.BlacklistConfig
+	logger        *zap.Logger
+
+	mu        sync.RWMutex
+	entries   map[uint]*indexedBlacklist
+	byUser    blacklistKeys
+	byIP      blacklistKeys
+	byDevice  blacklistKeys
+	subnetIPs map[netip.Prefix]map[string]int // 自动升级网段内的黑名单IP（IP -> 记录数）
+	ranges    *iprange.Tree[*indexedIPRange]
+	rangeByID map[uint]netip.Prefix
+	syncedAt  time.Time // 已同步的最大更新时间
+}
+
+// NewBlacklistIndex 创建黑名单内存索引
+func NewBlacklistIndex(blacklistRepo *repository.BlacklistRepository, rangeRepo *repository.BlacklistIPRangeRepository, cfg config.BlacklistConfig, logger *zap.Logger) *BlacklistIndex {
+	return &BlacklistIndex{
+		blacklistRepo: blacklistRepo,
+		rangeRepo:     rangeRepo,
+		cfg:           cfg,
+		logger:        logger,
+		entries:       make(map[uint]*indexedBlacklist),
+		byUser:        make(blacklistKeys),
+		byIP:          make(blacklistKeys),
+		byDevice:      make(blacklistKeys),
+		subnetIPs:     make(map[netip.Prefix]map[string]int),
+		ranges:        iprange.New[*indexedIPRange](),
+		rangeByID:     make(map[uint]netip.Prefix),
+	}
+}
+
+// Check 查询买家ID、IP、设备码是否命中黑名单（任一命中即拦截，已过期的记录不命中）
+func (idx *BlacklistIndex) Check(buyerID, ipAddress, deviceCode string, now time.Time) BlacklistCheckResult {
+	result := BlacklistCheckResult{Matches: []BlacklistMatch{}}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	collect := func(field, value string, keys blacklistKeys) {
+		if value == "" {
+			return
+		}
+		for id := range keys[value] {
+			entry := idx.entries[id]
+			if entry.expireAt != nil && !entry.expireAt.After(now) {
+				continue
+			}
+			result.Matches = append(result.Matches, BlacklistMatch{
+				Field:       field,
+				BlacklistID: entry.id,
+				RiskLevel:   entry.riskLevel,
+			})
+			result.RiskLevel = MaxRiskLevel(result.RiskLevel, entry.riskLevel)
+		}
+	}
+
+	collect(BlacklistFieldBuyerID, buyerID, idx.byUser)
+	collect(BlacklistFieldIPAddress, ipAddress, idx.byIP)
+	collect(BlacklistFieldDeviceCode, model.NormalizeDeviceCode(deviceCode), idx.byDevice)
+
+	if addr, err := netip.ParseAddr(ipAddress); err == nil {
+		for _, ipRange := range idx.ranges.Lookup(addr) {
+			if ipRange.expireAt != nil && !ipRange.expireAt.After(now) {
+				continue
+			}
+			result.Matches = append(result.Matches, BlacklistMatch{
+				Field:       BlacklistFieldIPRange,
+				BlacklistID: ipRange.id,
+				CIDR:        ipRange.prefix.String(),
+				RiskLevel:   ipRange.riskLevel,
+			})
+			result.RiskLevel = MaxRiskLevel(result.RiskLevel, ipRange.riskLevel)
+		}
+	}
+
+	result.Blocked = len(result.Matches) > 0
+	return result
+}
+
+// Size 索引中的黑名单记录数
+func (idx *BlacklistIndex) Size() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.entries)
+}
+
+// SubnetIPCount 查询IP所在的自动升级网段及网段内的黑名单IP数（IP无效或未配置网段长度时返回false）
+func (idx *BlacklistIndex) SubnetIPCount(ipAddress string) (netip.Prefix, int, bool) {
+	subnet, ok := idx.subnetOf(ipAddress)
+	if !ok {
+		return netip.Prefix{}, 0, false
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return subnet, len(idx.subnetIPs[subnet]), true
+}
+
+// RangeCovers IP是否已被未过期的黑名单网段覆盖
+func (idx *BlacklistIndex) RangeCovers(ipAddress string, now time.Time) bool {
+	addr, err := netip.ParseAddr(ipAddress)
+	if err != nil {
+		return false
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	for _, ipRange := range idx.ranges.Lookup(addr) {
+		if ipRange.expireAt == nil || ipRange.expireAt.After(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// subnetOf IP所在的自动升级网段
+func (idx *BlacklistIndex) subnetOf(ipAddress string) (netip.Prefix, bool) {
+	if ipAddress == "" {
+		return netip.Prefix{}, false
+	}
+	addr, err := netip.ParseAddr(ipAddress)
+	if err != nil {
+		return netip.Prefix{}, false
+	}
+	addr = addr.Unmap()
+
+	bits := idx.cfg.RangePromotePrefixV6
+	if addr.Is4() {
+		bits = idx.cfg.RangePromotePrefixV4
+	}
+	if bits <= 0 || bits > addr.BitLen() {
+		return netip.Prefix{}, false
+	}
+	subnet, err := addr.Prefix(bits)
+	if err != nil {
+		return netip.Prefix{}, false
+	}
+	return subnet, true
+}
+
+// OnBlacklistSaved 黑名单新增或更新（实现 BlacklistListener）
+func (idx *BlacklistIndex) OnBlacklistSaved(entry *model.AlipayBlacklist) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.upsertLocked(entry)
+}
+
+// OnBlacklistRemoved 黑名单删除（实现 BlacklistListener）
+func (idx *BlacklistIndex) OnBlacklistRemoved(entry *model.AlipayBlacklist) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.removeLocked(entry.ID)
+}
+
+// upsertLocked 写入索引（调用方持有写锁）
+func (idx *BlacklistIndex) upsertLocked(entry *model.AlipayBlacklist) {
+	idx.removeLocked(entry.ID)
+
+	indexed := &indexedBlacklist{
+		id:           entry.ID,
+		alipayUserID: entry.AlipayUserID,
+		riskLevel:    RiskLevel(entry.RiskLevel),
+		expireAt:     entry.ExpireAt,
+	}
+	if entry.DeviceCode != nil {
+		indexed.deviceCode = *entry.DeviceCode
+	}
+	if entry.IPAddress != nil {
+		indexed.ipAddress = *entry.IPAddress
+	}
+
+	idx.entries[entry.ID] = indexed
+	idx.byUser.add(indexed.alipayUserID, entry.ID)
+	idx.byIP.add(indexed.ipAddress, entry.ID)
+	idx.byDevice.add(indexed.deviceCode, entry.ID)
+
+	if subnet, ok := idx.subnetOf(indexed.ipAddress); ok {
+		ips, exists := idx.subnetIPs[subnet]
+		if !exists {
+			ips = make(map[string]int)
+			idx.subnetIPs[subnet] = ips
+		}
+		ips[indexed.ipAddress]++
+	}
+}
+
+// advanceSyncedLocked 推进增量同步位置（仅由数据库同步推进，监听回调不推进，避免跳过其他实例更早的写入）
+func (idx *BlacklistIndex) advanceSyncedLocked(entry *model.AlipayBlacklist) {
+	if entry.UpdatedAt.After(idx.syncedAt) {
+		idx.syncedAt = entry.UpdatedAt
+	}
+}
+
+// removeLocked 从索引删除（调用方持有写锁）
+func (idx *BlacklistIndex) removeLocked(id uint) {
+	indexed, ok := idx.entries[id]
+	if !ok {
+		return
+	}
+	delete(idx.entries, id)
+	idx.byUser.remove(indexed.alipayUserID, id)
+	idx.byIP.remove(indexed.ipAddress, id)
+	idx.byDevice.remove(indexed.deviceCode, id)
+
+	if subnet, ok := idx.subnetOf(indexed.ipAddress); ok {
+		if ips, exists := idx.subnetIPs[subnet]; exists {
+			ips[indexed.ipAddress]--
+			if ips[indexed.ipAddress] <= 0 {
+				delete(ips, indexed.ipAddress)
+			}
+			if len(ips) == 0 {
+				delete(idx.subnetIPs, subnet)
+			}
+		}
+	}
+}
+
+// OnIPRangeSaved 黑名单网段新增或更新（实现 IPRangeListener）
+func (idx *BlacklistIndex) OnIPRangeSaved(ipRange *model.AlipayBlacklistIPRange) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.upsertRangeLocked(ipRange)
+}
+
+// OnIPRangeRemoved 黑名单网段删除（实现 IPRangeListener）
+func (idx *BlacklistIndex) OnIPRangeRemoved(ipRange *model.AlipayBlacklistIPRange) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.removeRangeLocked(ipRange.ID)
+}
+
+// upsertRangeLocked 写入网段（调用方持有写锁，网段格式无效时忽略）
+func (idx *BlacklistIndex) upsertRangeLocked(ipRange *model.AlipayBlacklistIPRange) {
+	prefix, err := iprange.ParsePrefix(ipRange.CIDR)
+	if err != nil {
+		idx.logger.Warn("黑名单网段格式无效，忽略",
+			zap.Uint("range_id", ipRange.ID),
+			zap.String("cidr", ipRange.CIDR))
+		return
+	}
+
+	idx.removeRangeLocked(ipRange.ID)
+	idx.ranges.Insert(prefix, &indexedIPRange{
+		id:        ipRange.ID,
+		prefix:    prefix,
+		riskLevel: RiskLevel(ipRange.RiskLevel),
+		expireAt:  ipRange.ExpireAt,
+	})
+	idx.rangeByID[ipRange.ID] = prefix
+}
+
+// removeRangeLocked 删除网段（调用方持有写锁）
+func (idx *BlacklistIndex) removeRangeLocked(id uint) {
+	prefix, ok := idx.rangeByID[id]
+	if !ok {
+		return
+	}
+	delete(idx.rangeByID, id)
+	idx.ranges.Delete(prefix)
+}
+
+// ReloadRanges 从数据库全量加载黑名单网段（整体替换）
+func (idx *BlacklistIndex) ReloadRanges() error {
+	if idx.rangeRepo == nil {
+		return nil
+	}
+	ranges, err := idx.rangeRepo.FindAll()
+	if err != nil {
+		return err
+	}
+
+	rebuilt := NewBlacklistIndex(nil, nil, idx.cfg, idx.logger)
+	for _, ipRange := range ranges {
+		rebuilt.upsertRangeLocked(ipRange)
+	}
+
+	idx.mu.Lock()
+	idx.ranges = rebuilt.ranges
+	idx.rangeByID = rebuilt.rangeByID
+	idx.mu.Unlock()
+	return nil
+}
+
+// Reload 从数据库全量重建索引（重建完成后整体替换，查询不受影响）
+func (idx *BlacklistIndex) Reload() error {
+	startTime := time.Now()
+	rebuilt := NewBlacklistIndex(idx.blacklistRepo, nil, idx.cfg, idx.logger)
+
+	var afterID uint
+	for {
+		batch, err := idx.blacklistRepo.FindBatchAfterID(afterID, blacklistIndexBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, entry := range batch {
+			rebuilt.upsertLocked(entry)
+			rebuilt.advanceSyncedLocked(entry)
+			afterID = entry.ID
+		}
+		if len(batch) < blacklistIndexBatchSize {
+			break
+		}
+	}
+
+	idx.mu.Lock()
+	idx.entries = rebuilt.entries
+	idx.byUser = rebuilt.byUser
+	idx.byIP = rebuilt.byIP
+	idx.byDevice = rebuilt.byDevice
+	idx.subnetIPs = rebuilt.subnetIPs
+	idx.syncedAt = rebuilt.syncedAt
+	idx.mu.Unlock()
+
+	if err := idx.ReloadRanges(); err != nil {
+		return err
+	}
+
+	idx.logger.Info("黑名单内存索引已重建",
+		zap.Int("size", len(rebuilt.entries)),
+		zap.Int("ranges", idx.RangeCount()),
+		zap.Duration("duration", time.Since(startTime)))
+
+	return nil
+}
+
+// RangeCount 索引中的黑名单网段数
+func (idx *BlacklistIndex) RangeCount() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.ranges.Len()
+}
+
+// SyncUpdated 增量同步更新时间不早于上次同步位置的记录，并重新加载黑名单网段
+func (idx *BlacklistIndex) SyncUpdated() error {
+	if err := idx.ReloadRanges(); err != nil {
+		return err
+	}
+
+	idx.mu.RLock()
+	since := idx.syncedAt.Add(-blacklistIndexSyncOverlap)
+	idx.mu.RUnlock()
+
+	for {
+		batch, err := idx.blacklistRepo.FindUpdatedSince(since, blacklistIndexBatchSize)
+		if err != nil {
+			return err
+		}
+
+		idx.mu.Lock()
+		for _, entry := range batch {
+			idx.upsertLocked(entry)
+			idx.advanceSyncedLocked(entry)
+		}
+		idx.mu.Unlock()
+
+		// 未取满或同一更新时间的记录超过一批时停止，避免原地循环
+		if len(batch) < blacklistIndexBatchSize || !batch[len(batch)-1].UpdatedAt.After(since) {
+			return nil
+		}
+		since = batch[len(batch)-1].UpdatedAt
+	}
+}
+
+// Run 定期增量同步和全量重建索引（阻塞直到上下文取消，调用前需先 Reload 完成加载）
+func (idx *BlacklistIndex) Run(ctx context.Context) {
+	syncTicker := time.NewTicker(idx.cfg.GetIndexSyncInterval())
+	defer syncTicker.Stop()
+	reloadTicker := time.NewTicker(idx.cfg.GetIndexReloadInterval())
+	defer reloadTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			idx.logger.Info("黑名单内存索引同步已停止")
+			return
+		case <-syncTicker.C:
+			if err := idx.SyncUpdated(); err != nil {
+				idx.logger.Error("增量同步黑名单内存索引失败", zap.Error(err))
+			}
+		case <-reloadTicker.C:
+			if err := idx.Reload(); err != nil {
+				idx.logger.Error("重建黑名单内存索引失败", zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"complaint-monitor/internal/config"
+	"complaint-monitor/internal/model"
+
+	"go.uber.org/zap"
+)
+
+func TestBlacklistIndexCheck(t *testing.T) {
+	idx := NewBlacklistIndex(nil, nil, config.BlacklistConfig{}, zap.NewNop())
+	now := time.Now()
+	device := "device-1"
+	ip := "1.2.3.4"
+	expired := now.Add(-time.Minute)
+
+	idx.OnBlacklistSaved(&model.AlipayBlacklist{ID: 1, AlipayUserID: "2088001", RiskLevel: "low"})
+	idx.OnBlacklistSaved(&model.AlipayBlacklist{ID: 2, AlipayUserID: "2088002", DeviceCode: &device, IPAddress: &ip, RiskLevel: "high"})
+	idx.OnBlacklistSaved(&model.AlipayBlacklist{ID: 3, AlipayUserID: "2088003", RiskLevel: "critical", ExpireAt: &expired})
+
+	if got := idx.Check("2088001", "", "", now); !got.Blocked || got.RiskLevel != RiskLevelLow {
+		t.Errorf("买家ID命中: %+v", got)
+	}
+	if got := idx.Check("2088999", ip, "", now); !got.Blocked || got.RiskLevel != RiskLevelHigh || got.Matches[0].Field != BlacklistFieldIPAddress {
+		t.Errorf("IP命中: %+v", got)
+	}
+	if got := idx.Check("2088001", "", device, now); len(got.Matches) != 2 || got.RiskLevel != RiskLevelHigh {
+		t.Errorf("多字段命中应取最高风险等级: %+v", got)
+	}
+	if got := idx.Check("2088003", "", "", now); got.Blocked {
+		t.Errorf("已过期记录不应命中: %+v", got)
+	}
+
+	// 更新后旧的IP不再命中
+	newIP := "5.6.7.8"
+	idx.OnBlacklistSaved(&model.AlipayBlacklist{ID: 2, AlipayUserID: "2088002", IPAddress: &newIP, RiskLevel: "high"})
+	if got := idx.Check("", ip, "", now); got.Blocked {
+		t.Errorf("更新后旧IP不应命中: %+v", got)
+	}
+
+	idx.OnBlacklistRemoved(&model.AlipayBlacklist{ID: 1})
+	if got := idx.Check("2088001", "", "", now); got.Blocked {
+		t.Errorf("删除后不应命中: %+v", got)
+	}
+	if idx.Size() != 2 {
+		t.Errorf("Size() = %d, 期望 2", idx.Size())
+	}
+}
+
+func TestBlacklistIndexIPRange(t *testing.T) {
+	idx := NewBlacklistIndex(nil, nil, config.BlacklistConfig{RangePromotePrefixV4: 24, RangePromotePrefixV6: 64}, zap.NewNop())
+	now := time.Now()
+	expired := now.Add(-time.Minute)
+
+	idx.OnIPRangeSaved(&model.AlipayBlacklistIPRange{ID: 1, CIDR: "10.1.2.0/24", RiskLevel: "medium"})
+	idx.OnIPRangeSaved(&model.AlipayBlacklistIPRange{ID: 2, CIDR: "2001:db8::/48", RiskLevel: "high"})
+	idx.OnIPRangeSaved(&model.AlipayBlacklistIPRange{ID: 3, CIDR: "172.16.0.0/16", RiskLevel: "high", ExpireAt: &expired})
+
+	if got := idx.Check("", "10.1.2.99", "", now); !got.Blocked || got.Matches[0].Field != BlacklistFieldIPRange || got.Matches[0].CIDR != "10.1.2.0/24" {
+		t.Errorf("IPv4网段命中: %+v", got)
+	}
+	if got := idx.Check("", "2001:db8:0:1::5", "", now); !got.Blocked || got.RiskLevel != RiskLevelHigh {
+		t.Errorf("IPv6网段命中: %+v", got)
+	}
+	if got := idx.Check("", "172.16.1.1", "", now); got.Blocked {
+		t.Errorf("已过期网段不应命中: %+v", got)
+	}
+
+	idx.OnIPRangeRemoved(&model.AlipayBlacklistIPRange{ID: 1})
+	if idx.RangeCovers("10.1.2.99", now) {
+		t.Error("删除后网段不应命中")
+	}
+
+	// 同一网段内不同IP计数，同一IP的多条记录只计一次
+	ips := []string{"192.168.5.1", "192.168.5.2", "192.168.5.2", "192.168.6.1"}
+	for i, ip := range ips {
+		idx.OnBlacklistSaved(&model.AlipayBlacklist{ID: uint(100 + i), AlipayUserID: "2088", IPAddress: &ip, RiskLevel: "low"})
+	}
+	if subnet, count, ok := idx.SubnetIPCount("192.168.5.200"); !ok || count != 2 || subnet.String() != "192.168.5.0/24" {
+		t.Errorf("SubnetIPCount = %v %d %v", subnet, count, ok)
+	}
+	idx.OnBlacklistRemoved(&model.AlipayBlacklist{ID: 101})
+	if _, count, _ := idx.SubnetIPCount("192.168.5.1"); count != 2 {
+		t.Errorf("删除重复IP的一条记录后计数 = %d, 期望 2", count)
+	}
+	idx.OnBlacklistRemoved(&model.AlipayBlacklist{ID: 102})
+	if _, count, _ := idx.SubnetIPCount("192.168.5.1"); count != 1 {
+		t.Errorf("删除后计数 = %d, 期望 1", count)
+	}
+}
@@ -0,0 +1,389 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"complaint-monitor/internal/config"
+	"complaint-monitor/internal/model"
+	"complaint-monitor/internal/repository"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// Redis黑名单镜像键（供PHP侧查询，字段为买家ID/IP/设备码/CIDR网段，值为命中记录中的最高风险等级）
+const (
+	BlacklistMirrorUserKey    = "blacklist:user"
+	BlacklistMirrorIPKey      = "blacklist:ip"
+	BlacklistMirrorIPRangeKey = "blacklist:ip_range"
+	BlacklistMirrorDeviceKey  = "blacklist:device"
+	BlacklistMirrorChannel    = "blacklist:changes" // 变更通知频道
+	blacklistMirrorOpTimeout  = 3 * time.Second     // 单次变更同步超时
+	blacklistMirrorPipeBatch  = 500                 // 全量重建时每批写入的字段数
+	blacklistMirrorTempSuffix = ":rebuild:"         // 全量重建临时键后缀
+)
+
+// 镜像变更动作
+const (
+	BlacklistMirrorActionSaved        = "saved"
+	BlacklistMirrorActionRemoved      = "removed"
+	BlacklistMirrorActionRangeSaved   = "range_saved"
+	BlacklistMirrorActionRangeRemoved = "range_removed"
+	BlacklistMirrorActionResync       = "resync"
+)
+
+// BlacklistChangeEvent 黑名单变更通知（发布到 blacklist:changes 频道）
+type BlacklistChangeEvent struct {
+	Action       string `json:"action"`                 // saved/removed/range_saved/range_removed/resync
+	BlacklistID  uint   `json:"blacklist_id,omitempty"` // 网段变更时为网段ID
+	CIDR         string `json:"cidr,omitempty"`
+	AlipayUserID string `json:"alipay_user_id,omitempty"`
+	IPAddress    string `json:"ip_address,omitempty"`
+	DeviceCode   string `json:"device_code,omitempty"`
+	RiskLevel    string `json:"risk_level,omitempty"`
+	Timestamp    int64  `json:"timestamp"`
+}
+
+// BlacklistMirror 黑名单Redis镜像
+// 本服务的写入和删除通过 BlacklistListener 实时同步（按字段值从数据库重新汇总，同一IP/设备码可能对应多条记录），
+// 其他实例和PHP侧的写入以及同步失败造成的漂移通过定期全量重建修复；
+// 黑名单网段通过 IPRangeListener 同步到 blacklist:ip_range（PHP侧需读取全部网段自行匹配）
+type BlacklistMirror struct {
+	redis         *redis.Client
+	blacklistRepo *repository.BlacklistRepository
+	rangeRepo     *repository.BlacklistIPRangeRepository
+	cfg           config.BlacklistConfig
+	logger        *zap.Logger
+}
+
+// NewBlacklistMirror 创建黑名单Redis镜像
+func NewBlacklistMirror(redisClient *redis.Client, blacklistRepo *repository.BlacklistRepository, rangeRepo *repository.BlacklistIPRangeRepository, cfg config.BlacklistConfig, logger *zap.Logger) *BlacklistMirror {
+	return &BlacklistMirror{
+		redis:         redisClient,
+		blacklistRepo: blacklistRepo,
+		rangeRepo:     rangeRepo,
+		cfg:           cfg,
+		logger:        logger,
+	}
+}
+
+// OnBlacklistSaved 黑名单新增或更新（实现 BlacklistListener）
+func (m *BlacklistMirror) OnBlacklistSaved(entry *model.AlipayBlacklist) {
+	m.sync(entry, BlacklistMirrorActionSaved)
+}
+
+// OnBlacklistRemoved 黑名单删除（实现 BlacklistListener）
+func (m *BlacklistMirror) OnBlacklistRemoved(entry *model.AlipayBlacklist) {
+	m.sync(entry, BlacklistMirrorActionRemoved)
+}
+
+// OnIPRangeSaved 黑名单网段新增或更新（实现 IPRangeListener）
+func (m *BlacklistMirror) OnIPRangeSaved(ipRange *model.AlipayBlacklistIPRange) {
+	m.syncRange(ipRange, BlacklistMirrorActionRangeSaved, mirrorRangeLevel(ipRange, time.Now()))
+}
+
+// OnIPRangeRemoved 黑名单网段删除（实现 IPRangeListener）
+func (m *BlacklistMirror) OnIPRangeRemoved(ipRange *model.AlipayBlacklistIPRange) {
+	m.syncRange(ipRange, BlacklistMirrorActionRangeRemoved, "")
+}
+
+// syncRange 写入网段字段并发布变更通知（失败只记录日志，由全量重建修复）
+func (m *BlacklistMirror) syncRange(ipRange *model.AlipayBlacklistIPRange, action string, level RiskLevel) {
+	ctx, cancel := context.WithTimeout(context.Background(), blacklistMirrorOpTimeout)
+	defer cancel()
+
+	if err := m.setField(ctx, BlacklistMirrorIPRangeKey, ipRange.CIDR, level); err != nil {
+		m.logger.Error("同步黑名单网段Redis镜像失败",
+			zap.Uint("range_id", ipRange.ID),
+			zap.String("action", action),
+			zap.Error(err))
+		return
+	}
+
+	m.publish(ctx, BlacklistChangeEvent{
+		Action:      action,
+		BlacklistID: ipRange.ID,
+		CIDR:        ipRange.CIDR,
+		RiskLevel:   ipRange.RiskLevel,
+		Timestamp:   time.Now().Unix(),
+	})
+}
+
+// sync 刷新记录涉及的镜像字段并发布变更通知（失败只记录日志，由全量重建修复）
+func (m *BlacklistMirror) sync(entry *model.AlipayBlacklist, action string) {
+	ctx, cancel := context.WithTimeout(context.Background(), blacklistMirrorOpTimeout)
+	defer cancel()
+
+	if err := m.refresh(ctx, entry); err != nil {
+		m.logger.Error("同步黑名单Redis镜像失败",
+			zap.Uint("blacklist_id", entry.ID),
+			zap.String("action", action),
+			zap.Error(err))
+		return
+	}
+
+	event := BlacklistChangeEvent{
+		Action:       action,
+		BlacklistID:  entry.ID,
+		AlipayUserID: entry.AlipayUserID,
+		IPAddress:    stringValue(entry.IPAddress),
+		DeviceCode:   stringValue(entry.DeviceCode),
+		RiskLevel:    entry.RiskLevel,
+		Timestamp:    time.Now().Unix(),
+	}
+	m.publish(ctx, event)
+}
+
+// refresh 按记录的买家ID、IP、设备码从数据库重新汇总并写入镜像
+func (m *BlacklistMirror) refresh(ctx context.Context, entry *model.AlipayBlacklist) error {
+	now := time.Now()
+
+	if entry.AlipayUserID != "" {
+		entries, err := m.blacklistRepo.FindAllByAlipayUserID(entry.AlipayUserID)
+		if err != nil {
+			return err
+		}
+		if err := m.setField(ctx, BlacklistMirrorUserKey, entry.AlipayUserID, mirrorRiskLevel(entries, now)); err != nil {
+			return err
+		}
+	}
+
+	if ip := stringValue(entry.IPAddress); ip != "" {
+		entries, err := m.blacklistRepo.FindByIPAddress(ip)
+		if err != nil {
+			return err
+		}
+		if err := m.setField(ctx, BlacklistMirrorIPKey, ip, mirrorRiskLevel(entries, now)); err != nil {
+			return err
+		}
+	}
+
+	if device := stringValue(entry.DeviceCode); device != "" {
+		entries, err := m.blacklistRepo.FindByDeviceCode(device)
+		if err != nil {
+			return err
+		}
+		if err := m.setField(ctx, BlacklistMirrorDeviceKey, device, mirrorRiskLevel(entries, now)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setField 写入镜像字段（风险等级为空表示已无有效记录，删除字段）
+func (m *BlacklistMirror) setField(ctx context.Context, key, field string, level RiskLevel) error {
+	var err error
+	if level == "" {
+		err = m.redis.HDel(ctx, key, field).Err()
+	} else {
+		err = m.redis.HSet(ctx, key, field, string(level)).Err()
+	}
+	if err != nil {
+		return fmt.Errorf("写入Redis黑名单镜像失败: %w", err)
+	}
+	return nil
+}
+
+// publish 发布变更通知（失败只记录日志）
+func (m *BlacklistMirror) publish(ctx context.Context, event BlacklistChangeEvent) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		m.logger.Error("序列化黑名单变更通知失败", zap.Error(err))
+		return
+	}
+	if err := m.redis.Publish(ctx, BlacklistMirrorChannel, payload).Err(); err != nil {
+		m.logger.Warn("发布黑名单变更通知失败",
+			zap.String("action", event.Action),
+			zap.Uint("blacklist_id", event.BlacklistID),
+			zap.Error(err))
+	}
+}
+
+// Resync 从数据库全量重建镜像
+// 先写入临时键再整体 RENAME 替换，重建期间PHP侧查询不受影响
+func (m *BlacklistMirror) Resync(ctx context.Context) error {
+	startTime := time.Now()
+	now := startTime
+
+	users := make(map[string]RiskLevel)
+	ips := make(map[string]RiskLevel)
+	devices := make(map[string]RiskLevel)
+
+	var afterID uint
+	total := 0
+	for {
+		batch, err := m.blacklistRepo.FindBatchAfterID(afterID, blacklistIndexBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, entry := range batch {
+			afterID = entry.ID
+			if isExpired(entry, now) {
+				continue
+			}
+			level := mirrorEntryLevel(entry)
+			mergeMirrorLevel(users, entry.AlipayUserID, level)
+			mergeMirrorLevel(ips, stringValue(entry.IPAddress), level)
+			mergeMirrorLevel(devices, stringValue(entry.DeviceCode), level)
+			total++
+		}
+		if len(batch) < blacklistIndexBatchSize {
+			break
+		}
+	}
+
+	ranges := make(map[string]RiskLevel)
+	if m.rangeRepo != nil {
+		ipRanges, err := m.rangeRepo.FindAll()
+		if err != nil {
+			return err
+		}
+		for _, ipRange := range ipRanges {
+			mergeMirrorLevel(ranges, ipRange.CIDR, mirrorRangeLevel(ipRange, now))
+		}
+	}
+
+	suffix := fmt.Sprintf("%s%d", blacklistMirrorTempSuffix, startTime.UnixNano())
+	for key, values := range map[string]map[string]RiskLevel{
+		BlacklistMirrorUserKey:    users,
+		BlacklistMirrorIPKey:      ips,
+		BlacklistMirrorIPRangeKey: ranges,
+		BlacklistMirrorDeviceKey:  devices,
+	} {
+		if err := m.replaceHash(ctx, key, key+suffix, values); err != nil {
+			return err
+		}
+	}
+
+	m.publish(ctx, BlacklistChangeEvent{Action: BlacklistMirrorActionResync, Timestamp: time.Now().Unix()})
+
+	m.logger.Info("黑名单Redis镜像已重建",
+		zap.Int("entries", total),
+		zap.Int("users", len(users)),
+		zap.Int("ips", len(ips)),
+		zap.Int("devices", len(devices)),
+		zap.Int("ranges", len(ranges)),
+		zap.Duration("duration", time.Since(startTime)))
+
+	return nil
+}
+
+// replaceHash 将字段写入临时键后替换目标键（无字段时直接删除目标键）
+func (m *BlacklistMirror) replaceHash(ctx context.Context, key, tempKey string, values map[string]RiskLevel) error {
+	if len(values) == 0 {
+		if err := m.redis.Del(ctx, key).Err(); err != nil {
+			return fmt.Errorf("清空Redis黑名单镜像失败: %w", err)
+		}
+		return nil
+	}
+
+	fields := make([]interface{}, 0, blacklistMirrorPipeBatch*2)
+	flush := func() error {
+		if len(fields) == 0 {
+			return nil
+		}
+		if err := m.redis.HSet(ctx, tempKey, fields...).Err(); err != nil {
+			m.redis.Del(ctx, tempKey)
+			return fmt.Errorf("写入Redis黑名单镜像临时键失败: %w", err)
+		}
+		fields = fields[:0]
+		return nil
+	}
+
+	for value, level := range values {
+		fields = append(fields, value, string(level))
+		if len(fields) >= blacklistMirrorPipeBatch*2 {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	if err := m.redis.Rename(ctx, tempKey, key).Err(); err != nil {
+		m.redis.Del(ctx, tempKey)
+		return fmt.Errorf("替换Redis黑名单镜像失败: %w", err)
+	}
+	return nil
+}
+
+// Run 定期全量重建镜像（阻塞直到上下文取消，启动时立即重建一次）
+func (m *BlacklistMirror) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.cfg.GetMirrorResyncInterval())
+	defer ticker.Stop()
+
+	m.logger.Info("黑名单Redis镜像同步已启动", zap.Duration("resync_interval", m.cfg.GetMirrorResyncInterval()))
+
+	for {
+		if err := m.Resync(ctx); err != nil {
+			m.logger.Error("重建黑名单Redis镜像失败", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			m.logger.Info("黑名单Redis镜像同步已停止")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// mirrorRiskLevel 汇总未过期记录中的最高风险等级（无有效记录时返回空）
+func mirrorRiskLevel(entries []*model.AlipayBlacklist, now time.Time) RiskLevel {
+	var level RiskLevel
+	for _, entry := range entries {
+		if isExpired(entry, now) {
+			continue
+		}
+		level = MaxRiskLevel(level, mirrorEntryLevel(entry))
+	}
+	return level
+}
+
+// mirrorEntryLevel 记录的镜像风险等级（历史记录或PHP侧写入的未知等级按低风险处理，保证仍然命中）
+func mirrorEntryLevel(entry *model.AlipayBlacklist) RiskLevel {
+	level := RiskLevel(entry.RiskLevel)
+	if riskRank(level) == 0 {
+		return RiskLevelLow
+	}
+	return level
+}
+
+// mirrorRangeLevel 网段的镜像风险等级（已过期返回空）
+func mirrorRangeLevel(ipRange *model.AlipayBlacklistIPRange, now time.Time) RiskLevel {
+	if ipRange.IsExpired(now) {
+		return ""
+	}
+	level := RiskLevel(ipRange.RiskLevel)
+	if riskRank(level) == 0 {
+		return RiskLevelLow
+	}
+	return level
+}
+
+// mergeMirrorLevel 合并字段值的风险等级（风险等级为空时忽略）
+func mergeMirrorLevel(values map[string]RiskLevel, value string, level RiskLevel) {
+	if value == "" || level == "" {
+		return
+	}
+	values[value] = MaxRiskLevel(values[value], level)
+}
+
+// isExpired 黑名单记录是否已过期
+func isExpired(entry *model.AlipayBlacklist, now time.Time) bool {
+	return entry.ExpireAt != nil && !entry.ExpireAt.After(now)
+}
+
+// stringValue 取字符串指针的值（nil返回空字符串）
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
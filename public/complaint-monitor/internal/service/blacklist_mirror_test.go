@@ -0,0 +1,32 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"complaint-monitor/internal/model"
+)
+
+func TestMirrorRiskLevel(t *testing.T) {
+	now := time.Now()
+	expired := now.Add(-time.Minute)
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		entries []*model.AlipayBlacklist
+		want    RiskLevel
+	}{
+		{"无记录删除字段", nil, ""},
+		{"取最高风险等级", []*model.AlipayBlacklist{{RiskLevel: "low"}, {RiskLevel: "high", ExpireAt: &future}}, RiskLevelHigh},
+		{"忽略已过期记录", []*model.AlipayBlacklist{{RiskLevel: "low"}, {RiskLevel: "critical", ExpireAt: &expired}}, RiskLevelLow},
+		{"全部过期删除字段", []*model.AlipayBlacklist{{RiskLevel: "high", ExpireAt: &expired}}, ""},
+		{"未知等级按低风险", []*model.AlipayBlacklist{{RiskLevel: ""}}, RiskLevelLow},
+	}
+
+	for _, tt := range tests {
+		if got := mirrorRiskLevel(tt.entries, now); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
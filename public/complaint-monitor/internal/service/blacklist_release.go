@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"complaint-monitor/internal/model"
+	"complaint-monitor/pkg/metrics"
+
+	"go.uber.org/zap"
+)
+
+// 黑名单解除原因
+const (
+	releaseReasonExpired   = "expired"   // 过期
+	releaseReasonWithdrawn = "withdrawn" // 用户撤诉
+	releaseReasonManual    = "manual"    // 管理接口手动删除
+)
+
+// expireAt 根据风险等级计算过期时间（nil表示永久有效）
+func (s *BlacklistService) expireAt(level RiskLevel, from time.Time) *time.Time {
+	ttl := s.cfg.GetExpireDuration(string(level))
+	if ttl <= 0 {
+		return nil
+	}
+	return timePtr(from.Add(ttl))
+}
+
+// expireByLevel 各风险等级从 from 起算的过期时间（写入时按合并后的风险等级取值）
+func (s *BlacklistService) expireByLevel(from time.Time) map[string]*time.Time {
+	levels := []RiskLevel{RiskLevelLow, RiskLevelMedium, RiskLevelHigh, RiskLevelCritical}
+	result := make(map[string]*time.Time, len(levels))
+	for _, level := range levels {
+		result[string(level)] = s.expireAt(level, from)
+	}
+	return result
+}
+
+// ReleaseOnWithdrawal 用户撤诉后撤销该投诉拉黑时新增或累加的黑名单记录（alipay_complaint_blacklist）
+// 同一买家由其他投诉、导入或关联分析写入的记录不受影响；买家仍有其他未结束投诉时保持不变，
+// 未结束投诉按投诉买家身份统计，任一买家统计失败时不做任何撤销
+// 风险计数大于1时减1，否则解除拉黑并推送通知；撤销后删除对应的投诉拉黑记录
+func (s *BlacklistService) ReleaseOnWithdrawal(complaint *model.Complaint) error {
+	links, err := s.complaintRepo.FindBlacklistLinks(complaint.ID)
+	if err != nil {
+		return err
+	}
+	if len(links) == 0 {
+		s.logger.Debug("撤诉投诉没有拉黑记录，无需解除",
+			zap.String("alipay_task_id", complaint.AlipayTaskId))
+		return nil
+	}
+
+	// 按买家分组（记录已被删除的关联直接清理）
+	linksByBuyer := make(map[string][]*model.ComplaintBlacklist)
+	buyerIDs := make([]string, 0)
+	for _, link := range links {
+		entry, err := s.blacklistRepo.FindByID(link.BlacklistID)
+		if err != nil {
+			return err
+		}
+		if entry == nil {
+			if err := s.complaintRepo.DeleteBlacklistLink(link.ID); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, exists := linksByBuyer[entry.AlipayUserID]; !exists {
+			buyerIDs = append(buyerIDs, entry.AlipayUserID)
+		}
+		linksByBuyer[entry.AlipayUserID] = append(linksByBuyer[entry.AlipayUserID], link)
+	}
+
+	// 先统计所有买家的未结束投诉，避免统计失败时只解除了部分买家
+	releasable := make([]string, 0, len(buyerIDs))
+	for _, buyerID := range buyerIDs {
+		openCount, err := s.complaintRepo.CountOpenByBuyer(buyerID, complaint.ID)
+		if err != nil {
+			return fmt.Errorf("统计买家未结束投诉失败: %w", err)
+		}
+		if openCount > 0 {
+			s.logger.Info("买家仍有其他未结束投诉，保持拉黑",
+				zap.String("alipay_task_id", complaint.AlipayTaskId),
+				zap.String("alipay_user_id", buyerID),
+				zap.Int64("open_count", openCount))
+			continue
+		}
+		releasable = append(releasable, buyerID)
+	}
+
+	for _, buyerID := range releasable {
+		buyerLinks := linksByBuyer[buyerID]
+		err := s.withBuyerLock(buyerID, func(fenceToken int64) error {
+			return s.releaseLinks(complaint, buyerLinks, fenceToken)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// releaseLinks 撤销投诉新增或累加的黑名单记录（调用方持有买家黑名单锁）
+func (s *BlacklistService) releaseLinks(complaint *model.Complaint, links []*model.ComplaintBlacklist, fenceToken int64) error {
+	for _, link := range links {
+		// 持锁后重新查询，以最新的风险计数为准
+		entry, err := s.blacklistRepo.FindByID(link.BlacklistID)
+		if err != nil {
+			return err
+		}
+		if entry == nil {
+			if err := s.complaintRepo.DeleteBlacklistLink(link.ID); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if entry.RiskCount > 1 {
+			if err := s.blacklistRepo.DecrementRiskCount(entry.ID, fenceToken); err != nil {
+				return err
+			}
+			if err := s.complaintRepo.DeleteBlacklistLink(link.ID); err != nil {
+				return err
+			}
+			before := *entry
+			entry.RiskCount--
+			s.notifySaved(entry)
+			s.audit(BlacklistChange{
+				Action:      model.BlacklistAuditDecrement,
+				Actor:       SystemActor(auditActorWithdrawal),
+				ComplaintNo: complaint.AlipayTaskId,
+				Reason:      "用户撤诉，降低风险计数",
+				Before:      &before,
+				After:       entry,
+			})
+			s.logger.Info("用户撤诉，降低黑名单风险计数",
+				zap.Uint("blacklist_id", entry.ID),
+				zap.String("alipay_user_id", entry.AlipayUserID),
+				zap.Int("risk_count", entry.RiskCount),
+				zap.String("alipay_task_id", complaint.AlipayTaskId))
+			continue
+		}
+
+		deleted, err := s.blacklistRepo.DeleteByID(entry.ID, fenceToken)
+		if err != nil {
+			return err
+		}
+		if err := s.complaintRepo.DeleteBlacklistLink(link.ID); err != nil {
+			return err
+		}
+		if deleted {
+			message := fmt.Sprintf("用户撤诉（投诉单号：%s），且无其他未结束投诉", complaint.AlipayTaskId)
+			s.audit(BlacklistChange{
+				Action:      model.BlacklistAuditUnblock,
+				Actor:       SystemActor(auditActorWithdrawal),
+				ComplaintNo: complaint.AlipayTaskId,
+				Reason:      message,
+				Before:      entry,
+			})
+			s.released(entry, releaseReasonWithdrawn, message)
+		}
+	}
+
+	return nil
+}
+
+// SweepExpired 清理已过期的黑名单记录，返回解除数量
+func (s *BlacklistService) SweepExpired(now time.Time) (int, error) {
+	entries, err := s.blacklistRepo.FindExpired(now, s.cfg.SweepBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	released := 0
+	for _, entry := range entries {
+		// 多实例同时清理或记录刚被再次触发时删除不到，跳过即可
+		deleted, err := s.blacklistRepo.DeleteExpired(entry.ID, now)
+		if err != nil {
+			return released, err
+		}
+		if !deleted {
+			continue
+		}
+		released++
+		message := fmt.Sprintf("%s风险黑名单已过期（过期时间：%s）", entry.RiskLevel, entry.ExpireAt.Format("2006-01-02 15:04:05"))
+		s.audit(BlacklistChange{
+			Action: model.BlacklistAuditUnblock,
+			Actor:  SystemActor(auditActorExpiry),
+			Reason: message,
+			Before: entry,
+		})
+		s.released(entry, releaseReasonExpired, message)
+	}
+
+	return released, nil
+}
+
+// RunExpirySweeper 定期清理过期黑名单（阻塞直到上下文取消）
+func (s *BlacklistService) RunExpirySweeper(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.GetSweepInterval())
+	defer ticker.Stop()
+
+	s.logger.Info("黑名单过期清理已启动", zap.Duration("interval", s.cfg.GetSweepInterval()))
+
+	for {
+		released, err := s.SweepExpired(time.Now())
+		if err != nil {
+			s.logger.Error("清理过期黑名单失败", zap.Error(err))
+		} else if released > 0 {
+			s.logger.Info("过期黑名单清理完成", zap.Int("released", released))
+		}
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info("黑名单过期清理已停止")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// released 记录黑名单解除并推送通知（通知失败不影响解除）
+func (s *BlacklistService) released(entry *model.AlipayBlacklist, reason, message string) {
+	metrics.RecordBlacklistRelease(reason)
+	s.notifyRemoved(entry)
+
+	s.logger.Info("黑名单已解除",
+		zap.Uint("blacklist_id", entry.ID),
+		zap.String("alipay_user_id", entry.AlipayUserID),
+		zap.String("risk_level", entry.RiskLevel),
+		zap.String("reason", reason),
+		zap.String("message", message))
+
+	if s.notificationService == nil {
+		return
+	}
+	if err := s.notificationService.PushBlacklistReleaseNotification(entry, message); err != nil {
+		s.logger.Error("写入黑名单解除通知失败",
+			zap.Uint("blacklist_id", entry.ID),
+			zap.Error(err))
+	}
+}
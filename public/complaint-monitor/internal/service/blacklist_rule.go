@@ -0,0 +1,159 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"complaint-monitor/internal/config"
+)
+
+// RuleInput 拉黑规则匹配依据
+type RuleInput struct {
+	SubjectID    int
+	AgentID      int
+	Status       string  // 投诉状态
+	Reason       string  // 投诉原因
+	Amount       float64 // 投诉涉及订单总金额
+	HistoryCount int64   // 买家历史投诉次数（含本次）
+}
+
+// RuleDecision 拉黑规则决策结果
+type RuleDecision struct {
+	Action      string // 决策动作（config.BlacklistActionXxx）
+	RuleName    string // 命中的规则名称（为空表示使用默认动作）
+	MatchDetail string // 命中的条件
+}
+
+// ShouldBlock 是否拉黑
+func (d RuleDecision) ShouldBlock() bool {
+	return d.Action == config.BlacklistActionBlock
+}
+
+// BlacklistRuleEngine 拉黑规则引擎
+// 按配置顺序匹配规则，第一条命中的规则决定动作；均未命中时使用默认动作
+type BlacklistRuleEngine struct {
+	cfg config.BlacklistRulesConfig
+}
+
+// NewBlacklistRuleEngine 创建拉黑规则引擎
+func NewBlacklistRuleEngine(cfg config.BlacklistRulesConfig) *BlacklistRuleEngine {
+	return &BlacklistRuleEngine{cfg: cfg}
+}
+
+// Evaluate 计算投诉的拉黑决策
+func (e *BlacklistRuleEngine) Evaluate(in RuleInput) RuleDecision {
+	for _, rule := range e.cfg.Rules {
+		if detail, ok := matchRule(rule, in); ok {
+			return RuleDecision{
+				Action:      rule.Action,
+				RuleName:    rule.Name,
+				MatchDetail: detail,
+			}
+		}
+	}
+
+	action := e.cfg.DefaultAction
+	if action == "" {
+		action = config.BlacklistActionBlock
+	}
+	return RuleDecision{
+		Action:      action,
+		MatchDetail: "未命中规则，使用默认动作",
+	}
+}
+
+// matchRule 判断规则是否命中，返回命中的条件描述
+// 规则内已配置的条件需全部满足，未配置任何条件的规则匹配所有投诉
+func matchRule(rule config.BlacklistRule, in RuleInput) (string, bool) {
+	details := make([]string, 0)
+
+	if len(rule.Statuses) > 0 {
+		if !containsString(rule.Statuses, in.Status) {
+			return "", false
+		}
+		details = append(details, "status="+in.Status)
+	}
+
+	if len(rule.ReasonKeywords) > 0 {
+		keyword, ok := matchKeyword(rule.ReasonKeywords, in.Reason)
+		if !ok {
+			return "", false
+		}
+		details = append(details, "reason包含"+keyword)
+	}
+
+	if rule.MinAmount > 0 {
+		if in.Amount < rule.MinAmount {
+			return "", false
+		}
+		details = append(details, fmt.Sprintf("amount=%.2f>=%.2f", in.Amount, rule.MinAmount))
+	}
+	if rule.MaxAmount > 0 {
+		if in.Amount > rule.MaxAmount {
+			return "", false
+		}
+		details = append(details, fmt.Sprintf("amount=%.2f<=%.2f", in.Amount, rule.MaxAmount))
+	}
+
+	if rule.MinHistory > 0 {
+		if in.HistoryCount < rule.MinHistory {
+			return "", false
+		}
+		details = append(details, fmt.Sprintf("history=%d>=%d", in.HistoryCount, rule.MinHistory))
+	}
+	if rule.MaxHistory > 0 {
+		if in.HistoryCount > rule.MaxHistory {
+			return "", false
+		}
+		details = append(details, fmt.Sprintf("history=%d<=%d", in.HistoryCount, rule.MaxHistory))
+	}
+
+	if len(rule.SubjectIDs) > 0 {
+		if !containsInt(rule.SubjectIDs, in.SubjectID) {
+			return "", false
+		}
+		details = append(details, fmt.Sprintf("subject_id=%d", in.SubjectID))
+	}
+	if len(rule.AgentIDs) > 0 {
+		if !containsInt(rule.AgentIDs, in.AgentID) {
+			return "", false
+		}
+		details = append(details, fmt.Sprintf("agent_id=%d", in.AgentID))
+	}
+
+	if len(details) == 0 {
+		return "无条件", true
+	}
+	return strings.Join(details, "; "), true
+}
+
+// matchKeyword 返回投诉原因中包含的第一个关键词（忽略大小写）
+func matchKeyword(keywords []string, reason string) (string, bool) {
+	lower := strings.ToLower(reason)
+	for _, keyword := range keywords {
+		if keyword != "" && strings.Contains(lower, strings.ToLower(keyword)) {
+			return keyword, true
+		}
+	}
+	return "", false
+}
+
+// containsString 切片中是否包含指定字符串
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
+
+// containsInt 切片中是否包含指定整数
+func containsInt(values []int, target int) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"testing"
+
+	"complaint-monitor/internal/config"
+)
+
+func TestBlacklistRuleEngineEvaluate(t *testing.T) {
+	engine := NewBlacklistRuleEngine(config.BlacklistRulesConfig{
+		DefaultAction: config.BlacklistActionBlock,
+		Rules: []config.BlacklistRule{
+			{Name: "drop", Action: config.BlacklistActionIgnore, Statuses: []string{"DROP_COMPLAIN"}},
+			{Name: "agent_allowlist", Action: config.BlacklistActionIgnore, AgentIDs: []int{1001}},
+			{Name: "first_not_received", Action: config.BlacklistActionReview, ReasonKeywords: []string{"未收到"}, MaxHistory: 1},
+			{Name: "small_amount", Action: config.BlacklistActionReview, MaxAmount: 10, SubjectIDs: []int{2}},
+		},
+	})
+
+	tests := []struct {
+		name     string
+		input    RuleInput
+		action   string
+		ruleName string
+	}{
+		{"撤诉不拉黑", RuleInput{Status: "DROP_COMPLAIN", HistoryCount: 3}, config.BlacklistActionIgnore, "drop"},
+		{"代理商白名单", RuleInput{Status: "WAIT_PROCESS", AgentID: 1001}, config.BlacklistActionIgnore, "agent_allowlist"},
+		{"首次未收到货", RuleInput{Status: "WAIT_PROCESS", Reason: "付款后未收到货", HistoryCount: 1}, config.BlacklistActionReview, "first_not_received"},
+		{"多次未收到货", RuleInput{Status: "WAIT_PROCESS", Reason: "付款后未收到货", HistoryCount: 2}, config.BlacklistActionBlock, ""},
+		{"指定主体小额", RuleInput{Status: "WAIT_PROCESS", SubjectID: 2, Amount: 9.9}, config.BlacklistActionReview, "small_amount"},
+		{"其他主体小额", RuleInput{Status: "WAIT_PROCESS", SubjectID: 3, Amount: 9.9}, config.BlacklistActionBlock, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := engine.Evaluate(tt.input)
+			if got.Action != tt.action || got.RuleName != tt.ruleName {
+				t.Errorf("Evaluate(%+v) = %s/%s, 期望 %s/%s", tt.input, got.Action, got.RuleName, tt.action, tt.ruleName)
+			}
+		})
+	}
+}
+
+func TestBlacklistRuleEngineDefaultAction(t *testing.T) {
+	// 未配置默认动作时保持所有投诉都拉黑
+	if got := NewBlacklistRuleEngine(config.BlacklistRulesConfig{}).Evaluate(RuleInput{}); !got.ShouldBlock() {
+		t.Errorf("未配置规则时应拉黑，实际动作 %s", got.Action)
+	}
+
+	engine := NewBlacklistRuleEngine(config.BlacklistRulesConfig{DefaultAction: config.BlacklistActionReview})
+	if got := engine.Evaluate(RuleInput{}); got.Action != config.BlacklistActionReview || got.RuleName != "" {
+		t.Errorf("默认动作 = %s/%s, 期望 review", got.Action, got.RuleName)
+	}
+}
@@ -1,11 +1,16 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"complaint-monitor/internal/config"
+	"complaint-monitor/internal/lock"
 	"complaint-monitor/internal/model"
 	"complaint-monitor/internal/repository"
+	"complaint-monitor/pkg/metrics"
 
 	"go.uber.org/zap"
 )
@@ -14,7 +19,15 @@ import (
 type BlacklistService struct {
 	blacklistRepo       *repository.BlacklistRepository
 	complaintRepo       *repository.ComplaintRepository
+	decisionRepo        *repository.BlacklistDecisionRepository
+	ruleEngine          *BlacklistRuleEngine
+	riskScorer          *RiskScorer
 	notificationService *NotificationService
+	auditor             *BlacklistAuditor
+	allowlist           *AllowlistService
+	lockManager         *lock.DistributedLock
+	cfg                 config.BlacklistConfig
+	listeners           []BlacklistListener
 	logger              *zap.Logger
 }
 
@@ -22,13 +35,27 @@ type BlacklistService struct {
 func NewBlacklistService(
 	blacklistRepo *repository.BlacklistRepository,
 	complaintRepo *repository.ComplaintRepository,
+	decisionRepo *repository.BlacklistDecisionRepository,
+	ruleEngine *BlacklistRuleEngine,
+	riskScorer *RiskScorer,
 	notificationService *NotificationService,
+	auditor *BlacklistAuditor,
+	allowlist *AllowlistService,
+	lockManager *lock.DistributedLock,
+	cfg config.BlacklistConfig,
 	logger *zap.Logger,
 ) *BlacklistService {
 	return &BlacklistService{
 		blacklistRepo:       blacklistRepo,
 		complaintRepo:       complaintRepo,
+		decisionRepo:        decisionRepo,
+		ruleEngine:          ruleEngine,
+		riskScorer:          riskScorer,
 		notificationService: notificationService,
+		auditor:             auditor,
+		allowlist:           allowlist,
+		lockManager:         lockManager,
+		cfg:                 cfg,
 		logger:              logger,
 	}
 }
@@ -38,85 +65,209 @@ type RiskLevel string
 
 const (
 	RiskLevelLow      RiskLevel = "low"      // 低风险：1次投诉
-	RiskLevelMedium   RiskLevel = "medium"   // 中风险：24h内2-3次或金额500-1000元
-	RiskLevelHigh     RiskLevel = "high"     // 高风险：24h内4+次或金额>1000元
+	RiskLevelMedium   RiskLevel = "medium"   // 中风险：24h内2-3次、2个主体或金额500-1000元
+	RiskLevelHigh     RiskLevel = "high"     // 高风险：24h内4+次、3+个主体或金额>1000元
 	RiskLevelCritical RiskLevel = "critical" // 极高风险：历史5+次或涉及10+订单
 )
 
-// AddToBlacklist 添加到黑名单（所有投诉都触发拉黑）
-// 注意：现有表结构使用 (alipay_user_id, device_code, ip_address) 作为唯一键
-// 根据购买者ID、设备码、IP判断是否已经拉黑过，防止重复拉黑
-// 仅首次拉黑时写入消息队列到 telegram_message_queue 表，重复触发不写入消息队列
-func (s *BlacklistService) AddToBlacklist(
-	subject *model.Subject, // 主体信息（用于消息通知）
-	alipayUserID string,
-	deviceCode string,
-	ipAddress string,
-	complaintNo string,
-) error {
-	subjectID := subject.ID
-	// 1. 先检查是否已经存在（根据购买者ID、设备码、IP）
-	exists, existingBlacklist, err := s.CheckBlacklistByUniqueKey(alipayUserID, deviceCode, ipAddress)
-	if err != nil {
-		s.logger.Error("检查黑名单是否存在失败",
-			zap.Int("subject_id", subjectID),
-			zap.String("alipay_user_id", alipayUserID),
-			zap.String("device_code", deviceCode),
-			zap.String("ip_address", ipAddress),
+// AddListener 注册黑名单变更监听（需在服务开始处理投诉前注册）
+func (s *BlacklistService) AddListener(listener BlacklistListener) {
+	s.listeners = append(s.listeners, listener)
+}
+
+// 买家黑名单锁
+// 同一买家的黑名单记录由多个投诉和关联分析共享，自动写入时持有按买家划分的锁，并携带该锁的fencing token
+const (
+	buyerLockKeyPrefix  = "blacklist:lock:"     // 买家黑名单锁键前缀
+	buyerLockAttempts   = 20                    // 锁被占用时的最大尝试次数
+	buyerLockRetryDelay = 50 * time.Millisecond // 锁被占用时的重试间隔
+)
+
+// withBuyerLock 持有买家黑名单锁执行 fn，fn 收到的 fenceToken 用于写入黑名单（拒绝锁过期后的延迟写入）
+// 持锁期间只执行少量数据库读写，锁被占用时短暂等待重试；未配置锁管理器时不加锁，fenceToken 为0（不校验）
+func (s *BlacklistService) withBuyerLock(buyerID string, fn func(fenceToken int64) error) error {
+	if s.lockManager == nil {
+		return fn(0)
+	}
+
+	key := buyerLockKeyPrefix + buyerID
+	for attempt := 1; ; attempt++ {
+		err := s.lockManager.WithLock(context.Background(), key, lock.Options{}, func(_ context.Context, fenceToken int64) error {
+			return fn(fenceToken)
+		})
+		if !errors.Is(err, lock.ErrNotAcquired) || attempt >= buyerLockAttempts {
+			return err
+		}
+		time.Sleep(buyerLockRetryDelay)
+	}
+}
+
+// notifySaved 通知监听者黑名单新增或更新
+func (s *BlacklistService) notifySaved(entry *model.AlipayBlacklist) {
+	for _, listener := range s.listeners {
+		listener.OnBlacklistSaved(entry)
+	}
+}
+
+// notifyRemoved 通知监听者黑名单删除
+func (s *BlacklistService) notifyRemoved(entry *model.AlipayBlacklist) {
+	for _, listener := range s.listeners {
+		listener.OnBlacklistRemoved(entry)
+	}
+}
+
+// audit 记录黑名单变更审计（写入失败不影响变更本身，只记录错误日志）
+func (s *BlacklistService) audit(change BlacklistChange) {
+	if s.auditor == nil {
+		return
+	}
+	if err := s.auditor.Record(change); err != nil {
+		s.logger.Error("写入黑名单审计记录失败",
+			zap.String("action", change.Action),
+			zap.String("actor", change.Actor.Name),
+			zap.String("complaint_no", change.ComplaintNo),
 			zap.Error(err))
-		return fmt.Errorf("检查黑名单是否存在失败: %w", err)
 	}
+}
 
-	// 2. 如果已存在，更新风险计数和最后风险时间
-	if exists && existingBlacklist != nil {
-		s.logger.Info("黑名单记录已存在，更新风险计数",
-			zap.Int("subject_id", subjectID),
-			zap.String("alipay_user_id", alipayUserID),
-			zap.String("device_code", deviceCode),
-			zap.String("ip_address", ipAddress),
-			zap.Int("current_risk_count", existingBlacklist.RiskCount),
-			zap.String("complaint_no", complaintNo))
+// DecideForComplaint 按拉黑规则决定新入库投诉是否拉黑，并记录决策及命中的规则
+// amount: 投诉涉及订单总金额；buyerIDs: 投诉解析出的买家身份（已排除主体PID）；决策记录写入失败不影响决策结果
+func (s *BlacklistService) DecideForComplaint(complaint *model.Complaint, amount float64, buyerIDs []string) RuleDecision {
+	input := RuleInput{
+		SubjectID: complaint.SubjectID,
+		AgentID:   complaint.AgentID,
+		Status:    complaint.ComplaintStatus,
+		Reason:    complaint.ComplaintReason,
+		Amount:    amount,
+	}
 
-		// 更新风险计数（累加）
-		err = s.IncrementRiskCount(alipayUserID, deviceCode, ipAddress)
+	// 买家历史投诉次数（所有主体，含本次；多个买家取最大值）
+	// 无法确定买家身份或查询失败时按0次匹配规则，不使用未经校验的投诉人字段
+	for _, buyerID := range buyerIDs {
+		count, err := s.complaintRepo.CountByBuyerSince(buyerID, nil)
 		if err != nil {
-			s.logger.Error("更新风险计数失败",
-				zap.Int("subject_id", subjectID),
-				zap.String("alipay_user_id", alipayUserID),
+			s.logger.Warn("查询买家历史投诉次数失败，按0次匹配规则",
+				zap.String("alipay_task_id", complaint.AlipayTaskId),
+				zap.String("buyer_id", buyerID),
 				zap.Error(err))
-			return fmt.Errorf("更新风险计数失败: %w", err)
+			continue
+		}
+		if count > input.HistoryCount {
+			input.HistoryCount = count
 		}
+	}
 
-		// 重复触发：只更新风险计数，不写入消息队列
-		s.logger.Info("黑名单记录已存在（重复触发），仅更新风险计数，不写入消息队列",
-			zap.Int("subject_id", subjectID),
-			zap.String("alipay_user_id", alipayUserID),
-			zap.String("device_code", deviceCode),
-			zap.String("ip_address", ipAddress),
-			zap.Int("current_risk_count", existingBlacklist.RiskCount),
-			zap.String("complaint_no", complaintNo))
+	decision := s.ruleEngine.Evaluate(input)
+	metrics.RecordBlacklistDecision(complaint.SubjectID, decision.Action, decision.RuleName)
+
+	s.logger.Info("拉黑规则决策",
+		zap.Int("subject_id", complaint.SubjectID),
+		zap.String("alipay_task_id", complaint.AlipayTaskId),
+		zap.String("complainant_id", complaint.ComplainantID),
+		zap.Strings("buyer_ids", buyerIDs),
+		zap.String("complaint_status", input.Status),
+		zap.Float64("amount", input.Amount),
+		zap.Int64("history_count", input.HistoryCount),
+		zap.String("action", decision.Action),
+		zap.String("rule_name", decision.RuleName),
+		zap.String("match_detail", decision.MatchDetail))
+
+	record := &model.BlacklistDecision{
+		SubjectID:       complaint.SubjectID,
+		AgentID:         complaint.AgentID,
+		AlipayTaskId:    complaint.AlipayTaskId,
+		ComplainantID:   complaint.ComplainantID,
+		ComplaintStatus: input.Status,
+		ComplaintAmount: input.Amount,
+		HistoryCount:    input.HistoryCount,
+		Action:          decision.Action,
+		RuleName:        decision.RuleName,
+		MatchDetail:     decision.MatchDetail,
+	}
+	if err := s.decisionRepo.Create(record); err != nil {
+		s.logger.Error("写入拉黑决策记录失败",
+			zap.String("alipay_task_id", complaint.AlipayTaskId),
+			zap.Error(err))
+	}
+
+	return decision
+}
 
+// ListReview 分页查询待人工审核的投诉（subjectID 为0时查询所有主体）
+func (s *BlacklistService) ListReview(subjectID, page, pageSize int) ([]*model.BlacklistDecision, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	return s.decisionRepo.FindByAction(subjectID, config.BlacklistActionReview, (page-1)*pageSize, pageSize)
+}
+
+// BlacklistRequest 拉黑请求
+type BlacklistRequest struct {
+	Subject         *model.Subject // 主体信息（用于消息通知）
+	AlipayUserID    string         // 购买者支付宝用户ID
+	DeviceCode      string         // 设备码（为空时存储NULL）
+	IPAddress       string         // IP地址（为空时存储NULL）
+	ComplaintID     uint           // 投诉主表ID（记录本次新增或累加的黑名单记录，撤诉时只撤销这些记录；为0时不记录）
+	ComplaintNo     string         // 投诉单号
+	RuleName        string         // 命中的拉黑规则名称（为空表示默认动作，记录到审计）
+	IdentitySource  string         // 买家身份来源（model.IdentitySourceXxx，新增时写入黑名单记录）
+	ComplaintAmount float64        // 本次投诉中该买家涉及的金额（用于风险评估）
+	OrderCount      int            // 本次投诉中该买家涉及的订单数（用于风险评估）
+}
+
+// AddToBlacklist 添加到黑名单（仅在拉黑规则决策为拉黑时由调用方调用，见 DecideForComplaint）
+// 按 (alipay_user_id, device_code, ip_address) 的唯一键哈希原子地新增或累加，多实例并发处理同一买家也不会重复新增
+// 根据买家投诉历史、金额和订单数评估风险等级，已拉黑的记录风险等级只升不降
+// 仅首次拉黑时写入消息队列到 telegram_message_queue 表，重复触发不写入消息队列
+// 买家ID、IP或设备码命中白名单时跳过拉黑（投诉已由调用方入库），记录日志、指标并推送通知
+func (s *BlacklistService) AddToBlacklist(req BlacklistRequest) error {
+	subject := req.Subject
+	subjectID := subject.ID
+	alipayUserID := req.AlipayUserID
+	deviceCode := req.DeviceCode
+	ipAddress := req.IPAddress
+	complaintNo := req.ComplaintNo
+
+	// 0. 先检查白名单（查询失败时不拉黑，避免误伤白名单买家）
+	allowlisted, err := s.checkAllowlist(alipayUserID, ipAddress, deviceCode)
+	if err != nil {
+		return fmt.Errorf("检查白名单失败: %w", err)
+	}
+	if allowlisted != nil {
+		s.skipAllowlisted(req, allowlisted)
 		return nil
 	}
 
-	// 3. 如果不存在，插入新记录
-	// 查询历史投诉次数（用于计算风险计数）
-	historyCount, err := s.complaintRepo.CountByComplainant(subjectID, alipayUserID)
+	// 1. 评估风险等级（评估失败时按低风险处理，不影响拉黑；已拉黑的记录在写入时只升不降）
+	riskLevel, riskInput, err := s.riskScorer.Score(alipayUserID, req.ComplaintAmount, req.OrderCount)
 	if err != nil {
-		s.logger.Error("查询历史投诉次数失败", zap.Error(err))
-		historyCount = 1 // 默认为1
+		s.logger.Warn("评估买家风险等级失败，按低风险处理",
+			zap.String("alipay_user_id", alipayUserID),
+			zap.Error(err))
+	}
+
+	// 2. 新增时使用跨主体历史投诉次数作为风险计数（已存在时累加1；画像查询失败时默认为1）
+	historyCount := int64(1)
+	if riskInput.Profile != nil && riskInput.Profile.TotalComplaints > 1 {
+		historyCount = riskInput.Profile.TotalComplaints
 	}
 
-	// 构建黑名单记录
-	// 注意：现有表结构没有 subject_id, blacklist_type, risk_level 字段
+	// 构建黑名单记录（过期时间按风险等级计算）
+	// 注意：现有表结构没有 subject_id, blacklist_type 字段
 	// 使用 risk_count 存储投诉次数，last_risk_time 存储最后投诉时间
-	// 唯一索引是 (alipay_user_id, device_code, ip_address)
-	// 如果 device_code 或 ip_address 为空，使用 NULL（通过指针类型实现）
+	// 如果 device_code 或 ip_address 为空，使用 NULL（通过指针类型实现），唯一键 unique_key 中按空字符串计算
+	now := time.Now()
 	blacklist := &model.AlipayBlacklist{
-		AlipayUserID: alipayUserID,
-		RiskCount:    int(historyCount), // 使用风险触发次数存储投诉次数
-		LastRiskTime: timePtr(time.Now()),
-		Remark:       fmt.Sprintf("投诉触发自动拉黑，投诉单号：%s", complaintNo),
+		AlipayUserID:   alipayUserID,
+		RiskCount:      int(historyCount), // 使用风险触发次数存储投诉次数
+		LastRiskTime:   timePtr(now),
+		ExpireAt:       s.expireAt(riskLevel, now),
+		Remark:         fmt.Sprintf("投诉触发自动拉黑，投诉单号：%s", complaintNo),
+		RiskLevel:      string(riskLevel),
+		IdentitySource: req.IdentitySource,
 	}
 
 	// 设置设备码（如果为空，使用nil，存储为NULL）
@@ -133,34 +284,119 @@ func (s *BlacklistService) AddToBlacklist(
 		blacklist.IPAddress = nil // 存储为NULL
 	}
 
-	// 插入新记录
-	if err := s.blacklistRepo.Create(blacklist); err != nil {
-		s.logger.Error("插入黑名单失败",
+	// 3. 持有买家黑名单锁写入
+	var (
+		existingBlacklist *model.AlipayBlacklist
+		saved             *model.AlipayBlacklist
+		inserted          bool
+	)
+	err = s.withBuyerLock(alipayUserID, func(fenceToken int64) error {
+		// 查询已有记录（用于日志和审计中的变更前取值；是否新增以原子写入的结果为准）
+		var err error
+		existingBlacklist, err = s.blacklistRepo.FindByUniqueKey(alipayUserID, deviceCode, ipAddress)
+		if err != nil {
+			return fmt.Errorf("检查黑名单是否存在失败: %w", err)
+		}
+
+		// 按唯一键原子新增或累加：并发实例处理同一买家时只有一个新增成功，新增通知只推送一次
+		inserted, err = s.blacklistRepo.UpsertRisk(blacklist, s.expireByLevel(now), fenceToken)
+		if err != nil {
+			return fmt.Errorf("写入黑名单失败: %w", err)
+		}
+
+		// 查询写入后的记录（查询失败时由内存索引的增量同步兜底，审计按本次写入的值记录）
+		saved, err = s.blacklistRepo.FindByUniqueKey(alipayUserID, deviceCode, ipAddress)
+		if err != nil {
+			s.logger.Warn("查询写入后的黑名单记录失败，撤诉时不会撤销本次写入",
+				zap.String("alipay_user_id", alipayUserID),
+				zap.String("complaint_no", complaintNo),
+				zap.Error(err))
+			saved = nil
+			return nil
+		}
+		if saved != nil {
+			s.recordComplaintLink(req, saved.ID, inserted)
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("写入黑名单失败",
 			zap.Int("subject_id", subjectID),
 			zap.String("alipay_user_id", alipayUserID),
 			zap.String("device_code", deviceCode),
 			zap.String("ip_address", ipAddress),
 			zap.Error(err))
-		return fmt.Errorf("插入黑名单失败: %w", err)
+		return err
+	}
+
+	// 同步给监听者
+	if saved != nil {
+		s.notifySaved(saved)
+	} else {
+		saved = blacklist
+		if !inserted && existingBlacklist != nil {
+			incremented := *existingBlacklist
+			incremented.RiskCount++
+			incremented.RiskLevel = string(MaxRiskLevel(RiskLevel(existingBlacklist.RiskLevel), riskLevel))
+			incremented.LastRiskTime = timePtr(now)
+			incremented.ExpireAt = s.expireAt(RiskLevel(incremented.RiskLevel), now)
+			saved = &incremented
+		}
+	}
+
+	// 4. 已存在：只累加风险计数，不写入消息队列
+	if !inserted {
+		s.audit(BlacklistChange{
+			Action:      model.BlacklistAuditIncrement,
+			Actor:       ruleActor(req.RuleName),
+			ComplaintNo: complaintNo,
+			Reason:      "投诉再次触发拉黑",
+			Before:      existingBlacklist,
+			After:       saved,
+		})
+
+		s.logger.Info("黑名单记录已存在（重复触发），仅更新风险计数，不写入消息队列",
+			zap.Int("subject_id", subjectID),
+			zap.String("alipay_user_id", alipayUserID),
+			zap.String("device_code", deviceCode),
+			zap.String("ip_address", ipAddress),
+			zap.Int("risk_count", saved.RiskCount),
+			zap.String("risk_level", saved.RiskLevel),
+			zap.String("complaint_no", complaintNo))
+
+		return nil
 	}
 
+	// 5. 新增：记录指标、审计并推送通知
+	metrics.RecordBlacklistAdd(subjectID, string(riskLevel))
+	s.audit(BlacklistChange{
+		Action:      model.BlacklistAuditInsert,
+		Actor:       ruleActor(req.RuleName),
+		ComplaintNo: complaintNo,
+		Reason:      "投诉触发自动拉黑",
+		After:       saved,
+	})
+
 	s.logger.Info("新增黑名单记录成功",
 		zap.Int("subject_id", subjectID),
 		zap.String("alipay_user_id", alipayUserID),
 		zap.String("device_code", deviceCode),
 		zap.String("ip_address", ipAddress),
-		zap.Int("risk_count", blacklist.RiskCount),
+		zap.Int("risk_count", saved.RiskCount),
+		zap.String("risk_level", saved.RiskLevel),
+		zap.String("identity_source", req.IdentitySource),
 		zap.Int64("history_count", historyCount),
 		zap.String("complaint_no", complaintNo))
 
-	// 写入消息队列（参考 PHP 实现）
+	// 写入消息队列（参考 PHP 实现），附带买家跨主体投诉画像
 	if s.notificationService != nil {
 		err = s.notificationService.PushBlacklistNotification(
-			blacklist,
+			saved,
 			subject,
 			complaintNo,
 			"insert",
 			"用户首次命中风险，已新增黑名单记录",
+			riskInput.Profile,
 		)
 		if err != nil {
 			s.logger.Error("写入消息队列失败",
@@ -174,6 +410,239 @@ func (s *BlacklistService) AddToBlacklist(
 	return nil
 }
 
+// recordComplaintLink 记录投诉新增或累加的黑名单记录（写入失败只记录日志，撤诉时不会撤销该记录）
+func (s *BlacklistService) recordComplaintLink(req BlacklistRequest, blacklistID uint, inserted bool) {
+	if req.ComplaintID == 0 {
+		return
+	}
+	action := model.BlacklistAuditIncrement
+	if inserted {
+		action = model.BlacklistAuditInsert
+	}
+	link := &model.ComplaintBlacklist{
+		ComplaintID:  req.ComplaintID,
+		SubjectID:    req.Subject.ID,
+		AlipayTaskId: req.ComplaintNo,
+		BlacklistID:  blacklistID,
+		Action:       action,
+	}
+	if err := s.complaintRepo.SaveBlacklistLink(link); err != nil {
+		s.logger.Error("记录投诉拉黑记录失败，撤诉时不会撤销本次写入",
+			zap.Uint("complaint_id", req.ComplaintID),
+			zap.Uint("blacklist_id", blacklistID),
+			zap.Error(err))
+	}
+}
+
+// checkAllowlist 查询买家命中的白名单（未配置白名单服务或未命中返回nil）
+func (s *BlacklistService) checkAllowlist(alipayUserID, ipAddress, deviceCode string) (*model.BuyerAllowlist, error) {
+	if s.allowlist == nil {
+		return nil, nil
+	}
+	return s.allowlist.Match(alipayUserID, ipAddress, deviceCode)
+}
+
+// skipAllowlisted 记录白名单买家跳过拉黑并推送通知（通知失败不影响主流程）
+func (s *BlacklistService) skipAllowlisted(req BlacklistRequest, allowlisted *model.BuyerAllowlist) {
+	metrics.RecordBlacklistAllowlisted(req.Subject.ID, allowlisted.MatchType)
+
+	s.logger.Info("买家命中白名单，跳过拉黑",
+		zap.Int("subject_id", req.Subject.ID),
+		zap.String("alipay_user_id", req.AlipayUserID),
+		zap.String("device_code", req.DeviceCode),
+		zap.String("ip_address", req.IPAddress),
+		zap.String("complaint_no", req.ComplaintNo),
+		zap.Uint("allowlist_id", allowlisted.ID),
+		zap.String("match_type", allowlisted.MatchType),
+		zap.String("reason", allowlisted.Reason))
+
+	if s.notificationService == nil {
+		return
+	}
+	err := s.notificationService.PushAllowlistSkipNotification(allowlisted, req.Subject, req.ComplaintNo, req.AlipayUserID, req.DeviceCode, req.IPAddress)
+	if err != nil {
+		s.logger.Error("写入白名单跳过拉黑通知失败",
+			zap.String("alipay_user_id", req.AlipayUserID),
+			zap.Error(err))
+	}
+}
+
+// blacklistRemarkMaxLen 黑名单备注最大长度（alipay_blacklist.remark varchar(255)）
+const blacklistRemarkMaxLen = 255
+
+// AddAssociated 拉黑关联团伙中的买家账号（只拉黑账号，不带IP和设备码，避免误伤共用网络的用户）
+// 命中白名单时不处理；账号记录已存在（如快照之后被其他实例拉黑）时只累加风险计数，不推送和审计；返回是否新增
+func (s *BlacklistService) AddAssociated(buyerID string, riskLevel RiskLevel, evidence string) (bool, error) {
+	allowlisted, err := s.checkAllowlist(buyerID, "", "")
+	if err != nil {
+		return false, err
+	}
+	if allowlisted != nil {
+		metrics.RecordBlacklistAllowlisted(0, allowlisted.MatchType)
+		s.logger.Info("关联账号命中白名单，跳过拉黑",
+			zap.String("alipay_user_id", buyerID),
+			zap.Uint("allowlist_id", allowlisted.ID),
+			zap.String("evidence", evidence))
+		return false, nil
+	}
+
+	remark := truncateRunes("关联账号自动拉黑："+evidence, blacklistRemarkMaxLen)
+
+	now := time.Now()
+	blacklist := &model.AlipayBlacklist{
+		AlipayUserID:   buyerID,
+		RiskCount:      1,
+		LastRiskTime:   timePtr(now),
+		ExpireAt:       s.expireAt(riskLevel, now),
+		Remark:         remark,
+		RiskLevel:      string(riskLevel),
+		IdentitySource: model.IdentitySourceBuyerGraph,
+	}
+
+	// 按唯一键原子新增：并发实例或其他写入方同时拉黑该账号时只有一个新增成功，其余累加风险计数
+	inserted := false
+	var saved *model.AlipayBlacklist
+	err = s.withBuyerLock(buyerID, func(fenceToken int64) error {
+		inserted, err = s.blacklistRepo.UpsertRisk(blacklist, s.expireByLevel(now), fenceToken)
+		if err != nil {
+			return fmt.Errorf("写入关联账号黑名单失败: %w", err)
+		}
+		if !inserted {
+			return nil
+		}
+
+		// 查询写入后的记录（查询失败时由内存索引的增量同步兜底，审计按本次写入的值记录）
+		saved, err = s.blacklistRepo.FindByUniqueKey(buyerID, "", "")
+		if err != nil {
+			s.logger.Warn("查询写入后的关联账号黑名单记录失败",
+				zap.String("alipay_user_id", buyerID),
+				zap.Error(err))
+			saved = nil
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	if !inserted {
+		s.logger.Info("关联账号已有黑名单记录，仅更新风险计数",
+			zap.String("alipay_user_id", buyerID),
+			zap.String("evidence", evidence))
+		return false, nil
+	}
+
+	if saved != nil {
+		s.notifySaved(saved)
+	} else {
+		saved = blacklist
+	}
+	metrics.RecordBlacklistAssociated(string(riskLevel))
+	s.audit(BlacklistChange{
+		Action: model.BlacklistAuditInsert,
+		Actor:  SystemActor(auditActorBuyerGraph),
+		Reason: saved.Remark,
+		After:  saved,
+	})
+
+	s.logger.Info("关联账号已拉黑",
+		zap.Uint("blacklist_id", saved.ID),
+		zap.String("alipay_user_id", buyerID),
+		zap.String("risk_level", saved.RiskLevel),
+		zap.String("evidence", evidence))
+
+	return true, nil
+}
+
+// BlacklistEdit 手动修改黑名单的内容（字段为nil表示不修改）
+type BlacklistEdit struct {
+	RiskLevel *RiskLevel // 风险等级（手动修改可以调低）
+	ExpireAt  *time.Time // 过期时间
+	Permanent bool       // 改为永久有效（优先于 ExpireAt）
+	Remark    *string    // 备注
+}
+
+// UpdateEntry 管理接口手动修改黑名单，返回修改后的记录（不存在返回nil）
+func (s *BlacklistService) UpdateEntry(id uint, edit BlacklistEdit, operatorID int, reason string) (*model.AlipayBlacklist, error) {
+	entry, err := s.blacklistRepo.FindByID(id)
+	if err != nil || entry == nil {
+		return nil, err
+	}
+
+	updated := *entry
+	if edit.RiskLevel != nil {
+		if riskRank(*edit.RiskLevel) == 0 {
+			return nil, fmt.Errorf("无效的风险等级: %s", *edit.RiskLevel)
+		}
+		updated.RiskLevel = string(*edit.RiskLevel)
+	}
+	if edit.Permanent {
+		updated.ExpireAt = nil
+	} else if edit.ExpireAt != nil {
+		updated.ExpireAt = edit.ExpireAt
+	}
+	if edit.Remark != nil {
+		updated.Remark = truncateRunes(*edit.Remark, blacklistRemarkMaxLen)
+	}
+
+	if err := s.blacklistRepo.UpdateManual(id, updated.RiskLevel, updated.ExpireAt, updated.Remark); err != nil {
+		return nil, err
+	}
+
+	// 重新查询最新记录（风险计数可能被并发更新；查询不到说明已被删除）
+	latest, err := s.blacklistRepo.FindByID(id)
+	if err != nil || latest == nil {
+		return nil, err
+	}
+
+	s.notifySaved(latest)
+	s.audit(BlacklistChange{
+		Action: model.BlacklistAuditEdit,
+		Actor:  OperatorActor(operatorID),
+		Reason: reason,
+		Before: entry,
+		After:  latest,
+	})
+
+	s.logger.Info("黑名单已手动修改",
+		zap.Uint("blacklist_id", id),
+		zap.String("alipay_user_id", latest.AlipayUserID),
+		zap.String("previous_risk_level", entry.RiskLevel),
+		zap.String("risk_level", latest.RiskLevel),
+		zap.Int("operator_id", operatorID))
+
+	return latest, nil
+}
+
+// RemoveEntry 管理接口手动解除拉黑，返回被删除的记录（不存在返回nil）
+func (s *BlacklistService) RemoveEntry(id uint, operatorID int, reason string) (*model.AlipayBlacklist, error) {
+	entry, err := s.blacklistRepo.FindByID(id)
+	if err != nil || entry == nil {
+		return nil, err
+	}
+
+	deleted, err := s.blacklistRepo.DeleteByID(id, 0)
+	if err != nil {
+		return nil, err
+	}
+	if !deleted {
+		return nil, nil
+	}
+
+	message := fmt.Sprintf("管理接口手动解除（操作人：%d）", operatorID)
+	if reason != "" {
+		message += "：" + reason
+	}
+	s.audit(BlacklistChange{
+		Action: model.BlacklistAuditUnblock,
+		Actor:  OperatorActor(operatorID),
+		Reason: reason,
+		Before: entry,
+	})
+	s.released(entry, releaseReasonManual, message)
+
+	return entry, nil
+}
+
 // CheckBlacklist 检查是否在黑名单中（仅检查 alipay_user_id）
 func (s *BlacklistService) CheckBlacklist(alipayUserID string) (bool, *model.AlipayBlacklist, error) {
 	exists, err := s.blacklistRepo.Exists(alipayUserID)
@@ -212,21 +681,6 @@ func (s *BlacklistService) CheckBlacklistByUniqueKey(alipayUserID, deviceCode, i
 	return true, blacklist, nil
 }
 
-// IncrementRiskCount 增加风险触发次数
-func (s *BlacklistService) IncrementRiskCount(alipayUserID, deviceCode, ipAddress string) error {
-	err := s.blacklistRepo.IncrementRiskCount(alipayUserID, deviceCode, ipAddress)
-	if err != nil {
-		return fmt.Errorf("增加风险触发次数失败: %w", err)
-	}
-
-	s.logger.Debug("增加风险触发次数成功",
-		zap.String("alipay_user_id", alipayUserID),
-		zap.String("device_code", deviceCode),
-		zap.String("ip_address", ipAddress))
-
-	return nil
-}
-
 // GetBlacklistStats 获取黑名单统计
 func (s *BlacklistService) GetBlacklistStats() (map[string]interface{}, error) {
 	// 统计总数
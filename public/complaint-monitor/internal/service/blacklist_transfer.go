@@ -0,0 +1,511 @@
+package service
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"complaint-monitor/internal/config"
+	"complaint-monitor/internal/model"
+	"complaint-monitor/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// 黑名单导入导出格式
+const (
+	BlacklistFormatCSV   = "csv"
+	BlacklistFormatJSONL = "jsonl"
+)
+
+const (
+	// blacklistTimeLayout 导入导出的时间格式（本地时间）
+	blacklistTimeLayout = "2006-01-02 15:04:05"
+	// blacklistImportMaxErrors 导入汇总中保留的错误行数
+	blacklistImportMaxErrors = 20
+)
+
+// blacklistCSVHeader CSV表头（导入时按表头名称匹配列，alipay_user_id 必填，其他列可省略）
+var blacklistCSVHeader = []string{
+	"alipay_user_id", "device_code", "ip_address", "risk_count", "risk_level", "last_risk_time", "expire_at", "remark",
+}
+
+// BlacklistRecord 黑名单导入导出记录
+// 时间格式为 2006-01-02 15:04:05（导入也接受RFC3339）；expire_at 为空时按风险等级计算过期时间
+type BlacklistRecord struct {
+	AlipayUserID string `json:"alipay_user_id"`
+	DeviceCode   string `json:"device_code,omitempty"`
+	IPAddress    string `json:"ip_address,omitempty"`
+	RiskCount    int    `json:"risk_count,omitempty"`
+	RiskLevel    string `json:"risk_level,omitempty"`
+	LastRiskTime string `json:"last_risk_time,omitempty"`
+	ExpireAt     string `json:"expire_at,omitempty"`
+	Remark       string `json:"remark,omitempty"`
+}
+
+// BlacklistImportSummary 黑名单导入汇总
+type BlacklistImportSummary struct {
+	DryRun      bool     `json:"dry_run"`
+	Total       int      `json:"total"`       // 读取的记录数
+	Invalid     int      `json:"invalid"`     // 格式错误的记录数
+	Duplicates  int      `json:"duplicates"`  // 文件内按唯一键重复（已合并）的记录数
+	Allowlisted int      `json:"allowlisted"` // 命中白名单（跳过）的记录数
+	Inserted    int      `json:"inserted"`    // 新增（演练时为将新增）的记录数
+	Updated     int      `json:"updated"`     // 更新（演练时为将更新）的记录数
+	Errors      []string `json:"errors,omitempty"`
+}
+
+func (s *BlacklistImportSummary) addError(line int, err error) {
+	s.Invalid++
+	if len(s.Errors) < blacklistImportMaxErrors {
+		s.Errors = append(s.Errors, fmt.Sprintf("第%d行: %v", line, err))
+	}
+}
+
+// BlacklistTransfer 黑名单导入导出
+// 导入与自动拉黑一样跳过命中白名单的记录；每批写入后通知监听者（如Redis镜像，刷新镜像并发布变更通知），
+// 其他实例的内存索引通过增量同步获取变更；每条写入的记录追加一条审计
+type BlacklistTransfer struct {
+	blacklistRepo *repository.BlacklistRepository
+	allowlist     *AllowlistService
+	auditor       *BlacklistAuditor
+	listeners     []BlacklistListener
+	cfg           config.BlacklistConfig
+	logger        *zap.Logger
+}
+
+// NewBlacklistTransfer 创建黑名单导入导出（allowlist 为nil时不检查白名单）
+func NewBlacklistTransfer(blacklistRepo *repository.BlacklistRepository, allowlist *AllowlistService, auditor *BlacklistAuditor, cfg config.BlacklistConfig, logger *zap.Logger) *BlacklistTransfer {
+	return &BlacklistTransfer{
+		blacklistRepo: blacklistRepo,
+		allowlist:     allowlist,
+		auditor:       auditor,
+		cfg:           cfg,
+		logger:        logger,
+	}
+}
+
+// AddListener 注册黑名单变更监听（需在导入前注册）
+func (t *BlacklistTransfer) AddListener(listener BlacklistListener) {
+	t.listeners = append(t.listeners, listener)
+}
+
+// Import 导入黑名单
+// 文件内按 (alipay_user_id, device_code, ip_address) 去重合并，已存在的记录（按 FindByUniqueKey 查询）合并后更新：
+// 风险计数和风险等级取较大值，最后触发时间和过期时间取较晚值，原备注保留；命中白名单的记录跳过；dryRun 时只统计不写库
+// source 为导入来源（文件名），记录到审计的操作方
+func (t *BlacklistTransfer) Import(r io.Reader, format, source string, dryRun bool, batchSize int) (*BlacklistImportSummary, error) {
+	summary := &BlacklistImportSummary{DryRun: dryRun}
+	now := time.Now()
+
+	var entries []*model.AlipayBlacklist
+	err := readBlacklistRecords(r, format, func(line int, record BlacklistRecord) {
+		summary.Total++
+		entry, err := t.recordToBlacklist(record, now)
+		if err != nil {
+			summary.addError(line, err)
+			return
+		}
+		entries = append(entries, entry)
+	}, func(line int, err error) {
+		summary.Total++
+		summary.addError(line, err)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entries, summary.Duplicates = dedupeBlacklists(entries)
+
+	var inserts, updates []*model.AlipayBlacklist
+	previous := make(map[*model.AlipayBlacklist]*model.AlipayBlacklist) // 更新的记录 -> 合并前的值
+	for _, entry := range entries {
+		allowlisted, err := t.checkAllowlist(entry)
+		if err != nil {
+			return nil, err
+		}
+		if allowlisted != nil {
+			summary.Allowlisted++
+			t.logger.Info("导入记录命中白名单，跳过",
+				zap.String("alipay_user_id", entry.AlipayUserID),
+				zap.Uint("allowlist_id", allowlisted.ID),
+				zap.String("match_type", allowlisted.MatchType))
+			continue
+		}
+
+		existing, err := t.blacklistRepo.FindByUniqueKey(entry.AlipayUserID, stringValue(entry.DeviceCode), stringValue(entry.IPAddress))
+		if err != nil {
+			return nil, err
+		}
+		if existing == nil {
+			inserts = append(inserts, entry)
+			continue
+		}
+		before := *existing
+		previous[existing] = &before
+		mergeBlacklist(existing, entry)
+		existing.UpdatedAt = now
+		updates = append(updates, existing)
+	}
+	summary.Inserted = len(inserts)
+	summary.Updated = len(updates)
+
+	if dryRun {
+		return summary, nil
+	}
+
+	// 新增和更新分开写入：更新的记录带主键，按主键冲突更新
+	for _, group := range [][]*model.AlipayBlacklist{inserts, updates} {
+		for start := 0; start < len(group); start += batchSize {
+			end := min(start+batchSize, len(group))
+			if err := t.blacklistRepo.Upsert(group[start:end]...); err != nil {
+				return summary, err
+			}
+			t.auditImported(group[start:end], previous, source)
+			t.notifySaved(group[start:end])
+		}
+	}
+
+	t.logger.Info("黑名单导入完成",
+		zap.Int("total", summary.Total),
+		zap.Int("invalid", summary.Invalid),
+		zap.Int("duplicates", summary.Duplicates),
+		zap.Int("allowlisted", summary.Allowlisted),
+		zap.Int("inserted", summary.Inserted),
+		zap.Int("updated", summary.Updated))
+
+	return summary, nil
+}
+
+// checkAllowlist 查询导入记录是否命中白名单（未配置白名单服务时返回nil）
+func (t *BlacklistTransfer) checkAllowlist(entry *model.AlipayBlacklist) (*model.BuyerAllowlist, error) {
+	if t.allowlist == nil {
+		return nil, nil
+	}
+	return t.allowlist.Match(entry.AlipayUserID, stringValue(entry.IPAddress), stringValue(entry.DeviceCode))
+}
+
+// notifySaved 通知监听者导入写入的记录
+func (t *BlacklistTransfer) notifySaved(entries []*model.AlipayBlacklist) {
+	for _, entry := range entries {
+		for _, listener := range t.listeners {
+			listener.OnBlacklistSaved(entry)
+		}
+	}
+}
+
+// auditImported 为导入写入的记录追加审计（审计写入失败只记录日志）
+func (t *BlacklistTransfer) auditImported(entries []*model.AlipayBlacklist, previous map[*model.AlipayBlacklist]*model.AlipayBlacklist, source string) {
+	if t.auditor == nil {
+		return
+	}
+	for _, entry := range entries {
+		change := BlacklistChange{
+			Action: model.BlacklistAuditImport,
+			Actor:  ImportActor(source),
+			Reason: "批量导入新增",
+			Before: previous[entry],
+			After:  entry,
+		}
+		if change.Before != nil {
+			change.Reason = "批量导入合并更新"
+		}
+		if err := t.auditor.Record(change); err != nil {
+			t.logger.Error("写入黑名单导入审计记录失败",
+				zap.Uint("blacklist_id", entry.ID),
+				zap.String("alipay_user_id", entry.AlipayUserID),
+				zap.Error(err))
+		}
+	}
+}
+
+// Export 导出符合筛选条件的黑名单，返回导出数量
+func (t *BlacklistTransfer) Export(w io.Writer, format string, filter repository.BlacklistExportFilter, batchSize int) (int, error) {
+	write, flush, err := newBlacklistWriter(w, format)
+	if err != nil {
+		return 0, err
+	}
+
+	var afterID uint
+	count := 0
+	for {
+		batch, err := t.blacklistRepo.FindForExport(filter, afterID, batchSize)
+		if err != nil {
+			return count, err
+		}
+		for _, entry := range batch {
+			if err := write(blacklistToRecord(entry)); err != nil {
+				return count, fmt.Errorf("写入导出文件失败: %w", err)
+			}
+			afterID = entry.ID
+			count++
+		}
+		if len(batch) < batchSize {
+			break
+		}
+	}
+
+	if err := flush(); err != nil {
+		return count, fmt.Errorf("写入导出文件失败: %w", err)
+	}
+	return count, nil
+}
+
+// recordToBlacklist 校验导入记录并转换为黑名单记录（空设备码和IP存储为NULL）
+func (t *BlacklistTransfer) recordToBlacklist(record BlacklistRecord, now time.Time) (*model.AlipayBlacklist, error) {
+	alipayUserID := strings.TrimSpace(record.AlipayUserID)
+	if alipayUserID == "" {
+		return nil, fmt.Errorf("alipay_user_id不能为空")
+	}
+
+	level := RiskLevel(strings.TrimSpace(record.RiskLevel))
+	if level == "" {
+		level = RiskLevelLow
+	}
+	if riskRank(level) == 0 {
+		return nil, fmt.Errorf("无效的风险等级: %s", record.RiskLevel)
+	}
+
+	entry := &model.AlipayBlacklist{
+		AlipayUserID:   alipayUserID,
+		RiskCount:      max(record.RiskCount, 1),
+		RiskLevel:      string(level),
+		Remark:         strings.TrimSpace(record.Remark),
+		IdentitySource: model.IdentitySourceImport,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	if deviceCode := model.NormalizeDeviceCode(record.DeviceCode); deviceCode != "" {
+		entry.DeviceCode = &deviceCode
+	}
+	if ip := strings.TrimSpace(record.IPAddress); ip != "" {
+		addr, err := netip.ParseAddr(ip)
+		if err != nil {
+			return nil, fmt.Errorf("无效的IP地址: %s", ip)
+		}
+		normalized := addr.Unmap().String()
+		entry.IPAddress = &normalized
+	}
+
+	lastRiskTime, err := parseBlacklistTime(record.LastRiskTime)
+	if err != nil {
+		return nil, fmt.Errorf("last_risk_time格式错误: %w", err)
+	}
+	if lastRiskTime == nil {
+		lastRiskTime = timePtr(now)
+	}
+	entry.LastRiskTime = lastRiskTime
+
+	expireAt, err := parseBlacklistTime(record.ExpireAt)
+	if err != nil {
+		return nil, fmt.Errorf("expire_at格式错误: %w", err)
+	}
+	if expireAt == nil {
+		if ttl := t.cfg.GetExpireDuration(string(level)); ttl > 0 {
+			expireAt = timePtr(lastRiskTime.Add(ttl))
+		}
+	}
+	entry.ExpireAt = expireAt
+
+	return entry, nil
+}
+
+// blacklistToRecord 黑名单记录转换为导出记录
+func blacklistToRecord(entry *model.AlipayBlacklist) BlacklistRecord {
+	record := BlacklistRecord{
+		AlipayUserID: entry.AlipayUserID,
+		DeviceCode:   stringValue(entry.DeviceCode),
+		IPAddress:    stringValue(entry.IPAddress),
+		RiskCount:    entry.RiskCount,
+		RiskLevel:    entry.RiskLevel,
+		Remark:       entry.Remark,
+	}
+	if entry.LastRiskTime != nil {
+		record.LastRiskTime = entry.LastRiskTime.Format(blacklistTimeLayout)
+	}
+	if entry.ExpireAt != nil {
+		record.ExpireAt = entry.ExpireAt.Format(blacklistTimeLayout)
+	}
+	return record
+}
+
+// parseBlacklistTime 解析导入时间（空字符串返回nil）
+func parseBlacklistTime(value string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.ParseInLocation(blacklistTimeLayout, value, time.Local); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("无法解析时间: %s", value)
+	}
+	return &t, nil
+}
+
+// dedupeBlacklists 按唯一键合并重复记录，返回合并后的记录和重复数
+func dedupeBlacklists(entries []*model.AlipayBlacklist) ([]*model.AlipayBlacklist, int) {
+	seen := make(map[string]*model.AlipayBlacklist, len(entries))
+	result := make([]*model.AlipayBlacklist, 0, len(entries))
+	duplicates := 0
+	for _, entry := range entries {
+		key := model.BlacklistUniqueKey(entry.AlipayUserID, stringValue(entry.DeviceCode), stringValue(entry.IPAddress))
+		if existing, ok := seen[key]; ok {
+			mergeBlacklist(existing, entry)
+			duplicates++
+			continue
+		}
+		seen[key] = entry
+		result = append(result, entry)
+	}
+	return result, duplicates
+}
+
+// mergeBlacklist 将 src 合并到 dst：风险计数和风险等级取较大值，最后触发时间和过期时间取较晚值（永久有效优先），dst 无备注时使用 src 的备注
+func mergeBlacklist(dst, src *model.AlipayBlacklist) {
+	dst.RiskCount = max(dst.RiskCount, src.RiskCount)
+	dst.RiskLevel = string(MaxRiskLevel(RiskLevel(dst.RiskLevel), RiskLevel(src.RiskLevel)))
+	if src.LastRiskTime != nil && (dst.LastRiskTime == nil || src.LastRiskTime.After(*dst.LastRiskTime)) {
+		dst.LastRiskTime = src.LastRiskTime
+	}
+	if dst.ExpireAt != nil && (src.ExpireAt == nil || src.ExpireAt.After(*dst.ExpireAt)) {
+		dst.ExpireAt = src.ExpireAt
+	}
+	if dst.Remark == "" {
+		dst.Remark = src.Remark
+	}
+}
+
+// readBlacklistRecords 逐条读取导入记录（行号从1开始，CSV表头为第1行）
+// 单条记录格式错误时回调 onError 并继续，文件级错误（格式不支持、缺少表头）直接返回
+func readBlacklistRecords(r io.Reader, format string, onRecord func(line int, record BlacklistRecord), onError func(line int, err error)) error {
+	switch format {
+	case BlacklistFormatCSV:
+		return readBlacklistCSV(r, onRecord, onError)
+	case BlacklistFormatJSONL:
+		return readBlacklistJSONL(r, onRecord, onError)
+	default:
+		return fmt.Errorf("不支持的格式: %s（支持 csv/jsonl）", format)
+	}
+}
+
+func readBlacklistCSV(r io.Reader, onRecord func(line int, record BlacklistRecord), onError func(line int, err error)) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("读取CSV表头失败: %w", err)
+	}
+	// 兼容Excel导出的UTF-8 BOM
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimPrefix(strings.TrimSpace(name), "\ufeff")] = i
+	}
+	if _, ok := columns["alipay_user_id"]; !ok {
+		return fmt.Errorf("CSV表头缺少 alipay_user_id 列")
+	}
+
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			if _, ok := err.(*csv.ParseError); ok {
+				onError(line, err)
+				continue
+			}
+			return fmt.Errorf("读取CSV失败: %w", err)
+		}
+
+		get := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+		record := BlacklistRecord{
+			AlipayUserID: get("alipay_user_id"),
+			DeviceCode:   get("device_code"),
+			IPAddress:    get("ip_address"),
+			RiskLevel:    get("risk_level"),
+			LastRiskTime: get("last_risk_time"),
+			ExpireAt:     get("expire_at"),
+			Remark:       get("remark"),
+		}
+		if value := get("risk_count"); value != "" {
+			count, err := strconv.Atoi(value)
+			if err != nil {
+				onError(line, fmt.Errorf("risk_count格式错误: %s", value))
+				continue
+			}
+			record.RiskCount = count
+		}
+		onRecord(line, record)
+	}
+}
+
+func readBlacklistJSONL(r io.Reader, onRecord func(line int, record BlacklistRecord), onError func(line int, err error)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var record BlacklistRecord
+		if err := json.Unmarshal([]byte(text), &record); err != nil {
+			onError(line, fmt.Errorf("JSON格式错误: %w", err))
+			continue
+		}
+		onRecord(line, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("读取JSONL失败: %w", err)
+	}
+	return nil
+}
+
+// newBlacklistWriter 创建导出写入函数
+func newBlacklistWriter(w io.Writer, format string) (write func(BlacklistRecord) error, flush func() error, err error) {
+	switch format {
+	case BlacklistFormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(blacklistCSVHeader); err != nil {
+			return nil, nil, fmt.Errorf("写入CSV表头失败: %w", err)
+		}
+		write = func(record BlacklistRecord) error {
+			return writer.Write([]string{
+				record.AlipayUserID, record.DeviceCode, record.IPAddress, strconv.Itoa(record.RiskCount),
+				record.RiskLevel, record.LastRiskTime, record.ExpireAt, record.Remark,
+			})
+		}
+		flush = func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+		return write, flush, nil
+	case BlacklistFormatJSONL:
+		buffered := bufio.NewWriter(w)
+		encoder := json.NewEncoder(buffered)
+		encoder.SetEscapeHTML(false)
+		write = func(record BlacklistRecord) error {
+			return encoder.Encode(record)
+		}
+		return write, buffered.Flush, nil
+	default:
+		return nil, nil, fmt.Errorf("不支持的格式: %s（支持 csv/jsonl）", format)
+	}
+}
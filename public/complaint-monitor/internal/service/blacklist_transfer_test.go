@@ -0,0 +1,98 @@
+package service
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"complaint-monitor/internal/config"
+	"complaint-monitor/internal/model"
+
+	"go.uber.org/zap"
+)
+
+func TestReadBlacklistRecords(t *testing.T) {
+	csvInput := "\ufeffip_address,alipay_user_id,risk_count\n1.2.3.4,2088001,3\n,2088002,x\n"
+	var records []BlacklistRecord
+	var errLines []int
+	err := readBlacklistRecords(strings.NewReader(csvInput), BlacklistFormatCSV,
+		func(line int, record BlacklistRecord) { records = append(records, record) },
+		func(line int, err error) { errLines = append(errLines, line) })
+	if err != nil {
+		t.Fatalf("readBlacklistRecords(csv) error = %v", err)
+	}
+	if len(records) != 1 || records[0].AlipayUserID != "2088001" || records[0].IPAddress != "1.2.3.4" || records[0].RiskCount != 3 {
+		t.Errorf("CSV按表头名称读取: %+v", records)
+	}
+	if len(errLines) != 1 || errLines[0] != 3 {
+		t.Errorf("CSV错误行 = %v, 期望 [3]", errLines)
+	}
+
+	jsonlInput := `{"alipay_user_id":"2088003","risk_level":"high"}` + "\n\n{bad json}\n"
+	records, errLines = nil, nil
+	err = readBlacklistRecords(strings.NewReader(jsonlInput), BlacklistFormatJSONL,
+		func(line int, record BlacklistRecord) { records = append(records, record) },
+		func(line int, err error) { errLines = append(errLines, line) })
+	if err != nil {
+		t.Fatalf("readBlacklistRecords(jsonl) error = %v", err)
+	}
+	if len(records) != 1 || records[0].RiskLevel != "high" || len(errLines) != 1 || errLines[0] != 3 {
+		t.Errorf("JSONL读取: records=%+v errLines=%v", records, errLines)
+	}
+
+	if err := readBlacklistRecords(strings.NewReader("buyer\n1\n"), BlacklistFormatCSV, nil, nil); err == nil {
+		t.Error("缺少 alipay_user_id 列应返回错误")
+	}
+}
+
+func TestRecordToBlacklistAndDedupe(t *testing.T) {
+	transfer := NewBlacklistTransfer(nil, nil, nil, config.BlacklistConfig{LowExpireDays: 30, HighExpireDays: 180}, zap.NewNop())
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local)
+
+	if _, err := transfer.recordToBlacklist(BlacklistRecord{AlipayUserID: "2088001", IPAddress: "1.2.3"}, now); err == nil {
+		t.Error("无效IP应返回错误")
+	}
+	if _, err := transfer.recordToBlacklist(BlacklistRecord{AlipayUserID: "2088001", RiskLevel: "extreme"}, now); err == nil {
+		t.Error("无效风险等级应返回错误")
+	}
+
+	low, err := transfer.recordToBlacklist(BlacklistRecord{AlipayUserID: "2088001", IPAddress: "::ffff:1.2.3.4"}, now)
+	if err != nil {
+		t.Fatalf("recordToBlacklist error = %v", err)
+	}
+	if *low.IPAddress != "1.2.3.4" || low.DeviceCode != nil || low.RiskCount != 1 || !low.ExpireAt.Equal(now.AddDate(0, 0, 30)) {
+		t.Errorf("默认值: %+v", low)
+	}
+
+	high, _ := transfer.recordToBlacklist(BlacklistRecord{AlipayUserID: "2088001", IPAddress: "1.2.3.4", RiskCount: 4, RiskLevel: "high", Remark: "partner"}, now)
+	other, _ := transfer.recordToBlacklist(BlacklistRecord{AlipayUserID: "2088001"}, now)
+
+	entries, duplicates := dedupeBlacklists([]*model.AlipayBlacklist{low, high, other})
+	if duplicates != 1 || len(entries) != 2 {
+		t.Fatalf("dedupeBlacklists = %d条, 重复%d", len(entries), duplicates)
+	}
+	merged := entries[0]
+	if merged.RiskCount != 4 || merged.RiskLevel != "high" || merged.Remark != "partner" || !merged.ExpireAt.Equal(now.AddDate(0, 0, 180)) {
+		t.Errorf("合并结果: %+v", merged)
+	}
+}
+
+func TestBlacklistExportRecord(t *testing.T) {
+	var buf bytes.Buffer
+	write, flush, err := newBlacklistWriter(&buf, BlacklistFormatCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	device := "dev,1"
+	lastRisk := time.Date(2025, 1, 2, 3, 4, 5, 0, time.Local)
+	_ = write(blacklistToRecord(&model.AlipayBlacklist{AlipayUserID: "2088001", DeviceCode: &device, RiskCount: 2, RiskLevel: "low", LastRiskTime: &lastRisk}))
+	if err := flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join(blacklistCSVHeader, ",") + "\n2088001,\"dev,1\",,2,low,2025-01-02 03:04:05,,\n"
+	if buf.String() != want {
+		t.Errorf("CSV导出 = %q, 期望 %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"complaint-monitor/internal/model"
+)
+
+// 买家关联字段
+const (
+	BuyerLinkPayIP       = "pay_ip"
+	BuyerLinkFirstOpenIP = "first_open_ip"
+	BuyerLinkDeviceCode  = "device_code"
+)
+
+// BuyerLink 关联证据（多个买家共用同一个IP或设备码）
+type BuyerLink struct {
+	Field    string   `json:"field"` // pay_ip/first_open_ip/device_code
+	Value    string   `json:"value"`
+	BuyerIDs []string `json:"buyer_ids"`
+}
+
+// BuyerCluster 买家关联团伙（关联图的连通分量）
+type BuyerCluster struct {
+	ID             string      `json:"id"` // 团伙ID（成员中最小的买家ID）
+	BuyerIDs       []string    `json:"buyer_ids"`
+	BlacklistedIDs []string    `json:"blacklisted_ids"`
+	Flagged        bool        `json:"flagged"` // 是否包含黑名单账号
+	Links          []BuyerLink `json:"links"`
+}
+
+// Evidence 买家在团伙中的关联证据描述（用于关联拉黑的备注）
+func (c *BuyerCluster) Evidence(buyerID string) string {
+	parts := make([]string, 0)
+	for _, link := range c.Links {
+		others := make([]string, 0, len(link.BuyerIDs))
+		member := false
+		for _, id := range link.BuyerIDs {
+			if id == buyerID {
+				member = true
+			} else {
+				others = append(others, id)
+			}
+		}
+		if member {
+			parts = append(parts, fmt.Sprintf("与%s共用%s %s", strings.Join(others, ","), link.Field, link.Value))
+		}
+	}
+	return strings.Join(parts, "；")
+}
+
+// buyerLinkKey 关联字段值
+type buyerLinkKey struct {
+	field string
+	value string
+}
+
+// BuyerGraph 买家关联图
+// 共用支付IP、首次打开IP或设备码的买家之间连边；关联买家数超过上限的值（公共出口IP、通用UA）不连边
+type BuyerGraph struct {
+	maxSharedBuyers int
+	links           map[buyerLinkKey]map[string]struct{}
+}
+
+// NewBuyerGraph 创建买家关联图（maxSharedBuyers 为0表示不限制）
+func NewBuyerGraph(maxSharedBuyers int) *BuyerGraph {
+	return &BuyerGraph{
+		maxSharedBuyers: maxSharedBuyers,
+		links:           make(map[buyerLinkKey]map[string]struct{}),
+	}
+}
+
+// AddOrder 加入订单的买家及其IP、设备码
+func (g *BuyerGraph) AddOrder(order *model.Order) {
+	if order.BuyerID == "" {
+		return
+	}
+	g.add(BuyerLinkPayIP, order.PayIP, order.BuyerID)
+	g.add(BuyerLinkFirstOpenIP, order.FirstOpenIP, order.BuyerID)
+	g.add(BuyerLinkDeviceCode, order.DeviceCode, order.BuyerID)
+}
+
+func (g *BuyerGraph) add(field, value, buyerID string) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return
+	}
+	key := buyerLinkKey{field: field, value: value}
+	buyers, ok := g.links[key]
+	if !ok {
+		buyers = make(map[string]struct{})
+		g.links[key] = buyers
+	}
+	buyers[buyerID] = struct{}{}
+}
+
+// Clusters 计算包含至少两个买家的团伙
+// 包含黑名单账号的团伙排在前面，其次按成员数降序
+func (g *BuyerGraph) Clusters(isBlacklisted func(buyerID string) bool) []BuyerCluster {
+	parent := make(map[string]string)
+	var find func(id string) string
+	find = func(id string) string {
+		p, ok := parent[id]
+		if !ok || p == id {
+			parent[id] = id
+			return id
+		}
+		root := find(p)
+		parent[id] = root
+		return root
+	}
+	union := func(a, b string) {
+		ra, rb := find(a), find(b)
+		if ra == rb {
+			return
+		}
+		// 以较小的ID为根，保证结果稳定
+		if ra < rb {
+			parent[rb] = ra
+		} else {
+			parent[ra] = rb
+		}
+	}
+
+	links := make([]BuyerLink, 0)
+	for key, buyers := range g.links {
+		if len(buyers) < 2 || (g.maxSharedBuyers > 0 && len(buyers) > g.maxSharedBuyers) {
+			continue
+		}
+		ids := sortedKeys(buyers)
+		for _, id := range ids[1:] {
+			union(ids[0], id)
+		}
+		links = append(links, BuyerLink{Field: key.field, Value: key.value, BuyerIDs: ids})
+	}
+
+	clusters := make(map[string]*BuyerCluster)
+	for id := range parent {
+		root := find(id)
+		cluster, ok := clusters[root]
+		if !ok {
+			cluster = &BuyerCluster{ID: root, BlacklistedIDs: []string{}, Links: []BuyerLink{}}
+			clusters[root] = cluster
+		}
+		cluster.BuyerIDs = append(cluster.BuyerIDs, id)
+		if isBlacklisted != nil && isBlacklisted(id) {
+			cluster.BlacklistedIDs = append(cluster.BlacklistedIDs, id)
+			cluster.Flagged = true
+		}
+	}
+	for _, link := range links {
+		cluster := clusters[find(link.BuyerIDs[0])]
+		cluster.Links = append(cluster.Links, link)
+	}
+
+	result := make([]BuyerCluster, 0, len(clusters))
+	for _, cluster := range clusters {
+		sort.Strings(cluster.BuyerIDs)
+		sort.Strings(cluster.BlacklistedIDs)
+		sort.Slice(cluster.Links, func(i, j int) bool {
+			if cluster.Links[i].Field != cluster.Links[j].Field {
+				return cluster.Links[i].Field < cluster.Links[j].Field
+			}
+			return cluster.Links[i].Value < cluster.Links[j].Value
+		})
+		result = append(result, *cluster)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Flagged != result[j].Flagged {
+			return result[i].Flagged
+		}
+		if len(result[i].BuyerIDs) != len(result[j].BuyerIDs) {
+			return len(result[i].BuyerIDs) > len(result[j].BuyerIDs)
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+// sortedKeys 集合的有序元素
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
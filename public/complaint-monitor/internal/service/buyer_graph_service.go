@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"complaint-monitor/internal/config"
+	"complaint-monitor/internal/lock"
+	"complaint-monitor/internal/repository"
+	"complaint-monitor/pkg/metrics"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+const (
+	buyerGraphLockKey     = "blacklist:graph:lock"     // 关联分析锁（多实例只有一个执行）
+	buyerGraphSnapshotKey = "blacklist:graph:clusters" // 最近一次分析结果
+	buyerGraphBatchSize   = 1000                       // 每批扫描的订单数
+)
+
+// BuyerGraphSnapshot 关联分析结果
+type BuyerGraphSnapshot struct {
+	GeneratedAt time.Time      `json:"generated_at"`
+	OrderCount  int            `json:"order_count"` // 参与分析的订单数
+	Clusters    []BuyerCluster `json:"clusters"`
+}
+
+// BuyerGraphService 买家关联分析
+// 定期扫描最近的已支付订单构建买家关联图，结果保存到Redis供所有实例查询；
+// 开启自动拉黑时，为包含黑名单账号的团伙中尚未拉黑的账号新增低风险黑名单
+type BuyerGraphService struct {
+	orderRepo        *repository.OrderRepository
+	blacklistIndex   *BlacklistIndex
+	blacklistService *BlacklistService
+	lockManager      *lock.DistributedLock
+	redis            *redis.Client
+	cfg              config.BuyerGraphConfig
+	logger           *zap.Logger
+}
+
+// NewBuyerGraphService 创建买家关联分析服务
+func NewBuyerGraphService(
+	orderRepo *repository.OrderRepository,
+	blacklistIndex *BlacklistIndex,
+	blacklistService *BlacklistService,
+	lockManager *lock.DistributedLock,
+	redisClient *redis.Client,
+	cfg config.BuyerGraphConfig,
+	logger *zap.Logger,
+) *BuyerGraphService {
+	return &BuyerGraphService{
+		orderRepo:        orderRepo,
+		blacklistIndex:   blacklistIndex,
+		blacklistService: blacklistService,
+		lockManager:      lockManager,
+		redis:            redisClient,
+		cfg:              cfg,
+		logger:           logger,
+	}
+}
+
+// Build 扫描最近的已支付订单并计算买家团伙
+func (s *BuyerGraphService) Build(ctx context.Context) (*BuyerGraphSnapshot, error) {
+	now := time.Now()
+	since := now.AddDate(0, 0, -s.cfg.LookbackDays)
+	graph := NewBuyerGraph(s.cfg.MaxSharedBuyers)
+
+	var afterID uint
+	orderCount := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		orders, err := s.orderRepo.FindPaidBuyerOrdersAfterID(afterID, since, buyerGraphBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, order := range orders {
+			graph.AddOrder(order)
+			afterID = order.ID
+		}
+		orderCount += len(orders)
+		if len(orders) < buyerGraphBatchSize {
+			break
+		}
+	}
+
+	clusters := graph.Clusters(func(buyerID string) bool {
+		return s.blacklistIndex.Check(buyerID, "", "", now).Blocked
+	})
+
+	return &BuyerGraphSnapshot{
+		GeneratedAt: now,
+		OrderCount:  orderCount,
+		Clusters:    clusters,
+	}, nil
+}
+
+// Analyze 执行一次关联分析（其他实例正在分析或最近已分析过时跳过）
+func (s *BuyerGraphService) Analyze(ctx context.Context) error {
+	err := s.lockManager.WithLock(ctx, buyerGraphLockKey, lock.Options{}, func(ctx context.Context, _ int64) error {
+		// 多实例按各自的周期触发，最近半个周期内已有结果时不重复分析
+		previous, err := s.Snapshot(ctx)
+		if err != nil {
+			return err
+		}
+		if previous != nil && time.Since(previous.GeneratedAt) < s.cfg.GetInterval()/2 {
+			return nil
+		}
+
+		startTime := time.Now()
+		snapshot, err := s.Build(ctx)
+		if err != nil {
+			return err
+		}
+		if err := s.saveSnapshot(ctx, snapshot); err != nil {
+			return err
+		}
+
+		flagged := 0
+		for _, cluster := range snapshot.Clusters {
+			if cluster.Flagged {
+				flagged++
+			}
+		}
+		metrics.UpdateBuyerClusterTotal(len(snapshot.Clusters), flagged)
+
+		s.logger.Info("买家关联分析完成",
+			zap.Int("order_count", snapshot.OrderCount),
+			zap.Int("clusters", len(snapshot.Clusters)),
+			zap.Int("flagged", flagged),
+			zap.Duration("duration", time.Since(startTime)))
+
+		if s.cfg.AutoBlacklist {
+			s.blacklistAssociated(snapshot)
+		}
+		return nil
+	})
+	if errors.Is(err, lock.ErrNotAcquired) {
+		s.logger.Debug("其他实例正在执行买家关联分析，跳过")
+		return nil
+	}
+	return err
+}
+
+// blacklistAssociated 拉黑包含黑名单账号的团伙中尚未拉黑的账号（单个账号失败不影响其他账号）
+func (s *BuyerGraphService) blacklistAssociated(snapshot *BuyerGraphSnapshot) {
+	level := RiskLevel(s.cfg.AssociatedRiskLevel)
+	added := 0
+	for i := range snapshot.Clusters {
+		cluster := &snapshot.Clusters[i]
+		if !cluster.Flagged {
+			continue
+		}
+		blacklisted := make(map[string]struct{}, len(cluster.BlacklistedIDs))
+		for _, id := range cluster.BlacklistedIDs {
+			blacklisted[id] = struct{}{}
+		}
+
+		for _, buyerID := range cluster.BuyerIDs {
+			if _, ok := blacklisted[buyerID]; ok {
+				continue
+			}
+			evidence := fmt.Sprintf("团伙%s（黑名单账号：%v）%s", cluster.ID, cluster.BlacklistedIDs, cluster.Evidence(buyerID))
+			ok, err := s.blacklistService.AddAssociated(buyerID, level, evidence)
+			if err != nil {
+				s.logger.Error("拉黑关联账号失败",
+					zap.String("cluster_id", cluster.ID),
+					zap.String("alipay_user_id", buyerID),
+					zap.Error(err))
+				continue
+			}
+			if ok {
+				added++
+			}
+		}
+	}
+
+	if added > 0 {
+		s.logger.Info("关联账号拉黑完成", zap.Int("added", added))
+	}
+}
+
+// saveSnapshot 保存分析结果（保留两个周期，分析停止后自然过期）
+func (s *BuyerGraphService) saveSnapshot(ctx context.Context, snapshot *BuyerGraphSnapshot) error {
+	payload, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("序列化关联分析结果失败: %w", err)
+	}
+	if err := s.redis.Set(ctx, buyerGraphSnapshotKey, payload, 2*s.cfg.GetInterval()).Err(); err != nil {
+		return fmt.Errorf("保存关联分析结果失败: %w", err)
+	}
+	return nil
+}
+
+// Snapshot 获取最近一次分析结果（尚未分析时返回nil）
+func (s *BuyerGraphService) Snapshot(ctx context.Context) (*BuyerGraphSnapshot, error) {
+	payload, err := s.redis.Get(ctx, buyerGraphSnapshotKey).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取关联分析结果失败: %w", err)
+	}
+
+	var snapshot BuyerGraphSnapshot
+	if err := json.Unmarshal(payload, &snapshot); err != nil {
+		return nil, fmt.Errorf("解析关联分析结果失败: %w", err)
+	}
+	return &snapshot, nil
+}
+
+// Run 定期执行关联分析（阻塞直到上下文取消）
+func (s *BuyerGraphService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.GetInterval())
+	defer ticker.Stop()
+
+	s.logger.Info("买家关联分析已启动",
+		zap.Duration("interval", s.cfg.GetInterval()),
+		zap.Int("lookback_days", s.cfg.LookbackDays),
+		zap.Bool("auto_blacklist", s.cfg.AutoBlacklist))
+
+	for {
+		if err := s.Analyze(ctx); err != nil {
+			s.logger.Error("买家关联分析失败", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info("买家关联分析已停止")
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"complaint-monitor/internal/model"
+)
+
+func TestBuyerGraphClusters(t *testing.T) {
+	graph := NewBuyerGraph(3)
+	orders := []*model.Order{
+		{BuyerID: "2088001", PayIP: "1.1.1.1", DeviceCode: "dev-a"},
+		{BuyerID: "2088002", PayIP: "1.1.1.1"},
+		{BuyerID: "2088003", FirstOpenIP: "2.2.2.2", DeviceCode: "dev-a"},
+		{BuyerID: "2088004", PayIP: "3.3.3.3"},
+		{BuyerID: "2088005", PayIP: "3.3.3.3"},
+		// 公共出口IP：关联买家超过上限，不连边
+		{BuyerID: "2088001", PayIP: "9.9.9.9"},
+		{BuyerID: "2088004", PayIP: "9.9.9.9"},
+		{BuyerID: "2088006", PayIP: "9.9.9.9"},
+		{BuyerID: "2088007", PayIP: "9.9.9.9"},
+		{BuyerID: "2088008", PayIP: "4.4.4.4"},
+	}
+	for _, order := range orders {
+		graph.AddOrder(order)
+	}
+
+	clusters := graph.Clusters(func(buyerID string) bool { return buyerID == "2088003" })
+	if len(clusters) != 2 {
+		t.Fatalf("团伙数 = %d, want 2: %+v", len(clusters), clusters)
+	}
+
+	first := clusters[0]
+	if !first.Flagged || first.ID != "2088001" || strings.Join(first.BuyerIDs, ",") != "2088001,2088002,2088003" {
+		t.Errorf("包含黑名单账号的团伙应排在前面: %+v", first)
+	}
+	if len(first.Links) != 2 || first.Links[0].Field != BuyerLinkDeviceCode || first.Links[1].Field != BuyerLinkPayIP {
+		t.Errorf("关联证据: %+v", first.Links)
+	}
+	if got := first.Evidence("2088002"); got != "与2088001共用pay_ip 1.1.1.1" {
+		t.Errorf("Evidence() = %q", got)
+	}
+
+	second := clusters[1]
+	if second.Flagged || strings.Join(second.BuyerIDs, ",") != "2088004,2088005" {
+		t.Errorf("未包含黑名单账号的团伙: %+v", second)
+	}
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"complaint-monitor/internal/gateway"
+	"complaint-monitor/internal/model"
+	"complaint-monitor/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// ComplaintHandleService 投诉处理服务（商家完结、回复投诉）
+type ComplaintHandleService struct {
+	complaintRepo *repository.ComplaintRepository
+	subjectRepo   *repository.SubjectRepository
+	gateways      gateway.Provider
+	alipayService *AlipayService
+	logger        *zap.Logger
+}
+
+// NewComplaintHandleService 创建投诉处理服务
+func NewComplaintHandleService(
+	complaintRepo *repository.ComplaintRepository,
+	subjectRepo *repository.SubjectRepository,
+	gateways gateway.Provider,
+	alipayService *AlipayService,
+	logger *zap.Logger,
+) *ComplaintHandleService {
+	return &ComplaintHandleService{
+		complaintRepo: complaintRepo,
+		subjectRepo:   subjectRepo,
+		gateways:      gateways,
+		alipayService: alipayService,
+		logger:        logger,
+	}
+}
+
+// FinishComplaint 完结投诉
+// 调用支付宝完结投诉API成功后，更新反馈内容、反馈时间、处理人和投诉状态
+func (s *ComplaintHandleService) FinishComplaint(ctx context.Context, complaintID uint, processCode, remark string, handlerID int) error {
+	complaint, subject, err := s.loadComplaint(complaintID)
+	if err != nil {
+		return err
+	}
+
+	if complaint.IsProcessed() || complaint.IsDropped() {
+		return fmt.Errorf("投诉已结束，无需处理: status=%s", complaint.ComplaintStatus)
+	}
+
+	gw, err := s.gateways.Gateway(subject)
+	if err != nil {
+		return fmt.Errorf("加载证书失败: %w", err)
+	}
+
+	if err := s.alipayService.FinishComplaint(ctx, gw, complaint.AlipayComplainId, processCode, remark); err != nil {
+		return fmt.Errorf("完结投诉失败: %w", err)
+	}
+
+	// 超时后处理的投诉，支付宝侧状态为超时处理完成
+	toStatus := model.ComplaintStatusProcessed
+	if complaint.IsOverdue() {
+		toStatus = model.ComplaintStatusOverdueProcessed
+	}
+
+	statusLog := &model.ComplaintStatusLog{
+		SubjectID:       complaint.SubjectID,
+		AlipayTaskId:    complaint.AlipayTaskId,
+		FromStatus:      complaint.ComplaintStatus,
+		ToStatus:        toStatus,
+		FromGmtModified: complaint.GmtModified,
+		ToGmtModified:   complaint.GmtModified,
+		RefundAmount:    complaint.RefundAmount,
+		Source:          model.StatusChangeSourceManual,
+	}
+
+	now := time.Now()
+	complaint.MerchantFeedback = remark
+	complaint.FeedbackTime = &now
+	complaint.HandlerID = handlerID
+	complaint.ComplaintStatus = toStatus
+
+	if err := s.complaintRepo.UpdateHandleResult(complaint, statusLog); err != nil {
+		// 支付宝侧已完结，本地更新失败时下次轮询会同步状态
+		return fmt.Errorf("投诉已在支付宝完结，但更新本地记录失败: %w", err)
+	}
+
+	s.logger.Info("投诉完结成功",
+		zap.Uint("complaint_id", complaint.ID),
+		zap.String("alipay_task_id", complaint.AlipayTaskId),
+		zap.String("process_code", processCode),
+		zap.String("from_status", statusLog.FromStatus),
+		zap.String("to_status", toStatus),
+		zap.Int("handler_id", handlerID),
+	)
+
+	return nil
+}
+
+// ReplyComplaint 回复投诉（不改变投诉状态）
+func (s *ComplaintHandleService) ReplyComplaint(ctx context.Context, complaintID uint, content string, handlerID int) error {
+	complaint, subject, err := s.loadComplaint(complaintID)
+	if err != nil {
+		return err
+	}
+
+	gw, err := s.gateways.Gateway(subject)
+	if err != nil {
+		return fmt.Errorf("加载证书失败: %w", err)
+	}
+
+	if err := s.alipayService.ReplyComplaint(ctx, gw, complaint.AlipayComplainId, content); err != nil {
+		return fmt.Errorf("回复投诉失败: %w", err)
+	}
+
+	now := time.Now()
+	complaint.MerchantFeedback = content
+	complaint.FeedbackTime = &now
+	complaint.HandlerID = handlerID
+
+	if err := s.complaintRepo.UpdateHandleResult(complaint, nil); err != nil {
+		return fmt.Errorf("投诉已在支付宝回复，但更新本地记录失败: %w", err)
+	}
+
+	s.logger.Info("投诉回复成功",
+		zap.Uint("complaint_id", complaint.ID),
+		zap.String("alipay_task_id", complaint.AlipayTaskId),
+		zap.Int("handler_id", handlerID),
+	)
+
+	return nil
+}
+
+// StatusHistory 查询投诉及其状态变更记录（按时间正序）
+func (s *ComplaintHandleService) StatusHistory(complaintID uint) (*model.Complaint, []*model.ComplaintStatusLog, error) {
+	complaint, err := s.complaintRepo.FindByID(complaintID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if complaint == nil {
+		return nil, nil, fmt.Errorf("投诉不存在: id=%d", complaintID)
+	}
+
+	logs, err := s.complaintRepo.FindStatusLogs(complaintID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return complaint, logs, nil
+}
+
+// loadComplaint 加载投诉及其所属主体（含证书）
+func (s *ComplaintHandleService) loadComplaint(complaintID uint) (*model.Complaint, *model.Subject, error) {
+	complaint, err := s.complaintRepo.FindByID(complaintID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if complaint == nil {
+		return nil, nil, fmt.Errorf("投诉不存在: id=%d", complaintID)
+	}
+	if complaint.AlipayComplainId == 0 {
+		return nil, nil, fmt.Errorf("投诉缺少支付宝投诉主表ID，无法调用处理API: id=%d", complaintID)
+	}
+
+	subject, err := s.subjectRepo.LoadSubjectWithCert(complaint.SubjectID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("加载主体失败: %w", err)
+	}
+
+	return complaint, subject, nil
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"complaint-monitor/internal/config"
+	"complaint-monitor/internal/model"
+	"complaint-monitor/pkg/metrics"
+
+	"go.uber.org/zap"
+)
+
+// ComplaintNotifier 新投诉通知
+// 新入库的投诉按主体和金额过滤后，附带买家的风险等级和历史投诉次数写入消息队列
+type ComplaintNotifier struct {
+	notificationSvc *NotificationService
+	riskScorer      *RiskScorer
+	cfg             config.NotificationConfig
+	logger          *zap.Logger
+}
+
+// NewComplaintNotifier 创建新投诉通知
+func NewComplaintNotifier(notificationSvc *NotificationService, riskScorer *RiskScorer, cfg config.NotificationConfig, logger *zap.Logger) *ComplaintNotifier {
+	return &ComplaintNotifier{
+		notificationSvc: notificationSvc,
+		riskScorer:      riskScorer,
+		cfg:             cfg,
+		logger:          logger,
+	}
+}
+
+// Notify 推送新入库投诉的通知（按配置跳过时返回nil）
+// buyerIDs: 投诉解析出的买家身份（已排除主体PID）；autoBlacklist: 拉黑规则是否要求拉黑该投诉
+func (n *ComplaintNotifier) Notify(complaint *model.Complaint, details []*model.ComplaintDetail, subject *model.Subject, buyerIDs []string, autoBlacklist bool) error {
+	amount := 0.0
+	for _, detail := range details {
+		amount += detail.OrderAmount
+	}
+
+	if reason := n.cfg.ComplaintSkipReason(subject.ID, amount); reason != "" {
+		metrics.RecordComplaintNotification(subject.ID, reason)
+		n.logger.Debug("按配置跳过投诉通知",
+			zap.Int("subject_id", subject.ID),
+			zap.String("alipay_task_id", complaint.AlipayTaskId),
+			zap.Float64("amount", amount),
+			zap.String("reason", reason))
+		return nil
+	}
+
+	riskLevel, historyCount := n.buyerRisk(complaint, buyerIDs, amount, len(details))
+	if err := n.notificationSvc.PushComplaintNotification(complaint, details, subject, string(riskLevel), historyCount, autoBlacklist); err != nil {
+		metrics.RecordComplaintNotification(subject.ID, "failed")
+		return err
+	}
+	metrics.RecordComplaintNotification(subject.ID, "queued")
+	return nil
+}
+
+// buyerRisk 买家的风险等级和历史投诉次数（所有主体，含本次；多个买家取最高等级和最多次数）
+// 无法确定买家身份或评估失败时只按本次投诉的金额和订单数评估，历史次数记为1；不使用未经校验的投诉人字段
+func (n *ComplaintNotifier) buyerRisk(complaint *model.Complaint, buyerIDs []string, amount float64, orderCount int) (RiskLevel, int) {
+	level := EvaluateRiskLevel(RiskInput{Amount: amount, OrderCount: orderCount})
+	historyCount := 1
+
+	for _, buyerID := range buyerIDs {
+		buyerLevel, input, err := n.riskScorer.Score(buyerID, amount, orderCount)
+		if err != nil {
+			n.logger.Warn("评估买家风险失败，按本次投诉评估",
+				zap.String("alipay_task_id", complaint.AlipayTaskId),
+				zap.String("buyer_id", buyerID),
+				zap.Error(err))
+			continue
+		}
+		level = MaxRiskLevel(level, buyerLevel)
+		if int(input.HistoryComplaints) > historyCount {
+			historyCount = int(input.HistoryComplaints)
+		}
+	}
+	return level, historyCount
+}
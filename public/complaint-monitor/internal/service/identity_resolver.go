@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"complaint-monitor/internal/config"
+	"complaint-monitor/internal/gateway"
+	"complaint-monitor/internal/model"
+	"complaint-monitor/internal/repository"
+	"complaint-monitor/pkg/metrics"
+
+	"go.uber.org/zap"
+)
+
+// identityPIDRefreshInterval 主体支付宝PID集合的缓存时间
+const identityPIDRefreshInterval = 5 * time.Minute
+
+// 身份解析结果（指标标签）
+const (
+	identityResultAccepted = "accepted"
+	identityResultRejected = "rejected"
+)
+
+// alipayUserIDPattern 支付宝用户ID格式（2088开头的16位数字）
+var alipayUserIDPattern = regexp.MustCompile(`^2088\d{12}$`)
+
+// BuyerIdentity 解析出的投诉买家身份
+type BuyerIdentity struct {
+	BuyerID string // 买家支付宝用户ID
+	Source  string // 身份来源（model.IdentitySourceXxx）
+}
+
+// IdentityResolver 投诉买家身份解析
+// 按可信度依次尝试：订单表的 buyer_id、支付宝交易查询的 buyer_user_id/buyer_open_id、投诉详情中的投诉人字段；
+// 投诉详情的 ComplainantID 取自 OppositePid，按支付宝文档是被投诉方PID，可能就是商户自身的PID，
+// 因此投诉人字段必须符合支付宝用户ID格式，且任何来源得到的值与主体 alipay_pid 相同时都拒绝使用
+type IdentityResolver struct {
+	orderRepo     *repository.OrderRepository
+	subjectRepo   *repository.SubjectRepository
+	alipayService *AlipayService
+	cfg           config.IdentityConfig
+	logger        *zap.Logger
+
+	mu           sync.Mutex
+	pids         map[string]struct{} // 所有主体的支付宝PID
+	pidsLoadedAt time.Time
+}
+
+// NewIdentityResolver 创建投诉买家身份解析
+func NewIdentityResolver(
+	orderRepo *repository.OrderRepository,
+	subjectRepo *repository.SubjectRepository,
+	alipayService *AlipayService,
+	cfg config.IdentityConfig,
+	logger *zap.Logger,
+) *IdentityResolver {
+	return &IdentityResolver{
+		orderRepo:     orderRepo,
+		subjectRepo:   subjectRepo,
+		alipayService: alipayService,
+		cfg:           cfg,
+		logger:        logger,
+	}
+}
+
+// Resolve 解析投诉涉及的买家身份（无法确定时返回空列表）
+// gw: 主体的投诉网关（为nil时不调用交易查询）；subject: 被投诉主体；orderList: 投诉涉及的订单；complainantID: 投诉详情中的投诉人字段
+func (r *IdentityResolver) Resolve(ctx context.Context, gw gateway.ComplaintGateway, subject *model.Subject, orderList []gateway.OrderItem, complainantID string) ([]BuyerIdentity, error) {
+	pids, err := r.subjectPIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. 订单表的 buyer_id（查询失败时继续尝试下一来源）
+	merchantOrderNos, platformOrderNos := complaintOrderNos(orderList)
+	if len(merchantOrderNos) > 0 || len(platformOrderNos) > 0 {
+		buyerIDs, err := r.orderRepo.GetBuyerIDsByOrderNos(merchantOrderNos, platformOrderNos)
+		if err != nil {
+			r.logger.Warn("查询订单买家ID失败，尝试其他来源",
+				zap.Int("subject_id", subject.ID),
+				zap.Strings("merchant_order_nos", merchantOrderNos),
+				zap.Error(err))
+		}
+		if identities := r.accept(subject, buyerIDs, model.IdentitySourceOrder, pids); len(identities) > 0 {
+			return identities, nil
+		}
+	}
+
+	// 2. 支付宝交易查询的 buyer_user_id / buyer_open_id
+	if r.cfg.TradeQueryEnabled && gw != nil {
+		buyerIDs := r.queryTradeBuyers(ctx, gw, subject, orderList, pids)
+		if identities := r.accept(subject, buyerIDs, model.IdentitySourceTradeQuery, pids); len(identities) > 0 {
+			return identities, nil
+		}
+	}
+
+	// 3. 投诉详情中的投诉人字段（需符合支付宝用户ID格式）
+	if complainantID == "" {
+		return nil, nil
+	}
+	if !alipayUserIDPattern.MatchString(complainantID) {
+		r.logger.Warn("投诉人字段不是支付宝用户ID，无法确定投诉买家",
+			zap.Int("subject_id", subject.ID),
+			zap.String("complainant_id", complainantID))
+		metrics.RecordBlacklistIdentity(subject.ID, model.IdentitySourceComplainant, identityResultRejected)
+		return nil, nil
+	}
+	return r.accept(subject, []string{complainantID}, model.IdentitySourceComplainant, pids), nil
+}
+
+// queryTradeBuyers 通过交易查询获取投诉订单的买家ID（最多查询 TradeQueryMaxOrders 个订单，单个订单查询失败时跳过）
+// 开启 WriteBackBuyerID 时把买家ID回写到 buyer_id 为空的订单，回写失败不影响解析结果
+func (r *IdentityResolver) queryTradeBuyers(ctx context.Context, gw gateway.ComplaintGateway, subject *model.Subject, orderList []gateway.OrderItem, pids map[string]struct{}) []string {
+	buyerIDs := make([]string, 0)
+	queried := 0
+	for _, orderItem := range orderList {
+		if orderItem.TradeNo == "" && orderItem.OutTradeNo == "" {
+			continue
+		}
+		if queried >= r.cfg.TradeQueryMaxOrders || ctx.Err() != nil {
+			break
+		}
+		queried++
+
+		trade, err := r.alipayService.QueryTrade(ctx, gw, orderItem.TradeNo, orderItem.OutTradeNo)
+		if err != nil {
+			r.logger.Warn("交易查询失败，跳过该订单",
+				zap.Int("subject_id", subject.ID),
+				zap.String("trade_no", orderItem.TradeNo),
+				zap.String("out_trade_no", orderItem.OutTradeNo),
+				zap.Error(err))
+			continue
+		}
+		buyerID := trade.BuyerID()
+		if buyerID == "" {
+			continue
+		}
+		buyerIDs = append(buyerIDs, buyerID)
+
+		if r.cfg.WriteBackBuyerID && !isSubjectPID(buyerID, subject, pids) {
+			updated, err := r.orderRepo.FillBuyerID(orderItem.OutTradeNo, orderItem.TradeNo, buyerID)
+			if err != nil {
+				r.logger.Warn("回写订单买家ID失败",
+					zap.String("out_trade_no", orderItem.OutTradeNo),
+					zap.String("buyer_id", buyerID),
+					zap.Error(err))
+			} else if updated > 0 {
+				r.logger.Info("已回写订单买家ID",
+					zap.String("out_trade_no", orderItem.OutTradeNo),
+					zap.String("trade_no", orderItem.TradeNo),
+					zap.String("buyer_id", buyerID),
+					zap.Int64("updated", updated))
+			}
+		}
+	}
+	return buyerIDs
+}
+
+// accept 过滤空值、重复值和主体PID，返回可用于拉黑的买家身份
+func (r *IdentityResolver) accept(subject *model.Subject, buyerIDs []string, source string, pids map[string]struct{}) []BuyerIdentity {
+	identities := make([]BuyerIdentity, 0, len(buyerIDs))
+	seen := make(map[string]struct{}, len(buyerIDs))
+	for _, buyerID := range buyerIDs {
+		if buyerID == "" {
+			continue
+		}
+		if _, ok := seen[buyerID]; ok {
+			continue
+		}
+		seen[buyerID] = struct{}{}
+
+		if isSubjectPID(buyerID, subject, pids) {
+			r.logger.Warn("买家ID与主体支付宝PID相同，拒绝拉黑",
+				zap.Int("subject_id", subject.ID),
+				zap.String("buyer_id", buyerID),
+				zap.String("source", source))
+			metrics.RecordBlacklistIdentity(subject.ID, source, identityResultRejected)
+			continue
+		}
+		metrics.RecordBlacklistIdentity(subject.ID, source, identityResultAccepted)
+		identities = append(identities, BuyerIdentity{BuyerID: buyerID, Source: source})
+	}
+	return identities
+}
+
+// subjectPIDs 所有主体的支付宝PID（缓存 identityPIDRefreshInterval，刷新失败时沿用上次的结果）
+func (r *IdentityResolver) subjectPIDs() (map[string]struct{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.pids != nil && time.Since(r.pidsLoadedAt) < identityPIDRefreshInterval {
+		return r.pids, nil
+	}
+
+	list, err := r.subjectRepo.FindAllAlipayPIDs()
+	if err != nil {
+		if r.pids != nil {
+			r.logger.Warn("刷新主体支付宝PID失败，沿用上次的结果", zap.Error(err))
+			return r.pids, nil
+		}
+		return nil, fmt.Errorf("加载主体支付宝PID失败: %w", err)
+	}
+
+	pids := make(map[string]struct{}, len(list))
+	for _, pid := range list {
+		pids[pid] = struct{}{}
+	}
+	r.pids = pids
+	r.pidsLoadedAt = time.Now()
+	return pids, nil
+}
+
+// isSubjectPID 值是否为主体的支付宝PID（当前主体的PID始终参与比较，不依赖缓存是否已刷新）
+func isSubjectPID(value string, subject *model.Subject, pids map[string]struct{}) bool {
+	if subject != nil && subject.AlipayPID != "" && value == subject.AlipayPID {
+		return true
+	}
+	_, ok := pids[value]
+	return ok
+}
+
+// complaintOrderNos 提取投诉订单的商户订单号和支付宝订单号
+func complaintOrderNos(orderList []gateway.OrderItem) (merchantOrderNos, platformOrderNos []string) {
+	for _, orderItem := range orderList {
+		if orderItem.OutTradeNo != "" {
+			merchantOrderNos = append(merchantOrderNos, orderItem.OutTradeNo)
+		}
+		if orderItem.TradeNo != "" {
+			platformOrderNos = append(platformOrderNos, orderItem.TradeNo)
+		}
+	}
+	return merchantOrderNos, platformOrderNos
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"complaint-monitor/internal/config"
+	"complaint-monitor/internal/gateway"
+	"complaint-monitor/internal/gateway/simulator"
+	"complaint-monitor/internal/model"
+
+	"go.uber.org/zap"
+)
+
+// newTestIdentityResolver 创建已加载主体PID缓存的身份解析（不访问数据库）
+func newTestIdentityResolver(pids ...string) *IdentityResolver {
+	r := NewIdentityResolver(nil, nil, NewAlipayService(nil, zap.NewNop()), config.IdentityConfig{TradeQueryMaxOrders: 5}, zap.NewNop())
+	r.pids = make(map[string]struct{}, len(pids))
+	for _, pid := range pids {
+		r.pids[pid] = struct{}{}
+	}
+	r.pidsLoadedAt = time.Now()
+	return r
+}
+
+func TestResolveComplainantRejectsSubjectPID(t *testing.T) {
+	subject := &model.Subject{ID: 1, AlipayPID: "2088000000000001"}
+	r := newTestIdentityResolver("2088000000000002")
+
+	tests := []struct {
+		name          string
+		complainantID string
+		wantBuyer     string
+	}{
+		{"合法的买家ID", "2088123456789012", "2088123456789012"},
+		{"当前主体PID", "2088000000000001", ""},
+		{"其他主体PID", "2088000000000002", ""},
+		{"不是支付宝用户ID", "buyer-001", ""},
+		{"为空", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identities, err := r.Resolve(context.Background(), nil, subject, nil, tt.complainantID)
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			if tt.wantBuyer == "" {
+				if len(identities) != 0 {
+					t.Fatalf("期望不拉黑，实际 %+v", identities)
+				}
+				return
+			}
+			if len(identities) != 1 || identities[0].BuyerID != tt.wantBuyer || identities[0].Source != model.IdentitySourceComplainant {
+				t.Fatalf("期望 %s（complainant），实际 %+v", tt.wantBuyer, identities)
+			}
+		})
+	}
+}
+
+func TestIdentityAcceptFiltersDuplicatesAndPIDs(t *testing.T) {
+	subject := &model.Subject{ID: 1, AlipayPID: "2088000000000001"}
+	r := newTestIdentityResolver()
+
+	identities := r.accept(subject, []string{"", "2088111", "2088000000000001", "2088111", "2088222"}, model.IdentitySourceOrder, r.pids)
+	if len(identities) != 2 || identities[0].BuyerID != "2088111" || identities[1].BuyerID != "2088222" {
+		t.Fatalf("期望 [2088111 2088222]，实际 %+v", identities)
+	}
+	for _, identity := range identities {
+		if identity.Source != model.IdentitySourceOrder {
+			t.Errorf("身份来源应为 order，实际 %s", identity.Source)
+		}
+	}
+}
+
+func TestQueryTradeBuyers(t *testing.T) {
+	complaint := simulator.NewComplaint(1, time.Now(), 3)
+	srv := simulator.Start(&simulator.Scenario{Name: "trade_query", Complaints: []simulator.Complaint{complaint}})
+	defer srv.Close()
+
+	subject := &model.Subject{ID: 1, AlipayAppID: "2021000000000001", AlipayPID: "2088000000000001"}
+	gw, err := srv.Provider().Gateway(subject)
+	if err != nil {
+		t.Fatalf("创建模拟网关失败: %v", err)
+	}
+
+	r := newTestIdentityResolver()
+	r.cfg.TradeQueryMaxOrders = 2
+	orders := append([]gateway.OrderItem{{OutTradeNo: "NOT_EXIST"}}, complaint.Detail.TargetOrderList...)
+
+	// 不存在的交易跳过，最多查询2个订单
+	buyerIDs := r.queryTradeBuyers(context.Background(), gw, subject, orders, r.pids)
+	if len(buyerIDs) != 1 || buyerIDs[0] != complaint.BuyerID {
+		t.Fatalf("期望 [%s]，实际 %v", complaint.BuyerID, buyerIDs)
+	}
+	if calls := srv.Calls(simulator.APITradeQuery); calls != 2 {
+		t.Errorf("交易查询次数 = %d, 期望 2", calls)
+	}
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"complaint-monitor/internal/config"
+	"complaint-monitor/internal/iprange"
+	"complaint-monitor/internal/model"
+	"complaint-monitor/internal/repository"
+	"complaint-monitor/pkg/metrics"
+
+	"go.uber.org/zap"
+)
+
+// IPRangeService 黑名单网段服务
+// 支持手动添加CIDR网段；同一网段内的黑名单IP达到阈值后自动拉黑整个网段（作为 BlacklistListener 注册，需在内存索引之后注册）
+// 网段内有白名单IP时不自动拉黑，避免白名单买家被网段命中
+type IPRangeService struct {
+	rangeRepo *repository.BlacklistIPRangeRepository
+	index     *BlacklistIndex
+	allowlist *AllowlistService
+	cfg       config.BlacklistConfig
+	listeners []IPRangeListener
+	logger    *zap.Logger
+}
+
+// NewIPRangeService 创建黑名单网段服务（allowlist 为nil时不检查白名单）
+func NewIPRangeService(rangeRepo *repository.BlacklistIPRangeRepository, index *BlacklistIndex, allowlist *AllowlistService, cfg config.BlacklistConfig, logger *zap.Logger) *IPRangeService {
+	return &IPRangeService{
+		rangeRepo: rangeRepo,
+		index:     index,
+		allowlist: allowlist,
+		cfg:       cfg,
+		logger:    logger,
+	}
+}
+
+// AddListener 注册网段变更监听（需在开始处理前注册）
+func (s *IPRangeService) AddListener(listener IPRangeListener) {
+	s.listeners = append(s.listeners, listener)
+}
+
+func (s *IPRangeService) notifySaved(ipRange *model.AlipayBlacklistIPRange) {
+	for _, listener := range s.listeners {
+		listener.OnIPRangeSaved(ipRange)
+	}
+}
+
+func (s *IPRangeService) notifyRemoved(ipRange *model.AlipayBlacklistIPRange) {
+	for _, listener := range s.listeners {
+		listener.OnIPRangeRemoved(ipRange)
+	}
+}
+
+// List 查询所有网段
+func (s *IPRangeService) List() ([]*model.AlipayBlacklistIPRange, error) {
+	return s.rangeRepo.FindAll()
+}
+
+// Add 手动添加网段（过期时间按风险等级计算，网段已存在时返回错误）
+func (s *IPRangeService) Add(cidr string, riskLevel RiskLevel, remark string) (*model.AlipayBlacklistIPRange, error) {
+	prefix, err := iprange.ParsePrefix(cidr)
+	if err != nil {
+		return nil, err
+	}
+	if riskRank(riskLevel) == 0 {
+		return nil, fmt.Errorf("无效的风险等级: %s", riskLevel)
+	}
+
+	ipRange, err := s.create(prefix.String(), model.IPRangeSourceManual, 0, riskLevel, remark)
+	if err != nil {
+		return nil, err
+	}
+	if ipRange == nil {
+		return nil, fmt.Errorf("网段已存在: %s", prefix.String())
+	}
+	return ipRange, nil
+}
+
+// Remove 删除网段，返回被删除的记录（不存在返回nil）
+func (s *IPRangeService) Remove(id uint) (*model.AlipayBlacklistIPRange, error) {
+	ipRange, err := s.rangeRepo.FindByID(id)
+	if err != nil || ipRange == nil {
+		return nil, err
+	}
+
+	deleted, err := s.rangeRepo.Delete(id)
+	if err != nil {
+		return nil, err
+	}
+	if !deleted {
+		return nil, nil
+	}
+
+	metrics.RecordBlacklistRelease(releaseReasonManual)
+	s.notifyRemoved(ipRange)
+	s.logger.Info("黑名单网段已删除",
+		zap.Uint("range_id", ipRange.ID),
+		zap.String("cidr", ipRange.CIDR))
+	return ipRange, nil
+}
+
+// create 写入网段（网段已存在时返回nil）
+func (s *IPRangeService) create(cidr, source string, ipCount int, riskLevel RiskLevel, remark string) (*model.AlipayBlacklistIPRange, error) {
+	existing, err := s.rangeRepo.FindByCIDR(cidr)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, nil
+	}
+
+	now := time.Now()
+	ipRange := &model.AlipayBlacklistIPRange{
+		CIDR:      cidr,
+		Source:    source,
+		IPCount:   ipCount,
+		RiskLevel: string(riskLevel),
+		Remark:    remark,
+	}
+	if ttl := s.cfg.GetExpireDuration(string(riskLevel)); ttl > 0 {
+		ipRange.ExpireAt = timePtr(now.Add(ttl))
+	}
+	if err := s.rangeRepo.Create(ipRange); err != nil {
+		return nil, err
+	}
+
+	metrics.RecordBlacklistRangeAdd(source)
+	s.notifySaved(ipRange)
+	s.logger.Info("新增黑名单网段",
+		zap.Uint("range_id", ipRange.ID),
+		zap.String("cidr", ipRange.CIDR),
+		zap.String("source", source),
+		zap.Int("ip_count", ipCount),
+		zap.String("risk_level", ipRange.RiskLevel))
+	return ipRange, nil
+}
+
+// OnBlacklistSaved 黑名单新增或更新时检查所在网段是否达到自动升级阈值（实现 BlacklistListener）
+func (s *IPRangeService) OnBlacklistSaved(entry *model.AlipayBlacklist) {
+	if !s.cfg.RangePromoteEnabled || entry.IPAddress == nil {
+		return
+	}
+	ipAddress := *entry.IPAddress
+
+	subnet, count, ok := s.index.SubnetIPCount(ipAddress)
+	if !ok || count < s.cfg.RangePromoteThreshold || s.index.RangeCovers(ipAddress, time.Now()) {
+		return
+	}
+
+	if s.allowlist != nil {
+		allowlisted, err := s.allowlist.MatchSubnet(subnet)
+		if err != nil {
+			s.logger.Error("查询网段内白名单IP失败，跳过自动拉黑网段",
+				zap.String("cidr", subnet.String()),
+				zap.Error(err))
+			return
+		}
+		if allowlisted != nil {
+			s.logger.Info("网段内有白名单IP，跳过自动拉黑网段",
+				zap.String("cidr", subnet.String()),
+				zap.String("ip_address", ipAddress),
+				zap.Uint("allowlist_id", allowlisted.ID),
+				zap.String("allowlisted_ip", allowlisted.Value))
+			return
+		}
+	}
+
+	remark := fmt.Sprintf("网段内已有%d个不同的黑名单IP，自动拉黑网段（触发IP：%s）", count, ipAddress)
+	if _, err := s.create(subnet.String(), model.IPRangeSourceAuto, count, RiskLevelLow, remark); err != nil {
+		s.logger.Error("自动拉黑网段失败",
+			zap.String("cidr", subnet.String()),
+			zap.String("ip_address", ipAddress),
+			zap.Error(err))
+	}
+}
+
+// OnBlacklistRemoved 已拉黑的网段不随单个IP解除（实现 BlacklistListener）
+func (s *IPRangeService) OnBlacklistRemoved(entry *model.AlipayBlacklist) {}
+
+// SweepExpired 清理已过期的网段，返回解除数量
+func (s *IPRangeService) SweepExpired(now time.Time) (int, error) {
+	ranges, err := s.rangeRepo.FindExpired(now, s.cfg.SweepBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	released := 0
+	for _, ipRange := range ranges {
+		deleted, err := s.rangeRepo.DeleteExpired(ipRange.ID, now)
+		if err != nil {
+			return released, err
+		}
+		if !deleted {
+			continue
+		}
+		released++
+		metrics.RecordBlacklistRelease(releaseReasonExpired)
+		s.notifyRemoved(ipRange)
+		s.logger.Info("黑名单网段已过期解除",
+			zap.Uint("range_id", ipRange.ID),
+			zap.String("cidr", ipRange.CIDR))
+	}
+	return released, nil
+}
+
+// RunExpirySweeper 定期清理过期网段（阻塞直到上下文取消）
+func (s *IPRangeService) RunExpirySweeper(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.GetSweepInterval())
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.SweepExpired(time.Now()); err != nil {
+			s.logger.Error("清理过期黑名单网段失败", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"complaint-monitor/internal/model"
+	"complaint-monitor/internal/repository"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -76,21 +77,57 @@ type BlacklistNotificationData struct {
 	DeviceCode   *string `json:"device_code"`    // 设备码（可能为NULL）
 	IPAddress    *string `json:"ip_address"`     // IP地址（可能为NULL）
 	RiskCount    int     `json:"risk_count"`     // 风险触发次数
+	RiskLevel    string  `json:"risk_level"`     // 风险等级
 	LastRiskTime string  `json:"last_risk_time"` // 最后风险时间
 	Remark       string  `json:"remark"`         // 备注信息
 	ComplaintNo  string  `json:"complaint_no"`   // 投诉单号
 	SubjectID    int     `json:"subject_id"`     // 主体ID（用于日志）
 	SubjectName  string  `json:"subject_name"`   // 主体名称（用于日志）
 	Message      string  `json:"message"`        // 处理消息
+
+	IdentitySource string                        `json:"identity_source"`         // 买家身份来源（order/trade_query/complainant等）
+	BuyerProfile   *BuyerProfileNotificationData `json:"buyer_profile,omitempty"` // 买家跨主体投诉画像（查询失败时省略）
+}
+
+// BuyerProfileNotificationData 通知中的买家跨主体投诉画像
+type BuyerProfileNotificationData struct {
+	TotalComplaints int64   `json:"total_complaints"` // 投诉总次数（所有主体）
+	SubjectCount    int64   `json:"subject_count"`    // 投诉过的不同主体数
+	AgentCount      int64   `json:"agent_count"`      // 投诉过的不同代理商数
+	TotalAmount     float64 `json:"total_amount"`     // 投诉总金额（元）
+	FirstSeen       string  `json:"first_seen"`       // 首次投诉时间
+	LastSeen        string  `json:"last_seen"`        // 最近投诉时间
+}
+
+// newBuyerProfileNotificationData 转换买家投诉画像（profile 为nil时返回nil）
+func newBuyerProfileNotificationData(profile *repository.BuyerRiskProfile) *BuyerProfileNotificationData {
+	if profile == nil {
+		return nil
+	}
+	data := &BuyerProfileNotificationData{
+		TotalComplaints: profile.TotalComplaints,
+		SubjectCount:    profile.SubjectCount,
+		AgentCount:      profile.AgentCount,
+		TotalAmount:     profile.TotalAmount,
+	}
+	if profile.FirstSeen != nil {
+		data.FirstSeen = profile.FirstSeen.Format("2006-01-02 15:04:05")
+	}
+	if profile.LastSeen != nil {
+		data.LastSeen = profile.LastSeen.Format("2006-01-02 15:04:05")
+	}
+	return data
 }
 
 // PushComplaintNotification 推送投诉通知
+// riskLevel、historyCount: 投诉人的风险等级和历史投诉次数（所有主体，含本次）；autoBlacklist: 拉黑规则是否要求拉黑
 func (s *NotificationService) PushComplaintNotification(
 	complaint *model.Complaint,
 	details []*model.ComplaintDetail,
 	subject *model.Subject,
 	riskLevel string,
 	historyCount int,
+	autoBlacklist bool,
 ) error {
 	// 构建通知数据
 	merchantOrderNos := make([]string, 0, len(details))
@@ -110,7 +147,7 @@ func (s *NotificationService) PushComplaintNotification(
 		OrderCount:            len(details),
 		TotalAmount:           totalAmount,
 		MerchantOrderNos:      merchantOrderNos,
-		IsAutoBlacklist:       true, // 所有投诉都触发拉黑
+		IsAutoBlacklist:       autoBlacklist,
 		RiskLevel:             riskLevel,
 		HistoryComplaintCount: historyCount,
 	}
@@ -145,6 +182,7 @@ func (s *NotificationService) PushComplaintNotification(
 	s.logger.Info("投诉通知已加入队列",
 		zap.String("complaint_no", complaint.ComplaintNo),
 		zap.String("risk_level", riskLevel),
+		zap.Int("history_count", historyCount),
 		zap.Int("priority", priority))
 
 	return nil
@@ -158,6 +196,7 @@ func (s *NotificationService) PushBlacklistNotification(
 	complaintNo string,
 	action string, // 'insert' 或 'update'
 	message string, // 处理消息
+	profile *repository.BuyerRiskProfile, // 买家跨主体投诉画像（可为nil）
 ) error {
 	// 构建通知数据（参考 PHP 实现）
 	var title string
@@ -181,12 +220,16 @@ func (s *NotificationService) PushBlacklistNotification(
 		DeviceCode:   blacklist.DeviceCode,
 		IPAddress:    blacklist.IPAddress,
 		RiskCount:    blacklist.RiskCount,
+		RiskLevel:    blacklist.RiskLevel,
 		LastRiskTime: lastRiskTimeStr,
 		Remark:       blacklist.Remark,
 		ComplaintNo:  complaintNo,
 		SubjectID:    subject.ID,
 		SubjectName:  subject.CompanyName,
 		Message:      message,
+
+		IdentitySource: blacklist.IdentitySource,
+		BuyerProfile:   newBuyerProfileNotificationData(profile),
 	}
 
 	// 序列化为JSON
@@ -198,12 +241,11 @@ func (s *NotificationService) PushBlacklistNotification(
 	// 设置模板名称
 	templateName := "blacklist"
 
-	// 写入消息队列（参考 PHP 实现）
-	// PHP: TelegramMessageQueue::PRIORITY_HIGH = 3
+	// 写入消息队列（参考 PHP 实现），优先级由风险等级决定
 	msg := &TelegramMessageQueue{
 		Title:        title,
 		Content:      "", // 内容由模板生成
-		Priority:     3,  // PRIORITY_HIGH
+		Priority:     s.getPriorityByRiskLevel(blacklist.RiskLevel),
 		Status:       "pending",
 		MessageType:  "template",
 		TemplateName: &templateName,
@@ -221,11 +263,135 @@ func (s *NotificationService) PushBlacklistNotification(
 		zap.String("title", title),
 		zap.String("alipay_user_id", blacklist.AlipayUserID),
 		zap.String("action", action),
+		zap.String("risk_level", blacklist.RiskLevel),
 		zap.Int("priority", msg.Priority))
 
 	return nil
 }
 
+// PushDeadLetterNotification 推送投诉处理失败（转入死信）通知
+func (s *NotificationService) PushDeadLetterNotification(retry *model.ComplaintRetry, subject *model.Subject) error {
+	content := fmt.Sprintf(
+		"主体: %s (ID: %d)\n投诉单号: %s\n支付宝投诉ID: %d\n失败次数: %d\n最后错误: %s\n\n已停止自动重试，请排查后通过管理接口重新入队",
+		subject.CompanyName,
+		subject.ID,
+		retry.AlipayTaskId,
+		retry.AlipayComplainId,
+		retry.Attempts,
+		retry.LastError,
+	)
+
+	msg := &TelegramMessageQueue{
+		Title:       "❌ 投诉处理失败（已转入死信）",
+		Content:     content,
+		Priority:    2,
+		Status:      "pending",
+		MessageType: "text",
+		MaxRetry:    3,
+		RetryCount:  0,
+	}
+
+	if err := s.db.Create(msg).Error; err != nil {
+		return fmt.Errorf("写入死信通知队列失败: %w", err)
+	}
+
+	s.logger.Info("死信通知已加入队列",
+		zap.Uint("message_id", msg.ID),
+		zap.Uint("retry_id", retry.ID),
+		zap.String("alipay_task_id", retry.AlipayTaskId))
+
+	return nil
+}
+
+// PushBlacklistReleaseNotification 推送黑名单解除通知
+// reason: 解除原因（如 过期、用户撤诉）
+func (s *NotificationService) PushBlacklistReleaseNotification(blacklist *model.AlipayBlacklist, reason string) error {
+	deviceCode := ""
+	if blacklist.DeviceCode != nil {
+		deviceCode = *blacklist.DeviceCode
+	}
+	ipAddress := ""
+	if blacklist.IPAddress != nil {
+		ipAddress = *blacklist.IPAddress
+	}
+
+	content := fmt.Sprintf(
+		"支付宝用户ID: %s\n设备码: %s\nIP地址: %s\n风险等级: %s\n风险触发次数: %d\n解除原因: %s",
+		blacklist.AlipayUserID,
+		deviceCode,
+		ipAddress,
+		blacklist.RiskLevel,
+		blacklist.RiskCount,
+		reason,
+	)
+
+	msg := &TelegramMessageQueue{
+		Title:       "✅ 黑名单已解除",
+		Content:     content,
+		Priority:    7,
+		Status:      "pending",
+		MessageType: "text",
+		MaxRetry:    3,
+		RetryCount:  0,
+	}
+
+	if err := s.db.Create(msg).Error; err != nil {
+		return fmt.Errorf("写入黑名单解除通知队列失败: %w", err)
+	}
+
+	s.logger.Info("黑名单解除通知已加入队列",
+		zap.Uint("message_id", msg.ID),
+		zap.Uint("blacklist_id", blacklist.ID),
+		zap.String("alipay_user_id", blacklist.AlipayUserID),
+		zap.String("reason", reason))
+
+	return nil
+}
+
+// PushAllowlistSkipNotification 推送白名单买家跳过拉黑通知
+func (s *NotificationService) PushAllowlistSkipNotification(allowlist *model.BuyerAllowlist, subject *model.Subject, complaintNo, alipayUserID, deviceCode, ipAddress string) error {
+	expireAt := "永久"
+	if allowlist.ExpireAt != nil {
+		expireAt = allowlist.ExpireAt.Format("2006-01-02 15:04:05")
+	}
+
+	content := fmt.Sprintf(
+		"主体: %s (ID: %d)\n投诉单号: %s\n支付宝用户ID: %s\n设备码: %s\nIP地址: %s\n命中白名单: %s %s\n白名单原因: %s\n白名单有效期至: %s\n\n投诉已入库，未拉黑",
+		subject.CompanyName,
+		subject.ID,
+		complaintNo,
+		alipayUserID,
+		deviceCode,
+		ipAddress,
+		allowlist.MatchType,
+		allowlist.Value,
+		allowlist.Reason,
+		expireAt,
+	)
+
+	msg := &TelegramMessageQueue{
+		Title:       "🛡️ 白名单买家被投诉，已跳过拉黑",
+		Content:     content,
+		Priority:    5,
+		Status:      "pending",
+		MessageType: "text",
+		MaxRetry:    3,
+		RetryCount:  0,
+	}
+
+	if err := s.db.Create(msg).Error; err != nil {
+		return fmt.Errorf("写入白名单跳过拉黑通知队列失败: %w", err)
+	}
+
+	s.logger.Info("白名单跳过拉黑通知已加入队列",
+		zap.Uint("message_id", msg.ID),
+		zap.Uint("allowlist_id", allowlist.ID),
+		zap.String("alipay_user_id", alipayUserID),
+		zap.String("complaint_no", complaintNo))
+
+	return nil
+}
+
 // getPriorityByRiskLevel 根据风险等级获取优先级
 func (s *NotificationService) getPriorityByRiskLevel(riskLevel string) int {
 	switch riskLevel {
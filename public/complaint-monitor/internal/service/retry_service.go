@@ -0,0 +1,132 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"complaint-monitor/internal/config"
+	"complaint-monitor/internal/gateway"
+	"complaint-monitor/internal/model"
+	"complaint-monitor/internal/ratelimit"
+	"complaint-monitor/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// RetryService 投诉处理重试服务
+// 处理失败的投诉写入重试表，按指数退避重试；达到最大次数后转入死信并发送Telegram通知
+type RetryService struct {
+	retryRepo           *repository.RetryRepository
+	notificationService *NotificationService
+	cfg                 config.RetryConfig
+	logger              *zap.Logger
+}
+
+// NewRetryService 创建投诉处理重试服务
+func NewRetryService(
+	retryRepo *repository.RetryRepository,
+	notificationService *NotificationService,
+	cfg config.RetryConfig,
+	logger *zap.Logger,
+) *RetryService {
+	return &RetryService{
+		retryRepo:           retryRepo,
+		notificationService: notificationService,
+		cfg:                 cfg,
+		logger:              logger,
+	}
+}
+
+// RecordFailure 记录投诉处理失败（首次失败时入队，已入队时累加失败次数并计算下次重试时间）
+func (s *RetryService) RecordFailure(subject *model.Subject, item gateway.ComplaintItem, cause error) error {
+	retry, err := s.retryRepo.FindByTask(subject.ID, item.ComplaintEventID)
+	if err != nil {
+		return err
+	}
+	if retry == nil {
+		retry = &model.ComplaintRetry{
+			SubjectID:    subject.ID,
+			AlipayTaskId: item.ComplaintEventID,
+		}
+	}
+	if retry.IsDead() {
+		// 已转入死信的投诉不再累加，等待人工重新入队
+		return nil
+	}
+	if item.ComplaintID != 0 {
+		retry.AlipayComplainId = item.ComplaintID
+	}
+
+	// 已恢复的投诉再次失败时重新计数
+	if retry.Status == model.RetryStatusResolved {
+		retry.Attempts = 0
+	}
+
+	delay := ratelimit.BackoffDuration(retry.Attempts+1, s.cfg.GetBackoffBase(), s.cfg.GetBackoffMax())
+	dead := retry.RecordFailure(cause.Error(), time.Now(), delay, s.cfg.MaxAttempts)
+
+	if err := s.retryRepo.Save(retry); err != nil {
+		return err
+	}
+
+	if !dead {
+		s.logger.Info("投诉处理失败，已加入重试队列",
+			zap.Int("subject_id", subject.ID),
+			zap.String("alipay_task_id", retry.AlipayTaskId),
+			zap.Int("attempts", retry.Attempts),
+			zap.Time("next_attempt_at", retry.NextAttemptAt),
+		)
+		return nil
+	}
+
+	s.logger.Error("投诉处理失败次数达到上限，转入死信",
+		zap.Int("subject_id", subject.ID),
+		zap.String("alipay_task_id", retry.AlipayTaskId),
+		zap.Int("attempts", retry.Attempts),
+		zap.String("last_error", retry.LastError),
+	)
+	if err := s.notificationService.PushDeadLetterNotification(retry, subject); err != nil {
+		// 通知失败不影响死信记录，死信仍可通过管理接口查看
+		s.logger.Error("推送死信通知失败", zap.Error(err))
+	}
+
+	return nil
+}
+
+// DueRetries 获取主体已到重试时间的投诉
+func (s *RetryService) DueRetries(subjectID int) ([]*model.ComplaintRetry, error) {
+	return s.retryRepo.FindDue(subjectID, time.Now(), s.cfg.BatchSize)
+}
+
+// MarkResolved 标记重试成功
+func (s *RetryService) MarkResolved(retry *model.ComplaintRetry) error {
+	if err := s.retryRepo.MarkResolved(retry.ID); err != nil {
+		return err
+	}
+	s.logger.Info("投诉重试成功",
+		zap.Int("subject_id", retry.SubjectID),
+		zap.String("alipay_task_id", retry.AlipayTaskId),
+		zap.Int("attempts", retry.Attempts),
+	)
+	return nil
+}
+
+// ListDead 分页查询死信（subjectID 为0时查询所有主体）
+func (s *RetryService) ListDead(subjectID, page, pageSize int) ([]*model.ComplaintRetry, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	return s.retryRepo.FindDead(subjectID, (page-1)*pageSize, pageSize)
+}
+
+// Requeue 死信重新入队（下个轮询周期立即重试）
+func (s *RetryService) Requeue(id uint) error {
+	if err := s.retryRepo.Requeue(id); err != nil {
+		return fmt.Errorf("重新入队失败: %w", err)
+	}
+	s.logger.Info("死信已重新入队", zap.Uint("retry_id", id))
+	return nil
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"complaint-monitor/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// riskRecentWindow 近期投诉统计窗口
+const riskRecentWindow = 24 * time.Hour
+
+// RiskInput 风险评估依据
+type RiskInput struct {
+	RecentComplaints  int64   // 买家24小时内被投诉次数（所有主体）
+	HistoryComplaints int64   // 买家历史被投诉次数（所有主体）
+	SubjectCount      int64   // 买家投诉过的不同主体数
+	Amount            float64 // 本次投诉涉及金额（元）
+	OrderCount        int     // 本次投诉涉及订单数
+
+	Profile *repository.BuyerRiskProfile // 买家跨主体投诉画像（查询失败时为nil，不参与评估）
+}
+
+// EvaluateRiskLevel 根据风险依据计算风险等级（取命中的最高等级）
+// 同一买家在多个主体都有投诉视为职业投诉人：3个及以上主体为高风险
+func EvaluateRiskLevel(in RiskInput) RiskLevel {
+	switch {
+	case in.HistoryComplaints >= 5 || in.OrderCount >= 10:
+		return RiskLevelCritical
+	case in.RecentComplaints >= 4 || in.SubjectCount >= 3 || in.Amount > 1000:
+		return RiskLevelHigh
+	case in.RecentComplaints >= 2 || in.SubjectCount >= 2 || in.Amount >= 500:
+		return RiskLevelMedium
+	default:
+		return RiskLevelLow
+	}
+}
+
+// riskRank 风险等级排序值（未知等级视为最低）
+func riskRank(level RiskLevel) int {
+	switch level {
+	case RiskLevelCritical:
+		return 4
+	case RiskLevelHigh:
+		return 3
+	case RiskLevelMedium:
+		return 2
+	case RiskLevelLow:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// MaxRiskLevel 返回两个风险等级中较高的一个
+func MaxRiskLevel(a, b RiskLevel) RiskLevel {
+	if riskRank(b) > riskRank(a) {
+		return b
+	}
+	return a
+}
+
+// RiskScorer 买家风险评估
+type RiskScorer struct {
+	complaintRepo *repository.ComplaintRepository
+	logger        *zap.Logger
+}
+
+// NewRiskScorer 创建买家风险评估
+func NewRiskScorer(complaintRepo *repository.ComplaintRepository, logger *zap.Logger) *RiskScorer {
+	return &RiskScorer{
+		complaintRepo: complaintRepo,
+		logger:        logger,
+	}
+}
+
+// Score 评估买家风险等级
+// buyerID 为身份解析得到的买家ID；amount、orderCount 为本次投诉中该买家涉及的金额和订单数
+// 近期次数、历史次数和主体数按投诉买家身份（alipay_complaint_buyer）汇总
+func (s *RiskScorer) Score(buyerID string, amount float64, orderCount int) (RiskLevel, RiskInput, error) {
+	input := RiskInput{
+		Amount:     amount,
+		OrderCount: orderCount,
+	}
+
+	since := time.Now().Add(-riskRecentWindow)
+	recent, err := s.complaintRepo.CountByBuyerSince(buyerID, &since)
+	if err != nil {
+		return RiskLevelLow, input, fmt.Errorf("统计买家近期投诉次数失败: %w", err)
+	}
+	profile, err := s.complaintRepo.FindBuyerRiskProfile(buyerID)
+	if err != nil {
+		return RiskLevelLow, input, fmt.Errorf("查询买家投诉画像失败: %w", err)
+	}
+
+	input.RecentComplaints = recent
+	input.HistoryComplaints = profile.TotalComplaints
+	input.SubjectCount = profile.SubjectCount
+	input.Profile = profile
+	level := EvaluateRiskLevel(input)
+
+	s.logger.Debug("买家风险评估完成",
+		zap.String("buyer_id", buyerID),
+		zap.String("risk_level", string(level)),
+		zap.Int64("recent_complaints", recent),
+		zap.Int64("history_complaints", profile.TotalComplaints),
+		zap.Int64("subject_count", profile.SubjectCount),
+		zap.Int64("agent_count", profile.AgentCount),
+		zap.Float64("history_amount", profile.TotalAmount),
+		zap.Float64("amount", amount),
+		zap.Int("order_count", orderCount),
+	)
+
+	return level, input, nil
+}
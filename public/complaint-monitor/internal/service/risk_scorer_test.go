@@ -0,0 +1,44 @@
+package service
+
+import "testing"
+
+func TestEvaluateRiskLevel(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    RiskInput
+		expected RiskLevel
+	}{
+		{"首次投诉小额", RiskInput{RecentComplaints: 1, HistoryComplaints: 1, Amount: 100, OrderCount: 1}, RiskLevelLow},
+		{"24h内2次", RiskInput{RecentComplaints: 2, HistoryComplaints: 2, Amount: 100, OrderCount: 1}, RiskLevelMedium},
+		{"金额500元", RiskInput{RecentComplaints: 1, HistoryComplaints: 1, Amount: 500, OrderCount: 1}, RiskLevelMedium},
+		{"金额1000元", RiskInput{RecentComplaints: 1, HistoryComplaints: 1, Amount: 1000, OrderCount: 1}, RiskLevelMedium},
+		{"金额超过1000元", RiskInput{RecentComplaints: 1, HistoryComplaints: 1, Amount: 1000.01, OrderCount: 1}, RiskLevelHigh},
+		{"24h内4次", RiskInput{RecentComplaints: 4, HistoryComplaints: 4, Amount: 100, OrderCount: 1}, RiskLevelHigh},
+		{"历史5次", RiskInput{RecentComplaints: 1, HistoryComplaints: 5, Amount: 100, OrderCount: 1}, RiskLevelCritical},
+		{"涉及10个订单", RiskInput{RecentComplaints: 1, HistoryComplaints: 1, Amount: 100, OrderCount: 10}, RiskLevelCritical},
+		{"2个主体各投诉1次", RiskInput{RecentComplaints: 1, HistoryComplaints: 2, SubjectCount: 2, Amount: 100, OrderCount: 1}, RiskLevelMedium},
+		{"3个主体各投诉1次", RiskInput{RecentComplaints: 1, HistoryComplaints: 3, SubjectCount: 3, Amount: 100, OrderCount: 1}, RiskLevelHigh},
+		{"5个主体各投诉1次", RiskInput{RecentComplaints: 1, HistoryComplaints: 5, SubjectCount: 5, Amount: 100, OrderCount: 1}, RiskLevelCritical},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EvaluateRiskLevel(tt.input); got != tt.expected {
+				t.Errorf("EvaluateRiskLevel(%+v) = %s, 期望 %s", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMaxRiskLevel(t *testing.T) {
+	if got := MaxRiskLevel(RiskLevelHigh, RiskLevelMedium); got != RiskLevelHigh {
+		t.Errorf("MaxRiskLevel(high, medium) = %s", got)
+	}
+	if got := MaxRiskLevel(RiskLevelLow, RiskLevelCritical); got != RiskLevelCritical {
+		t.Errorf("MaxRiskLevel(low, critical) = %s", got)
+	}
+	// 历史数据没有风险等级时以新等级为准
+	if got := MaxRiskLevel("", RiskLevelLow); got != RiskLevelLow {
+		t.Errorf("MaxRiskLevel(\"\", low) = %s", got)
+	}
+}
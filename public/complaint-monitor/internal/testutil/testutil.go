@@ -1,11 +1,14 @@
 package testutil
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"complaint-monitor/internal/config"
 	"complaint-monitor/internal/logger"
 
+	"github.com/go-redis/redis/v8"
 	"go.uber.org/zap"
 )
 
@@ -27,6 +30,26 @@ func NewTestLogger(t *testing.T) *zap.Logger {
 	return log
 }
 
+// NewTestRedis 连接测试配置中的Redis（不可用时跳过测试，测试结束后关闭连接）
+func NewTestRedis(t *testing.T) *redis.Client {
+	t.Helper()
+	cfg := LoadTestConfig(t)
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.GetAddress(),
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		t.Skipf("测试Redis不可用，跳过: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
 // SkipIfShort 如果是短测试则跳过
 func SkipIfShort(t *testing.T) {
 	if testing.Short() {
@@ -0,0 +1,142 @@
+package watermark
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"complaint-monitor/internal/repository"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+const (
+	watermarkKeyPrefix = "complaint:watermark:"        // 同步水位键前缀
+	rescanKeyPrefix    = "complaint:watermark:rescan:" // 全量扫描请求键前缀
+)
+
+// Store 主体同步水位存储（Redis优先，数据库兜底）
+type Store struct {
+	redis  *redis.Client
+	repo   *repository.SyncStateRepository
+	logger *zap.Logger
+}
+
+// NewStore 创建同步水位存储
+func NewStore(redisClient *redis.Client, repo *repository.SyncStateRepository, logger *zap.Logger) *Store {
+	return &Store{
+		redis:  redisClient,
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Get 获取主体的同步水位
+// 返回 found=false 表示该主体从未同步过（需要全量扫描）
+func (s *Store) Get(ctx context.Context, subjectID int) (syncTime time.Time, found bool, err error) {
+	key := watermarkKey(subjectID)
+
+	// 1. 优先从Redis读取
+	value, err := s.redis.Get(ctx, key).Result()
+	if err == nil {
+		unix, parseErr := strconv.ParseInt(value, 10, 64)
+		if parseErr == nil {
+			return time.Unix(unix, 0), true, nil
+		}
+		s.logger.Warn("Redis中的同步水位格式错误，回退查询数据库",
+			zap.Int("subject_id", subjectID),
+			zap.String("value", value),
+			zap.Error(parseErr))
+	} else if err != redis.Nil {
+		s.logger.Warn("读取Redis同步水位失败，回退查询数据库",
+			zap.Int("subject_id", subjectID),
+			zap.Error(err))
+	}
+
+	// 2. Redis中不存在时，从数据库读取
+	state, err := s.repo.FindBySubjectID(subjectID)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	if state == nil || state.LastSyncTime == nil {
+		return time.Time{}, false, nil
+	}
+
+	// 3. 回写Redis（失败不影响返回）
+	if err := s.redis.Set(ctx, key, state.LastSyncTime.Unix(), 0).Err(); err != nil {
+		s.logger.Warn("回写Redis同步水位失败",
+			zap.Int("subject_id", subjectID),
+			zap.Error(err))
+	}
+
+	return *state.LastSyncTime, true, nil
+}
+
+// Save 保存主体的同步水位
+// 先写数据库保证持久化，再写Redis
+func (s *Store) Save(ctx context.Context, subjectID int, syncTime time.Time, fullScan bool) error {
+	if err := s.repo.SaveSyncTime(subjectID, syncTime, fullScan); err != nil {
+		return err
+	}
+
+	if err := s.redis.Set(ctx, watermarkKey(subjectID), syncTime.Unix(), 0).Err(); err != nil {
+		// 数据库已持久化，Redis写入失败只记录日志，下次读取时会从数据库回写
+		s.logger.Warn("写入Redis同步水位失败",
+			zap.Int("subject_id", subjectID),
+			zap.Error(err))
+	}
+
+	return nil
+}
+
+// clearRescanScript 清除全量扫描请求（仅当请求未被重新写入时）
+// KEYS[1]: 全量扫描请求键；ARGV[1]: 读取到的请求值
+var clearRescanScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+end
+return 0
+`)
+
+// RequestFullRescan 请求主体在下一次轮询时执行全量扫描
+func (s *Store) RequestFullRescan(ctx context.Context, subjectID int) error {
+	if err := s.redis.Set(ctx, rescanKey(subjectID), time.Now().UnixNano(), 0).Err(); err != nil {
+		return fmt.Errorf("写入全量扫描请求失败: %w", err)
+	}
+	s.logger.Info("已请求全量扫描", zap.Int("subject_id", subjectID))
+	return nil
+}
+
+// PeekFullRescanRequest 读取全量扫描请求（不清除）
+// 返回的 request 用于全量扫描完成后调用 ClearFullRescanRequest；没有请求时返回空字符串
+func (s *Store) PeekFullRescanRequest(ctx context.Context, subjectID int) (string, error) {
+	request, err := s.redis.Get(ctx, rescanKey(subjectID)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("读取全量扫描请求失败: %w", err)
+	}
+	return request, nil
+}
+
+// ClearFullRescanRequest 全量扫描完成后清除请求
+// 扫描期间重新写入的请求不会被清除，下次轮询仍会执行全量扫描
+func (s *Store) ClearFullRescanRequest(ctx context.Context, subjectID int, request string) error {
+	if err := clearRescanScript.Run(ctx, s.redis, []string{rescanKey(subjectID)}, request).Err(); err != nil {
+		return fmt.Errorf("清除全量扫描请求失败: %w", err)
+	}
+	return nil
+}
+
+// watermarkKey 同步水位键
+func watermarkKey(subjectID int) string {
+	return fmt.Sprintf("%s%d", watermarkKeyPrefix, subjectID)
+}
+
+// rescanKey 全量扫描请求键
+func rescanKey(subjectID int) string {
+	return fmt.Sprintf("%s%d", rescanKeyPrefix, subjectID)
+}
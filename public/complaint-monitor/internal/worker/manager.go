@@ -2,15 +2,20 @@ package worker
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strconv"
 	"sync"
 	"time"
 
-	"complaint-monitor/internal/cert"
+	"complaint-monitor/internal/cluster"
 	"complaint-monitor/internal/config"
+	"complaint-monitor/internal/gateway"
 	"complaint-monitor/internal/lock"
 	"complaint-monitor/internal/model"
 	"complaint-monitor/internal/repository"
 	"complaint-monitor/internal/service"
+	"complaint-monitor/internal/watermark"
 
 	"go.uber.org/zap"
 )
@@ -22,10 +27,15 @@ type Manager struct {
 	complaintRepo    *repository.ComplaintRepository
 	blacklistRepo    *repository.BlacklistRepository
 	orderRepo        *repository.OrderRepository
-	certManager      *cert.CertManager
+	gateways         gateway.Provider
 	lockManager      *lock.DistributedLock
 	alipayService    *service.AlipayService
 	blacklistService *service.BlacklistService
+	identities       *service.IdentityResolver
+	notifier         *service.ComplaintNotifier
+	watermarks       *watermark.Store
+	retryService     *service.RetryService
+	membership       *cluster.Membership // 为nil时单实例运行，负责所有主体
 	logger           *zap.Logger
 
 	workers       map[int]*SubjectWorker // subject_id -> worker
@@ -41,10 +51,15 @@ func NewManager(
 	complaintRepo *repository.ComplaintRepository,
 	blacklistRepo *repository.BlacklistRepository,
 	orderRepo *repository.OrderRepository,
-	certManager *cert.CertManager,
+	gateways gateway.Provider,
 	lockManager *lock.DistributedLock,
 	alipayService *service.AlipayService,
 	blacklistService *service.BlacklistService,
+	identities *service.IdentityResolver,
+	notifier *service.ComplaintNotifier,
+	watermarks *watermark.Store,
+	retryService *service.RetryService,
+	membership *cluster.Membership,
 	logger *zap.Logger,
 ) *Manager {
 	return &Manager{
@@ -53,10 +68,15 @@ func NewManager(
 		complaintRepo:    complaintRepo,
 		blacklistRepo:    blacklistRepo,
 		orderRepo:        orderRepo,
-		certManager:      certManager,
+		gateways:         gateways,
 		lockManager:      lockManager,
 		alipayService:    alipayService,
 		blacklistService: blacklistService,
+		identities:       identities,
+		notifier:         notifier,
+		watermarks:       watermarks,
+		retryService:     retryService,
+		membership:       membership,
 		logger:           logger,
 		workers:          make(map[int]*SubjectWorker),
 		stopChan:         make(chan struct{}),
@@ -76,6 +96,12 @@ func (m *Manager) Start(ctx context.Context) {
 	m.refreshTicker = time.NewTicker(m.cfg.Worker.GetRefreshInterval())
 	defer m.refreshTicker.Stop()
 
+	// 集群成员变化时立即重新分配主体（单实例时为nil通道，永不触发）
+	var membershipChanges <-chan struct{}
+	if m.membership != nil {
+		membershipChanges = m.membership.Changes()
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -92,6 +118,12 @@ func (m *Manager) Start(ctx context.Context) {
 			if err := m.refreshWorkers(ctx); err != nil {
 				m.logger.Error("刷新主体列表失败", zap.Error(err))
 			}
+
+		case <-membershipChanges:
+			m.logger.Info("集群成员变化，重新分配主体")
+			if err := m.refreshWorkers(ctx); err != nil {
+				m.logger.Error("刷新主体列表失败", zap.Error(err))
+			}
 		}
 	}
 }
@@ -108,6 +140,10 @@ func (m *Manager) refreshWorkers(ctx context.Context) error {
 
 	m.logger.Info("查询到激活主体", zap.Int("count", len(subjects)))
 
+	// 多实例部署时只保留分配给当前实例的主体
+	totalSubjects := len(subjects)
+	subjects = m.ownedSubjects(subjects)
+
 	// 构建当前应该存在的主体ID集合
 	currentSubjectIDs := make(map[int]bool)
 	for _, subject := range subjects {
@@ -120,7 +156,7 @@ func (m *Manager) refreshWorkers(ctx context.Context) error {
 	// 停止已不存在或不活跃的Worker
 	for subjectID, worker := range m.workers {
 		if !currentSubjectIDs[subjectID] {
-			m.logger.Info("停止Worker（主体已禁用或删除）", zap.Int("subject_id", subjectID))
+			m.logger.Info("停止Worker（主体已禁用、删除或已分配给其他实例）", zap.Int("subject_id", subjectID))
 			worker.Stop()
 			delete(m.workers, subjectID)
 		}
@@ -138,11 +174,27 @@ func (m *Manager) refreshWorkers(ctx context.Context) error {
 
 	m.logger.Info("主体列表刷新完成",
 		zap.Int("active_workers", len(m.workers)),
-		zap.Int("total_subjects", len(subjects)))
+		zap.Int("owned_subjects", len(subjects)),
+		zap.Int("total_subjects", totalSubjects))
 
 	return nil
 }
 
+// ownedSubjects 过滤出归当前实例负责的主体
+func (m *Manager) ownedSubjects(subjects []*model.Subject) []*model.Subject {
+	if m.membership == nil {
+		return subjects
+	}
+
+	owned := make([]*model.Subject, 0, len(subjects))
+	for _, subject := range subjects {
+		if m.membership.Owns(subject.ID) {
+			owned = append(owned, subject)
+		}
+	}
+	return owned
+}
+
 // createWorker 创建Worker
 func (m *Manager) createWorker(subject *model.Subject) *SubjectWorker {
 	return NewSubjectWorker(
@@ -151,16 +203,74 @@ func (m *Manager) createWorker(subject *model.Subject) *SubjectWorker {
 		m.complaintRepo,
 		m.blacklistRepo,
 		m.orderRepo,
-		m.certManager,
+		m.gateways,
 		m.lockManager,
 		m.alipayService,
 		m.blacklistService,
+		m.identities,
+		m.notifier,
+		m.watermarks,
+		m.retryService,
 		m.cfg.Worker.GetFetchInterval(),
+		m.cfg.Worker.FullScanDays,
+		m.cfg.Worker.GetSyncOverlap(),
 		m.cfg.Worker.Restartable,
 		m.logger,
 	)
 }
 
+// HandleComplaintNotification 处理支付宝推送的投诉通知
+// 通知只携带投诉主表ID，先查询详情得到投诉单号和状态，再携带详情进入与轮询相同的处理流程（分布式锁保证与轮询不重复处理）
+// 处理失败时与轮询一样写入重试队列，同时返回错误由支付宝重新推送
+func (m *Manager) HandleComplaintNotification(ctx context.Context, subject *model.Subject, gw gateway.ComplaintGateway, notification *gateway.ComplaintNotification) error {
+	detail, err := m.alipayService.FetchComplaintDetail(ctx, gw, gateway.ComplaintDetailRequest{
+		ComplaintEventID: strconv.FormatInt(notification.AlipayComplainId, 10),
+	})
+	if err != nil {
+		return fmt.Errorf("获取投诉详情失败: %w", err)
+	}
+	if detail.ComplaintEventID == "" {
+		return fmt.Errorf("投诉详情缺少投诉单号: alipay_complain_id=%d", notification.AlipayComplainId)
+	}
+
+	item := detail.ToItem(notification.AlipayComplainId)
+
+	m.logger.Info("处理投诉推送通知",
+		zap.Int("subject_id", subject.ID),
+		zap.String("notify_id", notification.NotifyID),
+		zap.Int64("alipay_complain_id", notification.AlipayComplainId),
+		zap.String("alipay_task_id", item.ComplaintEventID),
+		zap.String("status", item.Status),
+	)
+
+	err = m.workerFor(subject).processComplaint(ctx, gw, item, detail, model.StatusChangeSourceNotify)
+	if errors.Is(err, errComplaintBusy) {
+		// 其他Worker正在处理该投诉，轮询会兜底处理之后的变化
+		return nil
+	}
+	if err != nil {
+		if retryErr := m.retryService.RecordFailure(subject, item, err); retryErr != nil {
+			m.logger.Error("写入重试队列失败",
+				zap.String("alipay_task_id", item.ComplaintEventID),
+				zap.Error(retryErr))
+		}
+		return err
+	}
+	return nil
+}
+
+// workerFor 获取主体的Worker（主体不归当前实例负责或Worker尚未启动时创建临时Worker，不参与轮询）
+func (m *Manager) workerFor(subject *model.Subject) *SubjectWorker {
+	m.workersMutex.RLock()
+	worker, exists := m.workers[subject.ID]
+	m.workersMutex.RUnlock()
+
+	if exists {
+		return worker
+	}
+	return m.createWorker(subject)
+}
+
 // stopAllWorkers 停止所有Worker
 func (m *Manager) stopAllWorkers() {
 	m.workersMutex.Lock()
@@ -183,6 +293,11 @@ func (m *Manager) Stop() {
 	close(m.stopChan)
 }
 
+// RequestFullRescan 请求主体在下一次轮询时执行全量扫描
+func (m *Manager) RequestFullRescan(ctx context.Context, subjectID int) error {
+	return m.watermarks.RequestFullRescan(ctx, subjectID)
+}
+
 // GetWorkerCount 获取Worker数量
 func (m *Manager) GetWorkerCount() int {
 	m.workersMutex.RLock()
@@ -200,8 +315,13 @@ func (m *Manager) GetWorkerStats() map[string]interface{} {
 		workerIDs = append(workerIDs, subjectID)
 	}
 
-	return map[string]interface{}{
+	stats := map[string]interface{}{
 		"total_workers": len(m.workers),
 		"worker_ids":    workerIDs,
 	}
+	if m.membership != nil {
+		stats["instance_id"] = m.membership.InstanceID()
+		stats["cluster_members"] = m.membership.Members()
+	}
+	return stats
 }
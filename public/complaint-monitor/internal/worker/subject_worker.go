@@ -2,27 +2,32 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"runtime/debug"
 	"strconv"
 	"time"
 
-	"complaint-monitor/internal/cert"
+	"complaint-monitor/internal/gateway"
 	"complaint-monitor/internal/lock"
 	"complaint-monitor/internal/model"
 	"complaint-monitor/internal/repository"
 	"complaint-monitor/internal/service"
+	"complaint-monitor/internal/watermark"
 
-	"github.com/smartwalle/alipay/v3"
 	"go.uber.org/zap"
 )
 
 // 配置常量（参考代码）
 const (
-	QueryDaysBack  = 10  // 查询过去N天的投诉数据
+	QueryDaysBack  = 10  // 全量扫描默认查询过去N天的投诉数据（未配置full_scan_days时使用）
 	AlipayPageSize = 200 // 支付宝投诉列表每页数量（最大200）
 )
 
+// errComplaintBusy 投诉正在被其他Worker处理（锁已被占用），本次未处理
+// 轮询和推送由持锁方处理即可；重试队列保持待重试，下次到期再处理
+var errComplaintBusy = errors.New("投诉正在被其他Worker处理")
+
 // SubjectWorker 主体Worker（负责单个主体的投诉监控）
 type SubjectWorker struct {
 	subject       *model.Subject
@@ -30,11 +35,17 @@ type SubjectWorker struct {
 	complaintRepo *repository.ComplaintRepository
 	blacklistRepo *repository.BlacklistRepository
 	orderRepo     *repository.OrderRepository
-	certManager   *cert.CertManager
+	gateways      gateway.Provider
 	lockManager   *lock.DistributedLock
 	alipayService *service.AlipayService
 	blacklistSvc  *service.BlacklistService
+	identities    *service.IdentityResolver
+	notifier      *service.ComplaintNotifier
+	watermarks    *watermark.Store
+	retryService  *service.RetryService
 	fetchInterval time.Duration
+	fullScanDays  int
+	syncOverlap   time.Duration
 	restartable   bool
 	logger        *zap.Logger
 	stopChan      chan struct{}
@@ -47,25 +58,41 @@ func NewSubjectWorker(
 	complaintRepo *repository.ComplaintRepository,
 	blacklistRepo *repository.BlacklistRepository,
 	orderRepo *repository.OrderRepository,
-	certManager *cert.CertManager,
+	gateways gateway.Provider,
 	lockManager *lock.DistributedLock,
 	alipayService *service.AlipayService,
 	blacklistSvc *service.BlacklistService,
+	identities *service.IdentityResolver,
+	notifier *service.ComplaintNotifier,
+	watermarks *watermark.Store,
+	retryService *service.RetryService,
 	fetchInterval time.Duration,
+	fullScanDays int,
+	syncOverlap time.Duration,
 	restartable bool,
 	logger *zap.Logger,
 ) *SubjectWorker {
+	if fullScanDays <= 0 {
+		fullScanDays = QueryDaysBack
+	}
+
 	return &SubjectWorker{
 		subject:       subject,
 		subjectRepo:   subjectRepo,
 		complaintRepo: complaintRepo,
 		blacklistRepo: blacklistRepo,
 		orderRepo:     orderRepo,
-		certManager:   certManager,
+		gateways:      gateways,
 		lockManager:   lockManager,
 		alipayService: alipayService,
 		blacklistSvc:  blacklistSvc,
+		identities:    identities,
+		notifier:      notifier,
+		watermarks:    watermarks,
+		retryService:  retryService,
 		fetchInterval: fetchInterval,
+		fullScanDays:  fullScanDays,
+		syncOverlap:   syncOverlap,
 		restartable:   restartable,
 		logger:        logger.With(zap.Int("subject_id", subject.ID), zap.String("app_id", subject.AlipayAppID)),
 		stopChan:      make(chan struct{}),
@@ -131,18 +158,17 @@ func (w *SubjectWorker) processOnce(ctx context.Context) {
 	processCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
 
-	// 加载证书
-	client, err := w.certManager.LoadCert(w.subject)
+	// 加载证书并创建投诉网关
+	gw, err := w.gateways.Gateway(w.subject)
 	if err != nil {
 		w.logger.Error("加载证书失败", zap.Error(err))
 		return
 	}
 
-	// 计算查询时间范围（参考代码：过去N天到明天）
-	// 参考代码：calculateQueryTimeRange()
-	// 计算开始时间（N天前）
+	// 计算查询时间范围
+	// 有水位时增量查询（水位 - 安全重叠时间 到 明天），首次启动或手动触发时全量扫描（过去N天到明天）
 	now := time.Now()
-	beginTime := now.AddDate(0, 0, -QueryDaysBack)
+	beginTime, fullScan, rescanRequest := w.resolveQueryBegin(processCtx, now)
 	// 计算结束时间（明天）
 	endTime := now.AddDate(0, 0, 1)
 
@@ -150,13 +176,18 @@ func (w *SubjectWorker) processOnce(ctx context.Context) {
 	beginTimeStr := beginTime.Format("2006-01-02 15:04:05")
 	endTimeStr := endTime.Format("2006-01-02 15:04:05")
 
+	scanMode := "增量"
+	if fullScan {
+		scanMode = "全量"
+	}
+
 	// 打印详细的查询条件（控制台输出 + 日志）
 	fmt.Printf("\n=== 开始获取投诉列表 ===\n")
 	fmt.Printf("主体ID: %d\n", w.subject.ID)
 	fmt.Printf("AppID: %s\n", w.subject.AlipayAppID)
 	fmt.Printf("当前时间: %s\n", now.Format("2006-01-02 15:04:05"))
-	fmt.Printf("查询天数: %d 天前\n", QueryDaysBack)
-	fmt.Printf("查询开始时间: %s (计算: 当前时间 - %d 天)\n", beginTimeStr, QueryDaysBack)
+	fmt.Printf("扫描模式: %s\n", scanMode)
+	fmt.Printf("查询开始时间: %s\n", beginTimeStr)
 	fmt.Printf("查询结束时间: %s (计算: 当前时间 + 1 天)\n", endTimeStr)
 	fmt.Printf("时间范围: %s 至 %s\n", beginTimeStr, endTimeStr)
 	fmt.Printf("页大小: %d\n", AlipayPageSize)
@@ -166,37 +197,127 @@ func (w *SubjectWorker) processOnce(ctx context.Context) {
 		zap.Int("subject_id", w.subject.ID),
 		zap.String("app_id", w.subject.AlipayAppID),
 		zap.String("current_time", now.Format("2006-01-02 15:04:05")),
-		zap.Int("query_days_back", QueryDaysBack),
+		zap.String("scan_mode", scanMode),
 		zap.String("begin_time", beginTimeStr),
 		zap.String("end_time", endTimeStr),
 		zap.String("time_range", fmt.Sprintf("%s 至 %s", beginTimeStr, endTimeStr)),
 		zap.Int("page_size", AlipayPageSize),
 	)
 
-	// 分页查询投诉列表
+	// 1. 按投诉时间范围查询（发现新投诉）
+	listReq := gateway.ComplaintListRequest{
+		BeginTime: beginTimeStr,
+		EndTime:   endTimeStr,
+	}
+	totalProcessed, totalFailed, err := w.scanComplaintList(processCtx, gw, listReq)
+	if err != nil {
+		return // API调用失败，等待下次重试
+	}
+
+	// 2. 按处理时间范围查询（发现较早投诉的状态变更，如超时、撤诉）
+	// 增量模式下按投诉时间只能查到新投诉，已入库投诉的状态变化需要通过处理时间发现
+	processReq := gateway.ComplaintListRequest{
+		BeginTime:        now.AddDate(0, 0, -w.fullScanDays).Format("2006-01-02 15:04:05"),
+		EndTime:          endTimeStr,
+		ProcessBeginTime: beginTimeStr,
+		ProcessEndTime:   endTimeStr,
+	}
+	processed, failed, err := w.scanComplaintList(processCtx, gw, processReq)
+	if err != nil {
+		return // API调用失败，等待下次重试
+	}
+	totalProcessed += processed
+	totalFailed += failed
+
+	// 3. 重试到期的失败投诉（不受查询时间范围限制）
+	w.processRetries(processCtx, gw)
+
+	w.logger.Info("投诉处理完成",
+		zap.Int("total_processed", totalProcessed),
+		zap.Int("total_failed", totalFailed),
+	)
+
+	// 失败的投诉已写入重试队列时由重试队列负责，只有写入重试队列也失败时才不推进水位，下次轮询重新覆盖该时间范围
+	if totalFailed > 0 {
+		w.logger.Warn("存在处理失败的投诉，本次不推进同步水位",
+			zap.Int("total_failed", totalFailed),
+		)
+		return
+	}
+
+	// 更新同步水位（使用本次查询开始前的时间，保证查询期间新增的投诉下次仍能覆盖）
+	if err := w.watermarks.Save(processCtx, w.subject.ID, now, fullScan); err != nil {
+		w.logger.Error("保存同步水位失败", zap.Error(err))
+		return
+	}
+
+	// 手动触发的全量扫描成功完成后才清除请求，中途失败时下次轮询重新执行
+	if rescanRequest != "" {
+		if err := w.watermarks.ClearFullRescanRequest(processCtx, w.subject.ID, rescanRequest); err != nil {
+			w.logger.Warn("清除全量扫描请求失败", zap.Error(err))
+		}
+	}
+}
+
+// resolveQueryBegin 计算本次查询的开始时间
+// 返回 fullScan=true 表示本次为全量扫描；rescanRequest 非空表示本次响应手动触发的全量扫描请求
+func (w *SubjectWorker) resolveQueryBegin(ctx context.Context, now time.Time) (time.Time, bool, string) {
+	fullScanBegin := now.AddDate(0, 0, -w.fullScanDays)
+
+	// 1. 是否有手动触发的全量扫描请求（扫描成功后才清除）
+	rescanRequest, err := w.watermarks.PeekFullRescanRequest(ctx, w.subject.ID)
+	if err != nil {
+		w.logger.Warn("读取全量扫描请求失败", zap.Error(err))
+	}
+	if rescanRequest != "" {
+		w.logger.Info("收到全量扫描请求，执行全量扫描")
+		return fullScanBegin, true, rescanRequest
+	}
+
+	// 2. 读取同步水位
+	syncTime, found, err := w.watermarks.Get(ctx, w.subject.ID)
+	if err != nil {
+		w.logger.Warn("读取同步水位失败，执行全量扫描", zap.Error(err))
+		return fullScanBegin, true, ""
+	}
+	if !found {
+		w.logger.Info("主体没有同步水位（首次启动），执行全量扫描")
+		return fullScanBegin, true, ""
+	}
+
+	// 3. 增量查询：水位向前重叠一段安全时间，避免支付宝侧延迟入库导致漏单
+	beginTime := syncTime.Add(-w.syncOverlap)
+	if beginTime.Before(fullScanBegin) {
+		// 水位过旧（长时间停机），最多回溯全量扫描的时间范围
+		return fullScanBegin, true, ""
+	}
+
+	return beginTime, false, ""
+}
+
+// scanComplaintList 分页查询投诉列表并逐条处理
+// failed 为处理失败且未能写入重试队列的数量
+// 返回 err 表示列表API调用失败（本次轮询应中止）
+func (w *SubjectWorker) scanComplaintList(ctx context.Context, gw gateway.ComplaintGateway, baseReq gateway.ComplaintListRequest) (processed int, failed int, err error) {
 	// 根据参考代码，使用较大的页大小以提高效率
 	pageNum := 1
 	pageSize := AlipayPageSize // 使用参考代码中的最大页大小（200）
-	totalProcessed := 0
-	totalFailed := 0
 
 	for {
 		// 构建请求
-		listReq := service.ComplaintListRequest{
-			BeginTime: beginTimeStr,
-			EndTime:   endTimeStr,
-			PageNum:   pageNum,
-			PageSize:  pageSize,
-		}
+		listReq := baseReq
+		listReq.PageNum = pageNum
+		listReq.PageSize = pageSize
 
 		// 调用投诉列表API
-		listResp, err := w.alipayService.FetchComplaintList(client, listReq)
+		listResp, err := w.alipayService.FetchComplaintList(ctx, gw, listReq)
 		if err != nil {
 			w.logger.Error("获取投诉列表失败",
 				zap.Int("page_num", pageNum),
+				zap.String("process_begin_time", listReq.ProcessBeginTime),
 				zap.Error(err),
 			)
-			return // API调用失败，等待下次重试
+			return processed, failed, err
 		}
 
 		// 处理投诉列表
@@ -225,14 +346,12 @@ func (w *SubjectWorker) processOnce(ctx context.Context) {
 			// 使用投诉主表主键ID（ComplaintID）查询详情和保存
 			// ComplaintEventID 是支付宝投诉单号（TaskId）
 			// 注意：ComplaintID 是 complaint_list 中的 id 字段，必须保存到数据库的 alipay_complain_id 字段
-			alipayComplainId := complaintItem.ComplaintID // 支付宝投诉主表ID（complaint_list中的id）
-			complaintID := fmt.Sprintf("%d", alipayComplainId) // 转换为字符串，用于查询详情
+			alipayComplainId := complaintItem.ComplaintID  // 支付宝投诉主表ID（complaint_list中的id）
 			alipayTaskId := complaintItem.ComplaintEventID // 支付宝投诉单号（TaskId）
 
 			// 记录从API获取的投诉ID值
 			w.logger.Info("处理投诉项",
 				zap.Int64("complaint_id_from_api", alipayComplainId),
-				zap.String("complaint_id_string", complaintID),
 				zap.String("alipay_task_id", alipayTaskId),
 				zap.String("status", complaintItem.Status),
 			)
@@ -254,16 +373,26 @@ func (w *SubjectWorker) processOnce(ctx context.Context) {
 				continue
 			}
 
-			err := w.processComplaint(processCtx, client, complaintID, alipayTaskId)
+			err := w.processComplaint(ctx, gw, complaintItem, nil, model.StatusChangeSourcePoll)
+			if errors.Is(err, errComplaintBusy) {
+				continue
+			}
 			if err != nil {
 				w.logger.Error("处理投诉失败",
 					zap.Int64("complaint_id", complaintItem.ComplaintID),
 					zap.String("alipay_task_id", alipayTaskId),
 					zap.Error(err),
 				)
-				totalFailed++
+				// 写入重试队列，由重试队列按退避策略重试
+				if retryErr := w.retryService.RecordFailure(w.subject, complaintItem, err); retryErr != nil {
+					w.logger.Error("写入重试队列失败",
+						zap.String("alipay_task_id", alipayTaskId),
+						zap.Error(retryErr),
+					)
+					failed++
+				}
 			} else {
-				totalProcessed++
+				processed++
 			}
 		}
 
@@ -276,12 +405,50 @@ func (w *SubjectWorker) processOnce(ctx context.Context) {
 		pageNum++
 	}
 
-	w.logger.Info("投诉处理完成",
-		zap.Int("total_processed", totalProcessed),
-		zap.Int("total_failed", totalFailed),
-	)
+	return processed, failed, nil
+}
+
+// processRetries 重试到期的失败投诉
+func (w *SubjectWorker) processRetries(ctx context.Context, gw gateway.ComplaintGateway) {
+	retries, err := w.retryService.DueRetries(w.subject.ID)
+	if err != nil {
+		w.logger.Error("查询待重试投诉失败", zap.Error(err))
+		return
+	}
+	if len(retries) == 0 {
+		return
+	}
+
+	w.logger.Info("开始重试失败投诉", zap.Int("count", len(retries)))
+
+	for _, retry := range retries {
+		// 重试时不携带状态，已入库的投诉会重新查询详情比对
+		item := gateway.ComplaintItem{
+			ComplaintID:      retry.AlipayComplainId,
+			ComplaintEventID: retry.AlipayTaskId,
+		}
 
-	// TODO: 更新最后查询时间到Redis
+		err := w.processComplaint(ctx, gw, item, nil, model.StatusChangeSourcePoll)
+		if errors.Is(err, errComplaintBusy) {
+			// 其他Worker正在处理，不计入失败次数，也不标记成功
+			continue
+		}
+		if err != nil {
+			w.logger.Warn("重试投诉失败",
+				zap.String("alipay_task_id", retry.AlipayTaskId),
+				zap.Int("attempts", retry.Attempts+1),
+				zap.Error(err),
+			)
+			if retryErr := w.retryService.RecordFailure(w.subject, item, err); retryErr != nil {
+				w.logger.Error("更新重试记录失败", zap.Error(retryErr))
+			}
+			continue
+		}
+
+		if err := w.retryService.MarkResolved(retry); err != nil {
+			w.logger.Error("标记重试成功失败", zap.Error(err))
+		}
+	}
 }
 
 // Stop 停止Worker
@@ -295,30 +462,33 @@ func (w *SubjectWorker) GetSubjectID() int {
 	return w.subject.ID
 }
 
-// processComplaint 处理单个投诉（仅入库，不处理业务逻辑）
-// complaintID: 投诉主表主键ID（用于查询详情API）
-// alipayTaskId: 支付宝投诉单号（TaskId，用于去重和唯一标识）
-func (w *SubjectWorker) processComplaint(ctx context.Context, client *alipay.Client, complaintID string, alipayTaskId string) error {
-	// 获取分布式锁（使用支付宝投诉单号作为锁的key）
+// processComplaint 处理单个投诉
+// 新投诉入库并拉黑；已入库投诉在状态或处理时间变化时更新并记录状态变更
+// item.ComplaintID: 投诉主表主键ID（用于查询详情API）
+// item.ComplaintEventID: 支付宝投诉单号（TaskId，用于去重和唯一标识）
+// source: 发现投诉的途径（model.StatusChangeSourcePoll/Notify），记录到状态变更记录
+// 投诉正在被其他Worker处理时返回 errComplaintBusy
+// detail 为调用方已查询的投诉详情（推送通知），为nil时在处理过程中查询
+func (w *SubjectWorker) processComplaint(ctx context.Context, gw gateway.ComplaintGateway, item gateway.ComplaintItem, detail *gateway.ComplaintDetailResponse, source string) error {
+	alipayTaskId := item.ComplaintEventID
+
+	// 持有分布式锁处理（使用支付宝投诉单号作为锁的key），处理期间自动续期
+	// 锁丢失时处理上下文被取消，写库时携带fencing token拒绝过期写入
 	lockKey := fmt.Sprintf("complaint:lock:%s", alipayTaskId)
-	lockResult, err := w.lockManager.AcquireLock(ctx, lockKey, 30)
-	if err != nil {
-		return fmt.Errorf("获取锁失败: %w", err)
-	}
-
-	if !lockResult.IsAcquired() {
+	err := w.lockManager.WithLock(ctx, lockKey, lock.Options{}, func(ctx context.Context, fenceToken int64) error {
+		return w.processComplaintLocked(ctx, gw, item, detail, source, fenceToken)
+	})
+	if errors.Is(err, lock.ErrNotAcquired) {
 		w.logger.Debug("投诉正在被其他Worker处理", zap.String("alipay_task_id", alipayTaskId))
-		return nil
+		return errComplaintBusy
 	}
+	return err
+}
 
-	// 确保释放锁
-	defer func() {
-		if err := w.lockManager.ReleaseLock(ctx, lockResult); err != nil {
-			w.logger.Error("释放锁失败",
-				zap.String("alipay_task_id", alipayTaskId),
-				zap.Error(err))
-		}
-	}()
+// processComplaintLocked 持有锁时处理单个投诉
+func (w *SubjectWorker) processComplaintLocked(ctx context.Context, gw gateway.ComplaintGateway, item gateway.ComplaintItem, detail *gateway.ComplaintDetailResponse, source string, fenceToken int64) error {
+	complaintID := fmt.Sprintf("%d", item.ComplaintID) // 转换为字符串，用于查询详情
+	alipayTaskId := item.ComplaintEventID
 
 	w.logger.Info("开始处理投诉",
 		zap.String("complaint_id", complaintID),
@@ -331,18 +501,18 @@ func (w *SubjectWorker) processComplaint(ctx context.Context, client *alipay.Cli
 		return fmt.Errorf("查询投诉失败: %w", err)
 	}
 	if existing != nil {
-		w.logger.Debug("投诉已存在，跳过", zap.String("alipay_task_id", alipayTaskId))
-		return nil
+		if existing.ComplaintStatus == item.Status && existing.GmtModified == item.GmtModified {
+			w.logger.Debug("投诉已存在且状态未变化，跳过", zap.String("alipay_task_id", alipayTaskId))
+			return nil
+		}
+		existing.FenceToken = fenceToken
+		return w.updateComplaintStatus(ctx, gw, existing, complaintID, detail, source)
 	}
 
 	// 2. 获取投诉详情（使用投诉主表主键ID）
-	detailReq := service.ComplaintDetailRequest{
-		ComplaintEventID: complaintID, // 这里传入的是投诉主表主键ID
-	}
-
-	detailResp, err := w.alipayService.FetchComplaintDetail(client, detailReq)
+	detailResp, err := w.complaintDetail(ctx, gw, complaintID, detail)
 	if err != nil {
-		return fmt.Errorf("获取投诉详情失败: %w", err)
+		return err
 	}
 
 	// 3. 解析投诉时间
@@ -405,6 +575,7 @@ func (w *SubjectWorker) processComplaint(ctx context.Context, client *alipay.Cli
 		ComplainantID:    detailResp.ComplainantID,
 		ComplaintTime:    complaintTime,
 		ComplaintReason:  detailResp.ComplaintReason,
+		RefundAmount:     detailResp.RefundAmount(),
 		GmtCreate:        detailResp.GmtCreate,
 		GmtModified:      detailResp.GmtModified,
 	}
@@ -445,6 +616,7 @@ func (w *SubjectWorker) processComplaint(ctx context.Context, client *alipay.Cli
 	}
 
 	// 6. 保存到数据库（事务）
+	complaint.FenceToken = fenceToken
 	err = w.complaintRepo.CreateWithDetails(complaint, details)
 	if err != nil {
 		return fmt.Errorf("保存投诉数据失败: %w", err)
@@ -466,8 +638,39 @@ func (w *SubjectWorker) processComplaint(ctx context.Context, client *alipay.Cli
 		)
 	}
 
-	// 7. 根据订单号查询订单，获取购买者UID并拉黑
-	err = w.processBlacklistFromOrders(detailResp.TargetOrderList, alipayTaskId)
+	// 7. 解析并记录投诉买家身份（买家投诉画像按此汇总；无法确定时为空，不拉黑）
+	identities := w.resolveComplaintBuyers(ctx, gw, complaint, detailResp.TargetOrderList)
+
+	// 8. 按拉黑规则决定是否拉黑（如撤诉、首次未收到货等投诉不拉黑或转人工审核）
+	complaintAmount := 0.0
+	for _, detail := range details {
+		complaintAmount += detail.OrderAmount
+	}
+	buyerIDs := make([]string, 0, len(identities))
+	for _, identity := range identities {
+		buyerIDs = append(buyerIDs, identity.BuyerID)
+	}
+	decision := w.blacklistSvc.DecideForComplaint(complaint, complaintAmount, buyerIDs)
+
+	// 9. 推送新投诉通知（附带买家风险等级和历史投诉次数，失败不影响投诉处理）
+	if err := w.notifier.Notify(complaint, details, w.subject, buyerIDs, decision.ShouldBlock()); err != nil {
+		w.logger.Error("推送投诉通知失败",
+			zap.String("alipay_task_id", alipayTaskId),
+			zap.Error(err),
+		)
+	}
+
+	if !decision.ShouldBlock() {
+		w.logger.Info("拉黑规则未要求拉黑，跳过拉黑",
+			zap.String("alipay_task_id", alipayTaskId),
+			zap.String("action", decision.Action),
+			zap.String("rule_name", decision.RuleName),
+		)
+		return nil
+	}
+
+	// 10. 根据订单号查询订单，获取购买者的IP和设备码并拉黑
+	err = w.processBlacklistFromOrders(complaint.ID, detailResp.TargetOrderList, identities, detailResp.ComplainantID, alipayTaskId, decision.RuleName)
 	if err != nil {
 		// 拉黑失败不影响投诉数据保存，只记录错误日志
 		w.logger.Error("处理拉黑失败",
@@ -480,9 +683,123 @@ func (w *SubjectWorker) processComplaint(ctx context.Context, client *alipay.Cli
 	return nil
 }
 
+// complaintDetail 获取投诉详情（调用方已查询时直接使用，避免重复调用限流API）
+// complaintID 为投诉主表主键ID
+func (w *SubjectWorker) complaintDetail(ctx context.Context, gw gateway.ComplaintGateway, complaintID string, detail *gateway.ComplaintDetailResponse) (*gateway.ComplaintDetailResponse, error) {
+	if detail != nil {
+		return detail, nil
+	}
+	detailResp, err := w.alipayService.FetchComplaintDetail(ctx, gw, gateway.ComplaintDetailRequest{
+		ComplaintEventID: complaintID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取投诉详情失败: %w", err)
+	}
+	return detailResp, nil
+}
+
+// updateComplaintStatus 更新已入库投诉的状态
+// 列表中的状态或处理时间与库中不一致时调用，重新获取详情后更新并写入状态变更记录
+func (w *SubjectWorker) updateComplaintStatus(ctx context.Context, gw gateway.ComplaintGateway, existing *model.Complaint, complaintID string, detail *gateway.ComplaintDetailResponse, source string) error {
+	detailResp, err := w.complaintDetail(ctx, gw, complaintID, detail)
+	if err != nil {
+		return err
+	}
+
+	// 已退款金额只增不减（商家处理投诉时也可能写入退款金额）
+	refundAmount := existing.RefundAmount
+	if detailRefund := detailResp.RefundAmount(); detailRefund > refundAmount {
+		refundAmount = detailRefund
+	}
+
+	if detailResp.Status == existing.ComplaintStatus &&
+		detailResp.GmtModified == existing.GmtModified &&
+		refundAmount == existing.RefundAmount {
+		// 列表与详情数据存在延迟，以详情为准
+		w.logger.Debug("投诉详情状态未变化，跳过更新", zap.String("alipay_task_id", existing.AlipayTaskId))
+		return nil
+	}
+
+	statusLog := &model.ComplaintStatusLog{
+		SubjectID:       w.subject.ID,
+		AlipayTaskId:    existing.AlipayTaskId,
+		FromStatus:      existing.ComplaintStatus,
+		ToStatus:        detailResp.Status,
+		FromGmtModified: existing.GmtModified,
+		ToGmtModified:   detailResp.GmtModified,
+		RefundAmount:    refundAmount,
+		Source:          source,
+	}
+
+	wasDropped := existing.IsDropped()
+	existing.ComplaintStatus = detailResp.Status
+	existing.GmtModified = detailResp.GmtModified
+	existing.RefundAmount = refundAmount
+
+	if err := w.complaintRepo.UpdateStatusWithLog(existing, statusLog); err != nil {
+		return fmt.Errorf("更新投诉状态失败: %w", err)
+	}
+
+	w.logger.Info("投诉状态已更新",
+		zap.String("alipay_task_id", existing.AlipayTaskId),
+		zap.Uint("complaint_id", existing.ID),
+		zap.String("from_status", statusLog.FromStatus),
+		zap.String("to_status", statusLog.ToStatus),
+		zap.String("gmt_modified", existing.GmtModified),
+		zap.Float64("refund_amount", refundAmount),
+	)
+
+	// 用户撤诉：撤销该投诉拉黑时新增或累加的黑名单记录（失败不影响状态更新）
+	if !wasDropped && existing.IsDropped() {
+		if err := w.blacklistSvc.ReleaseOnWithdrawal(existing); err != nil {
+			w.logger.Error("撤诉解除拉黑失败",
+				zap.String("alipay_task_id", existing.AlipayTaskId),
+				zap.Error(err),
+			)
+		}
+	}
+
+	return nil
+}
+
+// resolveComplaintBuyers 解析新投诉的买家身份（订单 buyer_id → 交易查询 → 投诉人字段，与主体支付宝PID相同的值不使用）并记录到投诉买家身份表
+// 解析或记录失败时只记录日志：解析失败返回空列表（不拉黑），记录失败不影响本次拉黑
+func (w *SubjectWorker) resolveComplaintBuyers(ctx context.Context, gw gateway.ComplaintGateway, complaint *model.Complaint, orderList []gateway.OrderItem) []service.BuyerIdentity {
+	identities, err := w.identities.Resolve(ctx, gw, w.subject, orderList, complaint.ComplainantID)
+	if err != nil {
+		w.logger.Error("解析投诉买家身份失败",
+			zap.String("alipay_task_id", complaint.AlipayTaskId),
+			zap.Error(err),
+		)
+		return nil
+	}
+
+	buyers := make([]*model.ComplaintBuyer, 0, len(identities))
+	for _, identity := range identities {
+		buyers = append(buyers, &model.ComplaintBuyer{
+			ComplaintID:    complaint.ID,
+			SubjectID:      complaint.SubjectID,
+			AlipayTaskId:   complaint.AlipayTaskId,
+			BuyerID:        identity.BuyerID,
+			IdentitySource: identity.Source,
+		})
+	}
+	if err := w.complaintRepo.SaveBuyers(buyers); err != nil {
+		w.logger.Error("记录投诉买家身份失败",
+			zap.String("alipay_task_id", complaint.AlipayTaskId),
+			zap.Error(err),
+		)
+	}
+	return identities
+}
+
 // processBlacklistFromOrders 根据订单列表处理拉黑
-// alipayTaskId: 支付宝投诉单号（TaskId），用于日志和回退查询
-func (w *SubjectWorker) processBlacklistFromOrders(orderList []service.OrderItem, alipayTaskId string) error {
+// complaintID: 投诉主表ID（记录本次拉黑写入的黑名单记录，撤诉时据此撤销）
+// identities: 投诉入库时解析出的买家身份（为空时不拉黑）
+// complainantID: 投诉详情中的投诉人字段（用于日志）
+// alipayTaskId: 支付宝投诉单号（TaskId），用于日志
+// ruleName: 命中的拉黑规则名称（记录到黑名单审计）
+func (w *SubjectWorker) processBlacklistFromOrders(complaintID uint, orderList []gateway.OrderItem, identities []service.BuyerIdentity, complainantID, alipayTaskId, ruleName string) error {
 	if len(orderList) == 0 {
 		w.logger.Debug("投诉订单列表为空，跳过拉黑",
 			zap.String("alipay_task_id", alipayTaskId),
@@ -490,11 +807,13 @@ func (w *SubjectWorker) processBlacklistFromOrders(orderList []service.OrderItem
 		return nil
 	}
 
-	// 1. 提取订单号列表
+	// 1. 提取订单号列表，并汇总投诉金额（无法关联到买家订单时用于风险评估）
 	merchantOrderNos := make([]string, 0)
 	platformOrderNos := make([]string, 0)
+	complaintAmount := 0.0
 
 	for _, orderItem := range orderList {
+		complaintAmount += orderItem.Amount
 		if orderItem.OutTradeNo != "" {
 			merchantOrderNos = append(merchantOrderNos, orderItem.OutTradeNo)
 		}
@@ -503,40 +822,39 @@ func (w *SubjectWorker) processBlacklistFromOrders(orderList []service.OrderItem
 		}
 	}
 
-	// 2. 查询订单，获取购买者UID列表
-	buyerIDs, err := w.orderRepo.GetBuyerIDsByOrderNos(merchantOrderNos, platformOrderNos)
-	if err != nil {
-		w.logger.Warn("查询订单失败，回退使用ComplainantID",
-			zap.String("alipay_task_id", alipayTaskId),
-			zap.Error(err),
-		)
-		// 回退到使用ComplainantID（需要从投诉记录中获取）
-		return w.fallbackToComplainantID(alipayTaskId)
+	// 拉黑使用的基础请求（金额和订单数取整个投诉）
+	base := service.BlacklistRequest{
+		Subject:         w.subject,
+		ComplaintID:     complaintID,
+		ComplaintNo:     alipayTaskId,
+		RuleName:        ruleName,
+		ComplaintAmount: complaintAmount,
+		OrderCount:      len(orderList),
 	}
 
-	// 3. 如果查询不到buyer_id，回退使用ComplainantID
-	if len(buyerIDs) == 0 {
-		w.logger.Warn("未查询到购买者UID，回退使用ComplainantID",
+	// 2. 投诉买家身份（入库时已解析，与主体支付宝PID相同的值不拉黑）
+	if len(identities) == 0 {
+		w.logger.Warn("无法确定投诉买家身份，跳过拉黑",
 			zap.String("alipay_task_id", alipayTaskId),
+			zap.String("complainant_id", complainantID),
 			zap.Strings("merchant_order_nos", merchantOrderNos),
 			zap.Strings("platform_order_nos", platformOrderNos),
 		)
-		return w.fallbackToComplainantID(alipayTaskId)
+		return nil
 	}
 
-	// 4. 对每个购买者UID进行拉黑
+	// 3. 对每个买家进行拉黑
 	// 注意：需要根据订单号获取该用户的支付IP和设备码
 	successCount := 0
 	failedCount := 0
 
-	// 先查询所有相关订单，建立 buyer_id 到订单的映射
+	// 先查询所有相关订单，建立 buyer_id 到订单的映射（查询失败时不带IP和设备码拉黑）
 	orders, err := w.orderRepo.FindByOrderNos(merchantOrderNos, platformOrderNos)
 	if err != nil {
-		w.logger.Warn("批量查询订单失败，回退使用ComplainantID",
+		w.logger.Warn("批量查询订单失败，不带IP和设备码拉黑",
 			zap.String("alipay_task_id", alipayTaskId),
 			zap.Error(err),
 		)
-		return w.fallbackToComplainantID(alipayTaskId)
 	}
 
 	// 建立 buyer_id 到订单的映射（一个buyer_id可能对应多个订单）
@@ -547,20 +865,27 @@ func (w *SubjectWorker) processBlacklistFromOrders(orderList []service.OrderItem
 		}
 	}
 
-	for _, buyerID := range buyerIDs {
-		if buyerID == "" {
-			continue // 跳过空的buyer_id
-		}
+	for _, identity := range identities {
+		buyerID := identity.BuyerID
 
-		// 从订单表中获取该用户的支付IP和设备码
-		// 根据用户要求：IP和设备码都来自于订单表
+		// 从订单中获取该用户的支付IP和设备码
 		// - 支付IP：从订单表的 pay_ip 字段获取（优先），如果没有则使用 first_open_ip
-		// - 设备码：订单表中暂时没有设备码字段，使用空字符串
+		// - 设备码：从订单日志（order_log）中买家访问时记录的设备码获取，没有时为空（存储NULL）
 		ipAddress := ""
-		deviceCode := "" // 订单表中暂时没有设备码字段，使用空字符串
+		deviceCode := ""
+
+		// 风险评估使用该买家在本次投诉中涉及的订单金额和订单数
+		buyerAmount := complaintAmount
+		buyerOrderCount := len(orderList)
 
 		// 从该buyer_id对应的订单中获取IP地址（优先使用支付IP）
 		if buyerOrders, exists := buyerOrderMap[buyerID]; exists && len(buyerOrders) > 0 {
+			buyerAmount = 0
+			for _, order := range buyerOrders {
+				buyerAmount += order.OrderAmount
+			}
+			buyerOrderCount = len(buyerOrders)
+
 			// 遍历该用户的所有订单，优先使用支付IP（pay_ip）
 			// 如果所有订单都没有支付IP，则使用第一个订单的首次打开IP（first_open_ip）
 			for _, order := range buyerOrders {
@@ -579,12 +904,21 @@ func (w *SubjectWorker) processBlacklistFromOrders(orderList []service.OrderItem
 					}
 				}
 			}
-		} else {
+			// 设备码使用该用户第一个有设备码的订单
+			for _, order := range buyerOrders {
+				if order.DeviceCode != "" {
+					deviceCode = order.DeviceCode
+					break
+				}
+			}
+		} else if identity.Source != model.IdentitySourceComplainant {
 			// 如果没有找到对应的订单，尝试从订单号列表查询（使用第一个订单号）
+			// 投诉人字段无法确认与订单的对应关系，不使用订单的IP和设备码
 			if len(merchantOrderNos) > 0 {
-				orderIP, _, err := w.orderRepo.GetOrderIPAndDevice(merchantOrderNos[0], "")
-				if err == nil && orderIP != "" {
+				orderIP, orderDevice, err := w.orderRepo.GetOrderIPAndDevice(merchantOrderNos[0], "")
+				if err == nil {
 					ipAddress = orderIP
+					deviceCode = orderDevice
 				}
 			}
 		}
@@ -592,18 +926,20 @@ func (w *SubjectWorker) processBlacklistFromOrders(orderList []service.OrderItem
 		w.logger.Info("准备拉黑用户",
 			zap.String("alipay_task_id", alipayTaskId),
 			zap.String("buyer_id", buyerID),
+			zap.String("identity_source", identity.Source),
 			zap.String("pay_ip", ipAddress),
 			zap.String("device_code", deviceCode),
 		)
 
 		// 调用拉黑服务
-		err := w.blacklistSvc.AddToBlacklist(
-			w.subject, // 主体信息（用于消息通知）
-			buyerID,
-			deviceCode, // 设备码（订单表中暂时没有，使用空字符串）
-			ipAddress,  // 支付IP（从订单表的pay_ip字段获取）
-			alipayTaskId,
-		)
+		req := base
+		req.AlipayUserID = buyerID
+		req.DeviceCode = deviceCode // 设备码（从订单日志获取，没有时为空）
+		req.IPAddress = ipAddress   // 支付IP（从订单表的pay_ip字段获取）
+		req.ComplaintAmount = buyerAmount
+		req.OrderCount = buyerOrderCount
+		req.IdentitySource = identity.Source
+		err := w.blacklistSvc.AddToBlacklist(req)
 		if err != nil {
 			failedCount++
 			w.logger.Error("拉黑失败",
@@ -627,50 +963,10 @@ func (w *SubjectWorker) processBlacklistFromOrders(orderList []service.OrderItem
 
 	w.logger.Info("投诉拉黑处理完成",
 		zap.String("alipay_task_id", alipayTaskId),
-		zap.Int("total_buyer_ids", len(buyerIDs)),
+		zap.Int("total_buyer_ids", len(identities)),
 		zap.Int("success_count", successCount),
 		zap.Int("failed_count", failedCount),
 	)
 
 	return nil
 }
-
-// fallbackToComplainantID 回退使用ComplainantID进行拉黑
-// alipayTaskId: 支付宝投诉单号（TaskId）
-func (w *SubjectWorker) fallbackToComplainantID(alipayTaskId string) error {
-	// 查询投诉记录获取ComplainantID（使用支付宝投诉单号查询）
-	complaint, err := w.complaintRepo.FindByAlipayTaskId(w.subject.ID, alipayTaskId)
-	if err != nil {
-		return fmt.Errorf("查询投诉记录失败: %w", err)
-	}
-
-	if complaint == nil {
-		return fmt.Errorf("投诉记录不存在: alipay_task_id=%s", alipayTaskId)
-	}
-
-	if complaint.ComplainantID == "" {
-		w.logger.Warn("ComplainantID为空，无法拉黑",
-			zap.String("alipay_task_id", alipayTaskId),
-		)
-		return nil
-	}
-
-	// 使用ComplainantID进行拉黑
-	err = w.blacklistSvc.AddToBlacklist(
-		w.subject, // 主体信息（用于消息通知）
-		complaint.ComplainantID,
-		"", // 设备码为空
-		"", // IP地址为空
-		alipayTaskId,
-	)
-	if err != nil {
-		return fmt.Errorf("使用ComplainantID拉黑失败: %w", err)
-	}
-
-	w.logger.Info("使用ComplainantID拉黑成功",
-		zap.String("alipay_task_id", alipayTaskId),
-		zap.String("complainant_id", complaint.ComplainantID),
-	)
-
-	return nil
-}
@@ -1,6 +1,8 @@
 package metrics
 
 import (
+	"strconv"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
@@ -47,6 +49,46 @@ var (
 		Help: "添加黑名单的总次数",
 	}, []string{"subject_id", "risk_level"})
 
+	BlacklistReleaseTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "complaint_monitor_blacklist_release_total",
+		Help: "解除黑名单的总次数",
+	}, []string{"reason"})
+
+	BlacklistRangeAddTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "complaint_monitor_blacklist_range_add_total",
+		Help: "添加黑名单网段的总次数",
+	}, []string{"source"})
+
+	BlacklistAssociatedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "complaint_monitor_blacklist_associated_total",
+		Help: "按买家关联团伙拉黑的总次数",
+	}, []string{"risk_level"})
+
+	BlacklistAllowlistedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "complaint_monitor_blacklist_allowlisted_total",
+		Help: "买家命中白名单跳过拉黑的总次数",
+	}, []string{"subject_id", "match_type"})
+
+	BlacklistIdentityTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "complaint_monitor_blacklist_identity_total",
+		Help: "投诉买家身份解析的总次数（按身份来源和结果）",
+	}, []string{"subject_id", "source", "result"})
+
+	ComplaintNotificationTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "complaint_monitor_complaint_notification_total",
+		Help: "新投诉通知的总次数（按处理结果）",
+	}, []string{"subject_id", "result"})
+
+	BuyerClusterTotal = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "complaint_monitor_buyer_cluster_total",
+		Help: "最近一次关联分析发现的买家团伙数",
+	}, []string{"flagged"})
+
+	BlacklistDecisionTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "complaint_monitor_blacklist_decision_total",
+		Help: "拉黑规则决策的总次数",
+	}, []string{"subject_id", "action", "rule"})
+
 	BlacklistTotal = promauto.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "complaint_monitor_blacklist_total",
 		Help: "当前黑名单总数",
@@ -125,6 +167,33 @@ var (
 		Buckets: []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5},
 	}, []string{"command"})
 
+	// 支付宝API限流指标
+	RateLimitWaitTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "complaint_monitor_ratelimit_wait_total",
+		Help: "因限流等待令牌的总次数",
+	}, []string{"app_id", "api"})
+
+	RateLimitWaitDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "complaint_monitor_ratelimit_wait_duration_seconds",
+		Help:    "获取令牌的等待时长（秒）",
+		Buckets: []float64{0, 0.05, 0.1, 0.5, 1, 2, 5, 10, 30, 60},
+	}, []string{"app_id", "api"})
+
+	RateLimitThrottledTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "complaint_monitor_ratelimit_throttled_total",
+		Help: "支付宝返回限流错误的总次数",
+	}, []string{"app_id", "api"})
+
+	RateLimitBackoffSeconds = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "complaint_monitor_ratelimit_backoff_seconds",
+		Help: "当前退避时长（秒，0表示未退避）",
+	}, []string{"app_id", "api"})
+
+	RateLimitErrorTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "complaint_monitor_ratelimit_error_total",
+		Help: "限流器访问Redis失败的总次数（失败时放行）",
+	}, []string{"app_id", "api"})
+
 	// 系统指标
 	SystemUptime = promauto.NewGauge(prometheus.GaugeOpts{
 		Name: "complaint_monitor_system_uptime_seconds",
@@ -149,28 +218,72 @@ var (
 
 // RecordWorkerPanic 记录Worker Panic
 func RecordWorkerPanic(subjectID int, panicType string) {
-	WorkerPanicTotal.WithLabelValues(string(rune(subjectID)), panicType).Inc()
+	WorkerPanicTotal.WithLabelValues(strconv.Itoa(subjectID), panicType).Inc()
 }
 
 // RecordWorkerRestart 记录Worker重启
 func RecordWorkerRestart(subjectID int) {
-	WorkerRestartTotal.WithLabelValues(string(rune(subjectID))).Inc()
+	WorkerRestartTotal.WithLabelValues(strconv.Itoa(subjectID)).Inc()
 }
 
 // RecordComplaintFetch 记录投诉获取
 func RecordComplaintFetch(subjectID int, status string) {
-	ComplaintFetchTotal.WithLabelValues(string(rune(subjectID)), status).Inc()
+	ComplaintFetchTotal.WithLabelValues(strconv.Itoa(subjectID), status).Inc()
 }
 
 // RecordComplaintProcess 记录投诉处理
 func RecordComplaintProcess(subjectID int, status string, duration float64) {
-	ComplaintProcessTotal.WithLabelValues(string(rune(subjectID)), status).Inc()
-	ComplaintProcessDuration.WithLabelValues(string(rune(subjectID))).Observe(duration)
+	ComplaintProcessTotal.WithLabelValues(strconv.Itoa(subjectID), status).Inc()
+	ComplaintProcessDuration.WithLabelValues(strconv.Itoa(subjectID)).Observe(duration)
 }
 
 // RecordBlacklistAdd 记录添加黑名单
 func RecordBlacklistAdd(subjectID int, riskLevel string) {
-	BlacklistAddTotal.WithLabelValues(string(rune(subjectID)), riskLevel).Inc()
+	BlacklistAddTotal.WithLabelValues(strconv.Itoa(subjectID), riskLevel).Inc()
+}
+
+// RecordBlacklistRangeAdd 记录添加黑名单网段（source：manual 手动添加，auto 自动升级）
+func RecordBlacklistRangeAdd(source string) {
+	BlacklistRangeAddTotal.WithLabelValues(source).Inc()
+}
+
+// RecordBlacklistRelease 记录解除黑名单（reason：expired 过期，withdrawn 用户撤诉，manual 手动删除）
+func RecordBlacklistRelease(reason string) {
+	BlacklistReleaseTotal.WithLabelValues(reason).Inc()
+}
+
+// RecordBlacklistAssociated 记录按买家关联团伙拉黑
+func RecordBlacklistAssociated(riskLevel string) {
+	BlacklistAssociatedTotal.WithLabelValues(riskLevel).Inc()
+}
+
+// RecordBlacklistAllowlisted 记录买家命中白名单跳过拉黑（match_type：user/ip/device；关联分析拉黑时 subjectID 为0）
+func RecordBlacklistAllowlisted(subjectID int, matchType string) {
+	BlacklistAllowlistedTotal.WithLabelValues(strconv.Itoa(subjectID), matchType).Inc()
+}
+
+// RecordBlacklistIdentity 记录投诉买家身份解析结果（result：accepted 采用，rejected 与主体PID相同被拒绝）
+func RecordBlacklistIdentity(subjectID int, source, result string) {
+	BlacklistIdentityTotal.WithLabelValues(strconv.Itoa(subjectID), source, result).Inc()
+}
+
+// RecordComplaintNotification 记录新投诉通知结果（result：queued 已入队，failed 写入失败，其余为跳过原因）
+func RecordComplaintNotification(subjectID int, result string) {
+	ComplaintNotificationTotal.WithLabelValues(strconv.Itoa(subjectID), result).Inc()
+}
+
+// UpdateBuyerClusterTotal 更新买家团伙数（flagged 为包含黑名单账号的团伙数）
+func UpdateBuyerClusterTotal(total, flagged int) {
+	BuyerClusterTotal.WithLabelValues("true").Set(float64(flagged))
+	BuyerClusterTotal.WithLabelValues("false").Set(float64(total - flagged))
+}
+
+// RecordBlacklistDecision 记录拉黑规则决策（rule 为空表示使用默认动作）
+func RecordBlacklistDecision(subjectID int, action, rule string) {
+	if rule == "" {
+		rule = "default"
+	}
+	BlacklistDecisionTotal.WithLabelValues(strconv.Itoa(subjectID), action, rule).Inc()
 }
 
 // RecordNotificationPush 记录通知推送
@@ -180,7 +293,7 @@ func RecordNotificationPush(templateType, status string) {
 
 // RecordCertLoad 记录证书加载
 func RecordCertLoad(subjectID int, status string) {
-	CertLoadTotal.WithLabelValues(string(rune(subjectID)), status).Inc()
+	CertLoadTotal.WithLabelValues(strconv.Itoa(subjectID), status).Inc()
 }
 
 // RecordCertCacheHit 记录证书缓存命中
@@ -215,6 +328,29 @@ func RecordRedisCommand(command, status string, duration float64) {
 	RedisCommandDuration.WithLabelValues(command).Observe(duration)
 }
 
+// RecordRateLimitWait 记录获取令牌的耗时（waited 为是否因限流或退避实际等待过，只有等待过才计入等待次数）
+func RecordRateLimitWait(appID, api string, waited bool, seconds float64) {
+	if waited {
+		RateLimitWaitTotal.WithLabelValues(appID, api).Inc()
+	}
+	RateLimitWaitDuration.WithLabelValues(appID, api).Observe(seconds)
+}
+
+// RecordRateLimitThrottled 记录支付宝限流错误
+func RecordRateLimitThrottled(appID, api string) {
+	RateLimitThrottledTotal.WithLabelValues(appID, api).Inc()
+}
+
+// RecordRateLimitError 记录限流器Redis访问失败
+func RecordRateLimitError(appID, api string) {
+	RateLimitErrorTotal.WithLabelValues(appID, api).Inc()
+}
+
+// UpdateRateLimitBackoff 更新当前退避时长
+func UpdateRateLimitBackoff(appID, api string, seconds float64) {
+	RateLimitBackoffSeconds.WithLabelValues(appID, api).Set(seconds)
+}
+
 // UpdateSystemMetrics 更新系统指标
 func UpdateSystemMetrics(uptime float64, goroutines int, memoryUsage uint64, cpuUsage float64) {
 	SystemUptime.Set(uptime)
@@ -247,5 +383,5 @@ func UpdateDBConnectionTotal(idle, inUse, open int) {
 
 // UpdateBlacklistTotal 更新黑名单总数
 func UpdateBlacklistTotal(subjectID int, riskLevel string, total int64) {
-	BlacklistTotal.WithLabelValues(string(rune(subjectID)), riskLevel).Set(float64(total))
+	BlacklistTotal.WithLabelValues(strconv.Itoa(subjectID), riskLevel).Set(float64(total))
 }
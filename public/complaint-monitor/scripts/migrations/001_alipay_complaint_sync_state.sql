@@ -0,0 +1,12 @@
+-- 主体投诉同步水位表
+-- Redis 中的水位（complaint:watermark:{subject_id}）丢失时以此表兜底
+CREATE TABLE IF NOT EXISTS `alipay_complaint_sync_state` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `subject_id` int NOT NULL COMMENT '主体ID',
+  `last_sync_time` datetime DEFAULT NULL COMMENT '最后一次成功同步的时间（水位）',
+  `last_full_scan_time` datetime DEFAULT NULL COMMENT '最后一次全量扫描的时间',
+  `created_at` datetime DEFAULT NULL,
+  `updated_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uniq_subject_id` (`subject_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='主体投诉同步水位';
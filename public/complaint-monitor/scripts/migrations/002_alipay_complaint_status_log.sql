@@ -0,0 +1,21 @@
+-- 投诉状态变更记录表
+-- 轮询发现投诉状态或处理时间变化时写入，用于追溯投诉何时超时、何时撤诉
+CREATE TABLE IF NOT EXISTS `alipay_complaint_status_log` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `complaint_id` int unsigned NOT NULL COMMENT '投诉主表ID',
+  `subject_id` int NOT NULL COMMENT '主体ID',
+  `alipay_task_id` varchar(64) NOT NULL COMMENT '支付宝投诉单号（TaskId）',
+  `from_status` varchar(32) DEFAULT NULL COMMENT '变更前状态',
+  `to_status` varchar(32) DEFAULT NULL COMMENT '变更后状态',
+  `from_gmt_modified` varchar(32) DEFAULT NULL COMMENT '变更前处理时间',
+  `to_gmt_modified` varchar(32) DEFAULT NULL COMMENT '变更后处理时间',
+  `refund_amount` decimal(10,2) DEFAULT '0.00' COMMENT '变更后已退款金额',
+  `source` varchar(32) DEFAULT NULL COMMENT '变更来源（poll：轮询，manual：商家处理）',
+  `created_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_complaint_id` (`complaint_id`),
+  KEY `idx_subject_id` (`subject_id`),
+  KEY `idx_alipay_task_id` (`alipay_task_id`),
+  KEY `idx_to_status` (`to_status`),
+  KEY `idx_created_at` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='投诉状态变更记录';
@@ -0,0 +1,18 @@
+-- 投诉处理重试表
+-- 投诉处理失败时写入，按指数退避重试；超过最大重试次数后转为死信（dead），通过管理接口查看和重新入队
+CREATE TABLE IF NOT EXISTS `alipay_complaint_retry` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `subject_id` int NOT NULL COMMENT '主体ID',
+  `alipay_task_id` varchar(64) NOT NULL COMMENT '支付宝投诉单号（TaskId）',
+  `alipay_complain_id` bigint NOT NULL DEFAULT '0' COMMENT '支付宝投诉主表ID',
+  `status` varchar(16) NOT NULL COMMENT '状态（pending：等待重试，dead：死信，resolved：已恢复）',
+  `attempts` int NOT NULL DEFAULT '0' COMMENT '已失败次数',
+  `last_error` text COMMENT '最后一次失败原因',
+  `next_attempt_at` datetime DEFAULT NULL COMMENT '下次重试时间',
+  `dead_at` datetime DEFAULT NULL COMMENT '转入死信的时间',
+  `created_at` datetime DEFAULT NULL,
+  `updated_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uniq_subject_task` (`subject_id`, `alipay_task_id`),
+  KEY `idx_status_next` (`status`, `next_attempt_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='投诉处理重试队列';
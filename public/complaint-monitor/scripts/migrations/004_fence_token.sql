@@ -0,0 +1,9 @@
+-- fencing token 字段
+-- 处理投诉时持有分布式锁的fencing token（全局单调递增），写入时拒绝比已写入token更旧的写入，
+-- 避免锁过期后被其他实例接管时，原持有者的延迟写入覆盖新数据；0表示未携带token的写入
+ALTER TABLE `alipay_complaint`
+  ADD COLUMN `fence_token` bigint NOT NULL DEFAULT '0' COMMENT '最后一次写入时持有的fencing token';
+
+-- alipay_blacklist 的记录按买家由多个投诉和关联分析共享，写入时持有买家黑名单锁，记录该锁的fencing token
+ALTER TABLE `alipay_blacklist`
+  ADD COLUMN `fence_token` bigint NOT NULL DEFAULT '0' COMMENT '最后一次写入时持有的买家黑名单锁fencing token';
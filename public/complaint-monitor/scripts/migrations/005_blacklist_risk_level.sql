@@ -0,0 +1,6 @@
+-- 黑名单风险等级字段
+-- 拉黑时根据买家近24小时投诉次数、历史投诉次数、涉及金额和订单数评估（low/medium/high/critical），
+-- 已拉黑的记录再次触发时风险等级只升不降；决定Telegram通知优先级并作为 risk_level 指标标签
+ALTER TABLE `alipay_blacklist`
+  ADD COLUMN `risk_level` varchar(16) NOT NULL DEFAULT 'low' COMMENT '风险等级：low/medium/high/critical',
+  ADD KEY `idx_risk_level` (`risk_level`);
@@ -0,0 +1,23 @@
+-- 拉黑规则决策记录表
+-- 新投诉入库后按拉黑规则（blacklist_rules 配置）决定拉黑、人工审核或忽略，记录命中的规则
+-- action = review 的记录即待人工审核的投诉
+CREATE TABLE IF NOT EXISTS `alipay_blacklist_decision` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `subject_id` int NOT NULL COMMENT '主体ID',
+  `agent_id` int DEFAULT '0' COMMENT '代理商ID',
+  `alipay_task_id` varchar(64) NOT NULL COMMENT '支付宝投诉单号（TaskId）',
+  `complainant_id` varchar(64) DEFAULT NULL COMMENT '投诉人支付宝用户ID',
+  `complaint_status` varchar(32) DEFAULT NULL COMMENT '投诉状态',
+  `complaint_amount` decimal(15,2) DEFAULT '0.00' COMMENT '投诉涉及订单总金额',
+  `history_count` int DEFAULT '0' COMMENT '投诉人历史投诉次数（含本次）',
+  `action` varchar(16) NOT NULL COMMENT '决策动作：block/review/ignore',
+  `rule_name` varchar(64) DEFAULT NULL COMMENT '命中的规则名称（为空表示默认动作）',
+  `match_detail` varchar(255) DEFAULT NULL COMMENT '命中的条件',
+  `created_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_subject_id` (`subject_id`),
+  KEY `idx_alipay_task_id` (`alipay_task_id`),
+  KEY `idx_complainant_id` (`complainant_id`),
+  KEY `idx_action` (`action`),
+  KEY `idx_created_at` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='拉黑规则决策记录';
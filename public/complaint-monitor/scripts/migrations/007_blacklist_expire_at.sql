@@ -0,0 +1,16 @@
+-- 黑名单过期时间字段
+-- 按风险等级计算（blacklist 配置：低风险30天、中风险90天、高风险180天，严重风险永久有效），每次触发重新计算；
+-- 后台定期删除已过期的记录并推送解除通知。NULL 表示永久有效
+ALTER TABLE `alipay_blacklist`
+  ADD COLUMN `expire_at` datetime DEFAULT NULL COMMENT '过期时间（为空表示永久有效）',
+  ADD KEY `idx_expire_at` (`expire_at`);
+
+-- 存量记录按默认有效期从最后触发时间回填（如修改了 blacklist 配置，请同步调整天数）
+UPDATE `alipay_blacklist`
+SET `expire_at` = CASE `risk_level`
+    WHEN 'medium' THEN DATE_ADD(COALESCE(`last_risk_time`, `created_at`), INTERVAL 90 DAY)
+    WHEN 'high' THEN DATE_ADD(COALESCE(`last_risk_time`, `created_at`), INTERVAL 180 DAY)
+    WHEN 'critical' THEN NULL
+    ELSE DATE_ADD(COALESCE(`last_risk_time`, `created_at`), INTERVAL 30 DAY)
+  END
+WHERE `expire_at` IS NULL;
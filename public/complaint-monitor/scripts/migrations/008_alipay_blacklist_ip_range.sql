@@ -0,0 +1,17 @@
+-- 黑名单IP网段表
+-- 支持IPv4/IPv6 CIDR网段拉黑：管理接口手动添加，或同一网段内的黑名单IP达到阈值后自动升级（source = auto）
+-- 收银台黑名单查询和Redis镜像（blacklist:ip_range）均按网段匹配
+CREATE TABLE IF NOT EXISTS `alipay_blacklist_ip_range` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `cidr` varchar(64) NOT NULL COMMENT '网段（主机位已清零，如 1.2.3.0/24）',
+  `source` varchar(16) NOT NULL DEFAULT 'manual' COMMENT '来源：manual 手动添加 / auto 自动升级',
+  `ip_count` int DEFAULT '0' COMMENT '自动升级时网段内的黑名单IP数',
+  `risk_level` varchar(16) NOT NULL DEFAULT 'low' COMMENT '风险等级',
+  `remark` varchar(255) DEFAULT NULL COMMENT '备注信息',
+  `expire_at` datetime DEFAULT NULL COMMENT '过期时间（为空表示永久有效）',
+  `created_at` datetime DEFAULT NULL,
+  `updated_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_cidr` (`cidr`),
+  KEY `idx_expire_at` (`expire_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='黑名单IP网段';
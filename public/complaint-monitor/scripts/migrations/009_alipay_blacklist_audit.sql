@@ -0,0 +1,25 @@
+-- 黑名单变更审计表（只追加）
+-- 记录每次黑名单变更（新增、再次触发、撤诉减计数、解除、手动修改、导入）的操作方、来源投诉和变更前后的值
+-- hash = SHA-256(prev_hash 与各字段)，按 seq 连续成链，修改或删除任意记录都可通过 blacklist verify-audit 命令发现
+CREATE TABLE IF NOT EXISTS `alipay_blacklist_audit` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `seq` bigint NOT NULL COMMENT '链序号（从1开始连续递增）',
+  `blacklist_id` int unsigned DEFAULT '0' COMMENT '黑名单记录ID',
+  `alipay_user_id` varchar(64) DEFAULT NULL COMMENT '买家支付宝用户ID',
+  `device_code` varchar(128) DEFAULT NULL COMMENT '设备码',
+  `ip_address` varchar(64) DEFAULT NULL COMMENT 'IP地址',
+  `action` varchar(16) NOT NULL COMMENT '变更动作：insert/increment/decrement/unblock/edit/import',
+  `actor_type` varchar(16) NOT NULL COMMENT '操作方类型：system/operator/import',
+  `actor` varchar(128) DEFAULT NULL COMMENT '操作方（规则名、操作人ID、导入文件）',
+  `complaint_no` varchar(64) DEFAULT NULL COMMENT '来源投诉单号',
+  `before_value` text COMMENT '变更前的值（JSON）',
+  `after_value` text COMMENT '变更后的值（JSON）',
+  `reason` varchar(255) DEFAULT NULL COMMENT '变更原因',
+  `prev_hash` char(64) NOT NULL DEFAULT '' COMMENT '上一条记录的Hash',
+  `hash` char(64) NOT NULL COMMENT '本条记录的Hash',
+  `created_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_seq` (`seq`),
+  KEY `idx_blacklist_id` (`blacklist_id`),
+  KEY `idx_alipay_user_id` (`alipay_user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='黑名单变更审计（哈希链）';
@@ -0,0 +1,14 @@
+-- 买家白名单表
+-- 按支付宝用户ID、IP或设备码匹配，命中的买家投诉照常入库但不自动拉黑（投诉拉黑和关联分析拉黑均跳过）
+CREATE TABLE IF NOT EXISTS `alipay_buyer_allowlist` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `match_type` varchar(16) NOT NULL COMMENT '匹配类型：user 支付宝用户ID / ip IP地址 / device 设备码',
+  `value` varchar(128) NOT NULL COMMENT '支付宝用户ID、IP或设备码',
+  `reason` varchar(255) DEFAULT NULL COMMENT '加入白名单的原因',
+  `expire_at` datetime DEFAULT NULL COMMENT '过期时间（为空表示永久有效）',
+  `created_by` int DEFAULT '0' COMMENT '添加人ID',
+  `created_at` datetime DEFAULT NULL,
+  `updated_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_match` (`match_type`,`value`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='买家白名单';
@@ -0,0 +1,40 @@
+-- 黑名单唯一键字段
+-- 原唯一索引 (alipay_user_id, device_code, ip_address) 中 device_code/ip_address 可为 NULL，MySQL 认为 NULL 互不相等，
+-- 多实例并发拉黑同一买家时会插入重复记录。unique_key = SHA-256(alipay_user_id, device_code, ip_address)（空值按空字符串计算，
+-- 与 model.BlacklistUniqueKey 一致），服务按此唯一索引原子地新增或累加（INSERT ... ON DUPLICATE KEY UPDATE）
+-- unique_key 是由数据库计算的生成列（STORED）：其他系统（如PHP后台）新增记录或原地修改设备码/IP时同样会计算并受唯一索引约束，
+-- 写入方不需要（也不能）写入该字段
+
+-- 先把已有的重复记录合并到最早的一条：风险计数、最后触发时间取最大值，风险等级取最高，过期时间取最晚（有永久有效则为永久）
+UPDATE `alipay_blacklist` b
+JOIN (
+  SELECT SHA2(CONCAT(`alipay_user_id`, CHAR(31), IFNULL(`device_code`, ''), CHAR(31), IFNULL(`ip_address`, '')), 256) AS `unique_key`,
+    MIN(`id`) AS `keep_id`,
+    MAX(`risk_count`) AS `risk_count`,
+    MAX(`last_risk_time`) AS `last_risk_time`,
+    ELT(GREATEST(MAX(FIELD(`risk_level`, 'low', 'medium', 'high', 'critical')), 1), 'low', 'medium', 'high', 'critical') AS `risk_level`,
+    IF(SUM(`expire_at` IS NULL) > 0, NULL, MAX(`expire_at`)) AS `expire_at`
+  FROM `alipay_blacklist`
+  GROUP BY `unique_key`
+  HAVING COUNT(*) > 1
+) d ON b.`id` = d.`keep_id`
+SET b.`risk_count` = d.`risk_count`,
+  b.`last_risk_time` = d.`last_risk_time`,
+  b.`risk_level` = d.`risk_level`,
+  b.`expire_at` = d.`expire_at`;
+
+DELETE b FROM `alipay_blacklist` b
+JOIN (
+  SELECT SHA2(CONCAT(`alipay_user_id`, CHAR(31), IFNULL(`device_code`, ''), CHAR(31), IFNULL(`ip_address`, '')), 256) AS `unique_key`,
+    MIN(`id`) AS `keep_id`
+  FROM `alipay_blacklist`
+  GROUP BY `unique_key`
+  HAVING COUNT(*) > 1
+) d ON SHA2(CONCAT(b.`alipay_user_id`, CHAR(31), IFNULL(b.`device_code`, ''), CHAR(31), IFNULL(b.`ip_address`, '')), 256) = d.`unique_key`
+  AND b.`id` <> d.`keep_id`;
+
+ALTER TABLE `alipay_blacklist`
+  ADD COLUMN `unique_key` char(64)
+    GENERATED ALWAYS AS (SHA2(CONCAT(`alipay_user_id`, CHAR(31), IFNULL(`device_code`, ''), CHAR(31), IFNULL(`ip_address`, '')), 256)) STORED
+    COMMENT '唯一键：SHA-256(买家ID, 设备码, IP)（生成列）' AFTER `ip_address`,
+  ADD UNIQUE KEY `uk_unique_key` (`unique_key`);
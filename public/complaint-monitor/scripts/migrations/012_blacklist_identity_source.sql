@@ -0,0 +1,7 @@
+-- 黑名单买家身份来源字段
+-- 记录拉黑的买家ID从何处得到：order（订单表 buyer_id）、trade_query（支付宝交易查询 buyer_user_id）、
+-- complainant（投诉详情中的投诉人字段，已校验不是任何主体的 alipay_pid）、buyer_graph（关联分析）、import（批量导入）；
+-- 新增时写入，再次触发不覆盖。存量记录为空表示未知
+ALTER TABLE `alipay_blacklist`
+  ADD COLUMN `identity_source` varchar(16) NOT NULL DEFAULT '' COMMENT '买家身份来源：order/trade_query/complainant/buyer_graph/import' AFTER `expire_at`,
+  ADD KEY `idx_identity_source` (`identity_source`);
@@ -0,0 +1,42 @@
+-- 投诉买家身份表
+-- 投诉入库时记录解析出的买家支付宝用户ID（订单 buyer_id → 交易查询 → 投诉人字段，已排除主体PID）。
+-- complainant_id 取自支付宝的 opposite_pid，可能是商户自身的PID，买家投诉画像、近期投诉次数和撤诉时的未结束投诉统计改为按本表汇总
+CREATE TABLE IF NOT EXISTS `alipay_complaint_buyer` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `complaint_id` int unsigned NOT NULL COMMENT '投诉主表ID',
+  `subject_id` int NOT NULL COMMENT '主体ID',
+  `alipay_task_id` varchar(64) NOT NULL COMMENT '支付宝投诉单号（TaskId）',
+  `buyer_id` varchar(64) NOT NULL COMMENT '买家支付宝用户ID',
+  `identity_source` varchar(16) NOT NULL DEFAULT '' COMMENT '身份来源：order/trade_query/complainant',
+  `created_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_complaint_buyer` (`complaint_id`, `buyer_id`),
+  KEY `idx_subject_id` (`subject_id`),
+  KEY `idx_alipay_task_id` (`alipay_task_id`),
+  KEY `idx_buyer_id` (`buyer_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='投诉买家身份';
+
+-- 回填存量投诉：先取投诉订单在订单表中的已支付买家（排除任何主体的 alipay_pid）
+INSERT IGNORE INTO `alipay_complaint_buyer` (`complaint_id`, `subject_id`, `alipay_task_id`, `buyer_id`, `identity_source`, `created_at`)
+SELECT DISTINCT c.`id`, c.`subject_id`, c.`alipay_task_id`, o.`buyer_id`, 'order', NOW()
+FROM `alipay_complaint` c
+JOIN `alipay_complaint_detail` d ON d.`complaint_id` = c.`id`
+JOIN `order` o ON o.`merchant_order_no` = d.`merchant_order_no`
+WHERE o.`pay_status` = 1 AND o.`buyer_id` <> ''
+  AND o.`buyer_id` NOT IN (SELECT `alipay_pid` FROM `subject` WHERE `alipay_pid` IS NOT NULL AND `alipay_pid` <> '');
+
+INSERT IGNORE INTO `alipay_complaint_buyer` (`complaint_id`, `subject_id`, `alipay_task_id`, `buyer_id`, `identity_source`, `created_at`)
+SELECT DISTINCT c.`id`, c.`subject_id`, c.`alipay_task_id`, o.`buyer_id`, 'order', NOW()
+FROM `alipay_complaint` c
+JOIN `alipay_complaint_detail` d ON d.`complaint_id` = c.`id`
+JOIN `order` o ON o.`alipay_order_no` = d.`platform_order_no`
+WHERE d.`platform_order_no` <> '' AND o.`pay_status` = 1 AND o.`buyer_id` <> ''
+  AND o.`buyer_id` NOT IN (SELECT `alipay_pid` FROM `subject` WHERE `alipay_pid` IS NOT NULL AND `alipay_pid` <> '');
+
+-- 订单表查不到买家的投诉使用投诉人字段（需符合支付宝用户ID格式，且不是任何主体的 alipay_pid）
+INSERT IGNORE INTO `alipay_complaint_buyer` (`complaint_id`, `subject_id`, `alipay_task_id`, `buyer_id`, `identity_source`, `created_at`)
+SELECT c.`id`, c.`subject_id`, c.`alipay_task_id`, c.`complainant_id`, 'complainant', NOW()
+FROM `alipay_complaint` c
+WHERE c.`complainant_id` REGEXP '^2088[0-9]{12}$'
+  AND c.`complainant_id` NOT IN (SELECT `alipay_pid` FROM `subject` WHERE `alipay_pid` IS NOT NULL AND `alipay_pid` <> '')
+  AND NOT EXISTS (SELECT 1 FROM `alipay_complaint_buyer` b WHERE b.`complaint_id` = c.`id`);
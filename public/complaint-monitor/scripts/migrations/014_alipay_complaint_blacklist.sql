@@ -0,0 +1,23 @@
+-- 投诉拉黑记录表
+-- 投诉触发拉黑时记录新增或累加的黑名单记录ID，撤诉时只撤销这些记录，
+-- 不影响同一买家由其他投诉、导入或关联分析写入的记录（不同IP、设备码）
+CREATE TABLE IF NOT EXISTS `alipay_complaint_blacklist` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `complaint_id` int unsigned NOT NULL COMMENT '投诉主表ID',
+  `subject_id` int NOT NULL COMMENT '主体ID',
+  `alipay_task_id` varchar(64) NOT NULL COMMENT '支付宝投诉单号（TaskId）',
+  `blacklist_id` int unsigned NOT NULL COMMENT '黑名单记录ID',
+  `action` varchar(16) NOT NULL COMMENT '拉黑动作：insert/increment',
+  `created_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_complaint_blacklist` (`complaint_id`, `blacklist_id`),
+  KEY `idx_blacklist_id` (`blacklist_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='投诉拉黑记录';
+
+-- 回填存量投诉：黑名单审计中投诉拉黑的新增和累加记录（complaint_no 为支付宝投诉单号），只保留仍存在的黑名单记录
+INSERT IGNORE INTO `alipay_complaint_blacklist` (`complaint_id`, `subject_id`, `alipay_task_id`, `blacklist_id`, `action`, `created_at`)
+SELECT c.`id`, c.`subject_id`, c.`alipay_task_id`, a.`blacklist_id`, a.`action`, a.`created_at`
+FROM `alipay_blacklist_audit` a
+JOIN `alipay_complaint` c ON c.`alipay_task_id` = a.`complaint_no`
+JOIN `alipay_blacklist` b ON b.`id` = a.`blacklist_id`
+WHERE a.`action` IN ('insert', 'increment') AND a.`complaint_no` <> '';
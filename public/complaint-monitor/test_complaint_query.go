@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -9,6 +10,7 @@ import (
 
 	"complaint-monitor/internal/cert"
 	"complaint-monitor/internal/config"
+	"complaint-monitor/internal/gateway"
 	"complaint-monitor/internal/logger"
 	"complaint-monitor/internal/repository"
 	"complaint-monitor/internal/service"
@@ -61,14 +63,14 @@ func main() {
 		loggerInstance,
 	)
 
-	// 加载证书
-	client, err := certManager.LoadCert(subject)
+	// 加载证书并创建投诉网关
+	gw, err := certManager.Gateway(subject)
 	if err != nil {
 		log.Fatalf("加载证书失败: %v", err)
 	}
 
 	// 初始化AlipayService
-	alipayService := service.NewAlipayService(loggerInstance)
+	alipayService := service.NewAlipayService(nil, loggerInstance)
 
 	// 测试多个时间范围和查询条件
 	testCases := []struct {
@@ -121,7 +123,7 @@ func main() {
 		fmt.Printf("\n")
 
 		// 构建请求
-		listReq := service.ComplaintListRequest{
+		listReq := gateway.ComplaintListRequest{
 			BeginTime: testCase.beginTime,
 			EndTime:   testCase.endTime,
 			PageNum:   1,
@@ -130,7 +132,7 @@ func main() {
 
 		// 调用投诉列表API
 		fmt.Printf("开始调用投诉列表API...\n")
-		listResp, err := alipayService.FetchComplaintList(client, listReq)
+		listResp, err := alipayService.FetchComplaintList(context.Background(), gw, listReq)
 		if err != nil {
 			fmt.Printf("❌ 调用失败: %v\n", err)
 			fmt.Printf("继续测试下一个用例...\n\n")